@@ -0,0 +1,100 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate
+
+import (
+	"context"
+	"time"
+)
+
+// An OverloadError is returned by AdmissionController.Acquire
+// when a request is rejected.
+type OverloadError struct {
+	// Timeout is true if the request waited in the queue and timed out,
+	// and false if it was rejected because the queue was full.
+	Timeout bool
+}
+
+func (e *OverloadError) Error() string {
+	if e.Timeout {
+		return "gate: overloaded: timed out in wait queue"
+	}
+	return "gate: overloaded: wait queue full"
+}
+
+// An AdmissionController protects a resource from overload.
+// It admits a limited number of concurrent requests.
+// When the limit is reached, a bounded number of further requests
+// wait a limited time to be admitted, and the rest are rejected immediately.
+type AdmissionController struct {
+	sem      *Semaphore
+	timeout  time.Duration
+	clock    Clock
+	mu       Gate // guards the fields below
+	maxQueue int
+	queued   int
+}
+
+// NewAdmissionController returns a controller admitting up to limit concurrent requests,
+// with at most maxQueue requests waiting for up to timeout each.
+func NewAdmissionController(limit, maxQueue int, timeout time.Duration, opts ...Option) *AdmissionController {
+	o := newOptions(opts)
+	return &AdmissionController{
+		sem:      NewSemaphore(limit, o.fieldOptions("sem")...),
+		timeout:  timeout,
+		clock:    o.clock,
+		mu:       o.newGate(false, ""),
+		maxQueue: maxQueue,
+	}
+}
+
+// Acquire admits a request, waiting in the queue if necessary.
+// It returns an *OverloadError if the request is rejected,
+// or the context's error if the context expires while waiting.
+// Each successful call to Acquire must be matched by a call to Release.
+func (a *AdmissionController) Acquire(ctx context.Context) error {
+	a.mu.Lock()
+	// Requests are admitted without waiting only when none are queued,
+	// so that new requests do not overtake queued ones.
+	if a.queued == 0 && a.sem.TryAcquire() {
+		a.mu.Unlock(false)
+		return nil
+	}
+	if a.queued >= a.maxQueue {
+		a.mu.Unlock(false)
+		return &OverloadError{}
+	}
+	a.queued++
+	a.mu.Unlock(false)
+
+	wctx, cancel := context.WithCancel(ctx)
+	t := a.clock.AfterFunc(a.timeout, cancel)
+	err := a.sem.Acquire(wctx)
+	t.Stop()
+	cancel()
+
+	a.mu.Lock()
+	a.queued--
+	a.mu.Unlock(false)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return &OverloadError{Timeout: true}
+	}
+	return nil
+}
+
+// Release ends an admitted request.
+func (a *AdmissionController) Release() {
+	a.sem.Release()
+}
+
+// Queued returns the number of requests waiting to be admitted.
+func (a *AdmissionController) Queued() int {
+	a.mu.Lock()
+	defer a.mu.Unlock(false)
+	return a.queued
+}
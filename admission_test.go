@@ -0,0 +1,66 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/neild/gate"
+)
+
+func TestAdmissionController(t *testing.T) {
+	a := gate.NewAdmissionController(1, 1, 1*time.Hour)
+	if err := a.Acquire(context.Background()); err != nil {
+		t.Fatalf("first a.Acquire = %v, want nil", err)
+	}
+
+	// The second request waits in the queue.
+	donec := make(chan error)
+	go func() {
+		donec <- a.Acquire(context.Background())
+	}()
+	for a.Queued() == 0 {
+		time.Sleep(1 * time.Millisecond)
+	}
+
+	// The third request is rejected.
+	err := a.Acquire(context.Background())
+	var oerr *gate.OverloadError
+	if !errors.As(err, &oerr) || oerr.Timeout {
+		t.Fatalf("a.Acquire with full queue = %v, want OverloadError{Timeout: false}", err)
+	}
+
+	a.Release()
+	if err := <-donec; err != nil {
+		t.Fatalf("queued a.Acquire = %v, want nil", err)
+	}
+	a.Release()
+}
+
+func TestAdmissionControllerTimeout(t *testing.T) {
+	a := gate.NewAdmissionController(1, 1, 1*time.Millisecond)
+	a.Acquire(context.Background())
+	err := a.Acquire(context.Background())
+	var oerr *gate.OverloadError
+	if !errors.As(err, &oerr) || !oerr.Timeout {
+		t.Fatalf("a.Acquire timing out in queue = %v, want OverloadError{Timeout: true}", err)
+	}
+	if got := a.Queued(); got != 0 {
+		t.Fatalf("a.Queued after timeout = %v, want 0", got)
+	}
+}
+
+func TestAdmissionControllerContextExpired(t *testing.T) {
+	a := gate.NewAdmissionController(1, 1, 1*time.Hour)
+	a.Acquire(context.Background())
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Millisecond)
+	defer cancel()
+	if err := a.Acquire(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("a.Acquire with expired context = %v, want context.DeadlineExceeded", err)
+	}
+}
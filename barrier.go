@@ -0,0 +1,79 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrBarrierBroken is returned by Barrier.Wait when another party
+// waiting at the barrier gave up before all parties arrived.
+var ErrBarrierBroken = errors.New("gate: barrier broken")
+
+// A Barrier is a reusable synchronization point for a fixed number of parties.
+// Calls to Wait block until all parties have arrived,
+// at which point they are all released and the barrier resets for the next round.
+//
+// If a party's context expires while it is waiting, the barrier breaks:
+// every party waiting in the same round, and any party arriving later,
+// receives ErrBarrierBroken until Reset is called.
+type Barrier struct {
+	mu      Gate // guards the fields below
+	parties int
+	arrived int
+	round   *Event // set when the current round is released or broken
+}
+
+// NewBarrier returns a new barrier for n parties.
+func NewBarrier(n int) *Barrier {
+	return &Barrier{
+		mu:      New(false),
+		parties: n,
+		round:   NewEvent(),
+	}
+}
+
+// Wait blocks until all parties have called Wait or the context expires.
+func (b *Barrier) Wait(ctx context.Context) error {
+	b.mu.Lock()
+	round := b.round
+	if round.IsSet() {
+		// The barrier is broken.
+		b.mu.Unlock(false)
+		return round.Wait(ctx)
+	}
+	b.arrived++
+	if b.arrived == b.parties {
+		round.Set()
+		b.arrived = 0
+		b.round = NewEvent()
+		b.mu.Unlock(false)
+		return nil
+	}
+	b.mu.Unlock(false)
+
+	err := round.Wait(ctx)
+	if err == nil || err == ErrBarrierBroken {
+		return err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock(false)
+	if round.SetError(ErrBarrierBroken) {
+		return err
+	}
+	// The round was released or broken before we could break it.
+	return round.Wait(context.Background())
+}
+
+// Reset repairs a broken barrier.
+// Any parties waiting at the barrier receive ErrBarrierBroken.
+func (b *Barrier) Reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock(false)
+	b.round.SetError(ErrBarrierBroken)
+	b.arrived = 0
+	b.round = NewEvent()
+}
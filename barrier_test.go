@@ -0,0 +1,60 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/neild/gate"
+)
+
+func TestBarrier(t *testing.T) {
+	const parties = 3
+	b := gate.NewBarrier(parties)
+	for round := 0; round < 3; round++ {
+		var wg sync.WaitGroup
+		for i := 0; i < parties; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if err := b.Wait(context.Background()); err != nil {
+					t.Errorf("round %v: b.Wait = %v, want nil", round, err)
+				}
+			}()
+		}
+		wg.Wait()
+	}
+}
+
+func TestBarrierBroken(t *testing.T) {
+	b := gate.NewBarrier(3)
+	waitc := make(chan error)
+	go func() {
+		waitc <- b.Wait(context.Background())
+	}()
+	time.Sleep(1 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Millisecond)
+	defer cancel()
+	if err := b.Wait(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("b.Wait with expiring context = %v, want context.DeadlineExceeded", err)
+	}
+	if err := <-waitc; err != gate.ErrBarrierBroken {
+		t.Fatalf("b.Wait in broken round = %v, want ErrBarrierBroken", err)
+	}
+	if err := b.Wait(context.Background()); err != gate.ErrBarrierBroken {
+		t.Fatalf("b.Wait after break = %v, want ErrBarrierBroken", err)
+	}
+
+	b.Reset()
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Millisecond)
+	defer cancel()
+	if err := b.Wait(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("b.Wait after Reset = %v, want context.DeadlineExceeded", err)
+	}
+}
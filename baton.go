@@ -0,0 +1,51 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate
+
+import "context"
+
+// A Baton passes turns among a fixed set of participants in round-robin order.
+// Participants are numbered from zero, and participant zero has the first turn.
+//
+// With two participants, a Baton enforces strict alternation,
+// as in a lock-step protocol between a reader and a writer.
+type Baton struct {
+	turns []Gate // turns[i] is set if it is participant i's turn
+}
+
+// NewBaton returns a new baton for n participants.
+func NewBaton(n int) *Baton {
+	b := &Baton{
+		turns: make([]Gate, n),
+	}
+	for i := range b.turns {
+		b.turns[i] = New(i == 0)
+	}
+	return b
+}
+
+// Wait blocks until it is participant p's turn or the context expires.
+func (b *Baton) Wait(ctx context.Context, p int) error {
+	g := &b.turns[p]
+	if err := g.WaitAndLock(ctx); err != nil {
+		return err
+	}
+	g.Unlock(true)
+	return nil
+}
+
+// Pass ends participant p's turn, giving the next turn to the following participant.
+// It panics if it is not p's turn.
+func (b *Baton) Pass(p int) {
+	g := &b.turns[p]
+	if set := g.Lock(); !set {
+		g.Unlock(false)
+		panic("gate: Baton passed out of turn")
+	}
+	g.Unlock(false)
+	next := &b.turns[(p+1)%len(b.turns)]
+	next.Lock()
+	next.Unlock(true)
+}
@@ -0,0 +1,68 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate_test
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/neild/gate"
+)
+
+func TestBaton(t *testing.T) {
+	const (
+		participants = 3
+		rounds       = 10
+	)
+	b := gate.NewBaton(participants)
+	var (
+		mu    sync.Mutex
+		order []int
+		wg    sync.WaitGroup
+	)
+	for p := 0; p < participants; p++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < rounds; i++ {
+				b.Wait(context.Background(), p)
+				mu.Lock()
+				order = append(order, p)
+				mu.Unlock()
+				b.Pass(p)
+			}
+		}()
+	}
+	wg.Wait()
+	var want []int
+	for i := 0; i < rounds; i++ {
+		want = append(want, 0, 1, 2)
+	}
+	if !slices.Equal(order, want) {
+		t.Fatalf("turn order = %v, want %v", order, want)
+	}
+}
+
+func TestBatonWaitContextExpired(t *testing.T) {
+	b := gate.NewBaton(2)
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Millisecond)
+	defer cancel()
+	if err := b.Wait(ctx, 1); err != context.DeadlineExceeded {
+		t.Fatalf("b.Wait(1) out of turn = %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestBatonPassOutOfTurn(t *testing.T) {
+	b := gate.NewBaton(2)
+	defer func() {
+		if recover() == nil {
+			t.Errorf("b.Pass(1) out of turn did not panic")
+		}
+	}()
+	b.Pass(1)
+}
@@ -0,0 +1,212 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate
+
+import (
+	"errors"
+	"slices"
+	"time"
+)
+
+// ErrCircuitOpen is returned by CircuitBreaker when a request is rejected.
+var ErrCircuitOpen = errors.New("gate: circuit breaker open")
+
+// A BreakerState is the state of a CircuitBreaker.
+type BreakerState int
+
+const (
+	// BreakerClosed admits all requests.
+	BreakerClosed BreakerState = iota
+	// BreakerOpen rejects all requests.
+	BreakerOpen
+	// BreakerHalfOpen admits a limited number of probe requests.
+	BreakerHalfOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// A CircuitBreaker stops sending requests to a failing resource.
+//
+// The breaker starts closed, admitting all requests.
+// After a number of consecutive failures it opens, rejecting all requests.
+// Once a cooldown period has passed, it becomes half-open and admits a fixed number of probes,
+// while rejecting other requests.
+// If every probe succeeds the breaker closes; if any fails, it opens again.
+type CircuitBreaker struct {
+	mu        Gate // guards the fields below
+	clock     Clock
+	threshold int
+	cooldown  time.Duration
+	probes    int
+	state     BreakerState
+	gen       uint64     // incremented on each state change
+	failures  int        // consecutive failures while closed
+	openedAt  time.Time  // time the breaker last opened
+	timer     Timer      // moves an open breaker to half-open after the cooldown
+	admit     *Semaphore // probe admission while half-open
+	admitOpts []Option   // options for admit
+	succeeded int        // successful probes while half-open
+	hooks     []func(from, to BreakerState)
+	pending   [][2]BreakerState // state changes not yet passed to hooks
+}
+
+// NewCircuitBreaker returns a new, closed breaker which opens after threshold
+// consecutive failures, and admits probes requests after being open for cooldown.
+// The threshold and probes must be positive.
+func NewCircuitBreaker(threshold int, cooldown time.Duration, probes int, opts ...Option) *CircuitBreaker {
+	if threshold <= 0 {
+		panic("gate: circuit breaker threshold must be positive")
+	}
+	if probes <= 0 {
+		panic("gate: circuit breaker probes must be positive")
+	}
+	o := newOptions(opts)
+	return &CircuitBreaker{
+		mu:        o.newGate(false, ""),
+		clock:     o.clock,
+		threshold: threshold,
+		cooldown:  cooldown,
+		probes:    probes,
+		admitOpts: o.fieldOptions("admit"),
+	}
+}
+
+// Allow asks the breaker to admit a request.
+// If the request is admitted, the caller must call done with
+// whether the request succeeded.
+// If it is rejected, Allow returns ErrCircuitOpen.
+func (b *CircuitBreaker) Allow() (done func(success bool), err error) {
+	b.mu.Lock()
+	defer b.unlock()
+	b.advance(b.clock.Now())
+	switch b.state {
+	case BreakerOpen:
+		return nil, ErrCircuitOpen
+	case BreakerHalfOpen:
+		if !b.admit.TryAcquire() {
+			return nil, ErrCircuitOpen
+		}
+	}
+	gen := b.gen
+	return func(success bool) {
+		b.done(gen, success)
+	}, nil
+}
+
+// Do calls fn if the breaker admits the request, recording a failure if fn returns an error.
+// It returns ErrCircuitOpen if the request is rejected, and fn's error otherwise.
+func (b *CircuitBreaker) Do(fn func() error) error {
+	done, err := b.Allow()
+	if err != nil {
+		return err
+	}
+	err = fn()
+	done(err == nil)
+	return err
+}
+
+// State returns the breaker's current state.
+func (b *CircuitBreaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.unlock()
+	b.advance(b.clock.Now())
+	return b.state
+}
+
+// OnStateChange registers a function to be called after every change of state.
+// The function is called without the breaker's gate held.
+// An open breaker becomes half-open when its cooldown passes,
+// even if no request is made.
+func (b *CircuitBreaker) OnStateChange(f func(from, to BreakerState)) {
+	b.mu.Lock()
+	defer b.unlock()
+	b.hooks = append(slices.Clip(b.hooks), f)
+}
+
+// done records the result of a request admitted in generation gen.
+// Results of requests admitted before the last state change are ignored.
+func (b *CircuitBreaker) done(gen uint64, success bool) {
+	b.mu.Lock()
+	defer b.unlock()
+	if gen != b.gen {
+		return
+	}
+	switch b.state {
+	case BreakerClosed:
+		if success {
+			b.failures = 0
+			return
+		}
+		b.failures++
+		if b.failures >= b.threshold {
+			b.setState(BreakerOpen, b.clock.Now())
+		}
+	case BreakerHalfOpen:
+		if !success {
+			b.setState(BreakerOpen, b.clock.Now())
+			return
+		}
+		b.succeeded++
+		if b.succeeded >= b.probes {
+			b.setState(BreakerClosed, b.clock.Now())
+		}
+	}
+}
+
+// advance moves an open breaker to half-open once its cooldown has passed.
+func (b *CircuitBreaker) advance(now time.Time) {
+	if b.state == BreakerOpen && now.Sub(b.openedAt) >= b.cooldown {
+		b.setState(BreakerHalfOpen, now)
+	}
+}
+
+func (b *CircuitBreaker) setState(to BreakerState, now time.Time) {
+	b.pending = append(b.pending, [2]BreakerState{b.state, to})
+	b.state = to
+	b.gen++
+	b.failures = 0
+	switch to {
+	case BreakerOpen:
+		b.openedAt = now
+		if b.timer == nil {
+			b.timer = b.clock.AfterFunc(b.cooldown, b.expireCooldown)
+		} else {
+			b.timer.Reset(b.cooldown)
+		}
+	case BreakerHalfOpen:
+		b.admit = NewSemaphore(b.probes, b.admitOpts...)
+		b.succeeded = 0
+	}
+}
+
+// expireCooldown moves the breaker to half-open when its cooldown passes.
+func (b *CircuitBreaker) expireCooldown() {
+	b.mu.Lock()
+	defer b.unlock()
+	b.advance(b.clock.Now())
+}
+
+// unlock unlocks the breaker's gate and calls hooks for any state changes.
+func (b *CircuitBreaker) unlock() {
+	pending := b.pending
+	b.pending = nil
+	hooks := b.hooks
+	b.mu.Unlock(false)
+	for _, p := range pending {
+		for _, f := range hooks {
+			f(p[0], p[1])
+		}
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate_test
+
+import (
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/neild/gate"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	b := gate.NewCircuitBreaker(2, 1*time.Millisecond, 2)
+	var (
+		mu      sync.Mutex
+		changes []gate.BreakerState
+	)
+	b.OnStateChange(func(from, to gate.BreakerState) {
+		mu.Lock()
+		defer mu.Unlock()
+		changes = append(changes, to)
+	})
+	fail := func() error { return errors.New("failed") }
+	succeed := func() error { return nil }
+
+	b.Do(fail)
+	if got, want := b.State(), gate.BreakerClosed; got != want {
+		t.Fatalf("after one failure, b.State() = %v, want %v", got, want)
+	}
+	b.Do(fail)
+	if got, want := b.State(), gate.BreakerOpen; got != want {
+		t.Fatalf("after two failures, b.State() = %v, want %v", got, want)
+	}
+	if err := b.Do(succeed); err != gate.ErrCircuitOpen {
+		t.Fatalf("b.Do while open = %v, want %v", err, gate.ErrCircuitOpen)
+	}
+
+	time.Sleep(2 * time.Millisecond)
+	if got, want := b.State(), gate.BreakerHalfOpen; got != want {
+		t.Fatalf("after cooldown, b.State() = %v, want %v", got, want)
+	}
+	// Exactly two probes are admitted.
+	done1, err := b.Allow()
+	if err != nil {
+		t.Fatalf("first probe b.Allow = %v, want nil", err)
+	}
+	done2, err := b.Allow()
+	if err != nil {
+		t.Fatalf("second probe b.Allow = %v, want nil", err)
+	}
+	if _, err := b.Allow(); err != gate.ErrCircuitOpen {
+		t.Fatalf("third probe b.Allow = %v, want %v", err, gate.ErrCircuitOpen)
+	}
+	done1(true)
+	done2(true)
+	if got, want := b.State(), gate.BreakerClosed; got != want {
+		t.Fatalf("after successful probes, b.State() = %v, want %v", got, want)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := []gate.BreakerState{gate.BreakerOpen, gate.BreakerHalfOpen, gate.BreakerClosed}
+	if !slices.Equal(changes, want) {
+		t.Fatalf("state changes = %v, want %v", changes, want)
+	}
+}
+
+func TestCircuitBreakerProbeFails(t *testing.T) {
+	b := gate.NewCircuitBreaker(1, 1*time.Millisecond, 2)
+	b.Do(func() error { return errors.New("failed") })
+	time.Sleep(2 * time.Millisecond)
+	done, err := b.Allow()
+	if err != nil {
+		t.Fatalf("probe b.Allow = %v, want nil", err)
+	}
+	done(false)
+	if got, want := b.State(), gate.BreakerOpen; got != want {
+		t.Fatalf("after failed probe, b.State() = %v, want %v", got, want)
+	}
+}
+
+func TestCircuitBreakerStaleResult(t *testing.T) {
+	b := gate.NewCircuitBreaker(1, 1*time.Hour, 1)
+	done, _ := b.Allow()
+	b.Do(func() error { return errors.New("failed") })
+	// A request admitted before the breaker opened does not affect it.
+	done(true)
+	if got, want := b.State(), gate.BreakerOpen; got != want {
+		t.Fatalf("after stale success, b.State() = %v, want %v", got, want)
+	}
+}
+
+func TestCircuitBreakerHalfOpenHook(t *testing.T) {
+	clock := gate.NewFakeClock(time.Now())
+	b := gate.NewCircuitBreaker(1, 1*time.Minute, 1, gate.WithClock(clock))
+	var changes []gate.BreakerState
+	b.OnStateChange(func(from, to gate.BreakerState) {
+		changes = append(changes, to)
+	})
+	b.Do(func() error { return errors.New("failed") })
+	// The breaker reports becoming half-open without a call to Allow or State.
+	clock.Advance(1 * time.Minute)
+	want := []gate.BreakerState{gate.BreakerOpen, gate.BreakerHalfOpen}
+	if !slices.Equal(changes, want) {
+		t.Fatalf("state changes = %v, want %v", changes, want)
+	}
+}
@@ -0,0 +1,213 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+
+	"github.com/neild/gate"
+)
+
+// A Broadcast delivers every published item to each of its subscribers.
+type Broadcast[T any] struct {
+	pubMu sync.Mutex // serializes Publish, so subscribers see items in the same order
+	mu    sync.Mutex // guards err and subs
+	err   error
+	subs  []*Subscriber[T]
+}
+
+// A SlowPolicy determines what happens when a subscriber's buffer is full.
+type SlowPolicy int
+
+const (
+	// BlockPublisher causes Publish to wait for the subscriber to catch up.
+	BlockPublisher SlowPolicy = iota
+	// DropSubscriber causes the subscriber to be closed with ErrSubscriberDropped.
+	DropSubscriber
+)
+
+// ErrSubscriberDropped is returned by Subscriber.Get after a slow subscriber is dropped.
+var ErrSubscriberDropped = errors.New("subscriber dropped")
+
+// A Subscriber receives items from a Broadcast.
+type Subscriber[T any] struct {
+	gate   gate.Gate // set if buffer is non-empty or closed
+	max    int
+	policy SlowPolicy
+	err    error
+	q      []T
+	drainc chan struct{} // closed when an item is removed or the subscriber is closed
+}
+
+// Subscribe adds a new subscriber which receives all items published after this call.
+// The subscriber buffers at most max unread items, or an unlimited number if max is zero.
+// The policy determines what happens when the buffer is full.
+func (b *Broadcast[T]) Subscribe(max int, policy SlowPolicy) *Subscriber[T] {
+	s := &Subscriber[T]{
+		gate:   gate.New(false),
+		max:    max,
+		policy: policy,
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.err != nil {
+		s.Close(b.err)
+		return s
+	}
+	b.subs = append(b.subs, s)
+	return s
+}
+
+// Publish delivers an item to every subscriber.
+// It blocks until ctx is done or every subscriber with the BlockPublisher policy
+// has room for the item.
+// If ctx is done, the item may have been delivered to some subscribers but not others.
+//
+// Publish does not hold the lock guarding the subscribers while it waits for one,
+// so Subscribe and Close do not block behind a slow subscriber.
+// Closing the broadcast closes every subscriber, waking a blocked Publish.
+func (b *Broadcast[T]) Publish(ctx context.Context, v T) error {
+	b.pubMu.Lock()
+	defer b.pubMu.Unlock()
+	b.mu.Lock()
+	if b.err != nil {
+		b.mu.Unlock()
+		return b.err
+	}
+	subs := slices.Clone(b.subs)
+	b.mu.Unlock()
+	for _, s := range subs {
+		if err := s.put(ctx, v); err != nil {
+			return err
+		}
+	}
+	// Forget about any subscribers which have been closed or dropped.
+	b.mu.Lock()
+	b.subs = slices.DeleteFunc(b.subs, (*Subscriber[T]).closed)
+	b.mu.Unlock()
+	return nil
+}
+
+// Close closes the broadcast and all its subscribers,
+// causing pending and future Get operations to return err, or io.EOF if err is nil.
+func (b *Broadcast[T]) Close(err error) {
+	if err == nil {
+		err = io.EOF
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.err == nil {
+		b.err = err
+	}
+	for _, s := range b.subs {
+		s.Close(err)
+	}
+	b.subs = nil
+}
+
+// Get removes the next item from the subscriber's buffer, blocking until ctx is done,
+// an item is available, or the subscriber is closed.
+func (s *Subscriber[T]) Get(ctx context.Context) (T, error) {
+	var zero T
+	if err := s.gate.WaitAndLock(ctx); err != nil {
+		return zero, err
+	}
+	defer s.unlock()
+	if s.err != nil {
+		return zero, s.err
+	}
+	v := s.q[0]
+	s.q = slices.Delete(s.q, 0, 1)
+	s.drained()
+	return v, nil
+}
+
+// Close unsubscribes, causing pending and future Get operations to return err,
+// or io.EOF if err is nil.
+func (s *Subscriber[T]) Close(err error) {
+	if err == nil {
+		err = io.EOF
+	}
+	s.gate.Lock()
+	defer s.unlock()
+	if s.err == nil {
+		s.err = err
+	}
+	s.drained()
+}
+
+// put adds an item to the subscriber's buffer, applying the subscriber's policy if it is full.
+func (s *Subscriber[T]) put(ctx context.Context, v T) error {
+	for {
+		s.gate.Lock()
+		if s.err != nil {
+			s.unlock()
+			return nil
+		}
+		if s.max == 0 || len(s.q) < s.max {
+			s.q = append(s.q, v)
+			s.unlock()
+			return nil
+		}
+		if s.policy == DropSubscriber {
+			s.err = ErrSubscriberDropped
+			s.unlock()
+			return nil
+		}
+		if s.drainc == nil {
+			s.drainc = make(chan struct{})
+		}
+		drainc := s.drainc
+		s.unlock()
+		if err := s.gate.WaitFor(ctx, drainc); err != nil {
+			return err
+		}
+	}
+}
+
+// closed reports whether the subscriber has been closed.
+func (s *Subscriber[T]) closed() bool {
+	s.gate.Lock()
+	defer s.unlock()
+	return s.err != nil
+}
+
+// drained wakes a Publish call waiting for room in the buffer.
+// The subscriber's gate must be held.
+func (s *Subscriber[T]) drained() {
+	if s.drainc != nil {
+		close(s.drainc)
+		s.drainc = nil
+	}
+}
+
+// unlock unlocks the subscriber's gate,
+// setting the condition to true if the buffer is non-empty or closed.
+func (s *Subscriber[T]) unlock() {
+	s.gate.Unlock(s.err != nil || len(s.q) > 0)
+}
+
+func Example_broadcast() {
+	var b Broadcast[string]
+	fast := b.Subscribe(0, BlockPublisher)
+	slow := b.Subscribe(1, DropSubscriber)
+
+	b.Publish(context.Background(), "connected")
+	b.Publish(context.Background(), "disconnected")
+	fmt.Println(fast.Get(context.Background()))
+	fmt.Println(fast.Get(context.Background()))
+
+	// The slow subscriber was dropped when its buffer overflowed.
+	fmt.Println(slow.Get(context.Background()))
+	// Output:
+	// connected <nil>
+	// disconnected <nil>
+	//  subscriber dropped
+}
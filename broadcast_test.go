@@ -0,0 +1,51 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"testing/synctest"
+)
+
+func TestBroadcastCloseWhilePublishing(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		var b Broadcast[int]
+		s := b.Subscribe(1, BlockPublisher)
+		b.Publish(context.Background(), 1)
+
+		donec := make(chan error)
+		go func() {
+			// Blocks until the subscriber has room or is closed.
+			donec <- b.Publish(context.Background(), 2)
+		}()
+		synctest.Wait()
+		errClosed := errors.New("closed")
+		b.Close(errClosed)
+		if err := <-donec; err != nil {
+			t.Errorf("Publish blocked on subscriber during Close = %v, want nil", err)
+		}
+		if _, err := s.Get(context.Background()); err != errClosed {
+			t.Errorf("Get after Close = %v, want %v", err, errClosed)
+		}
+		if err := b.Publish(context.Background(), 3); err != errClosed {
+			t.Errorf("Publish after Close = %v, want %v", err, errClosed)
+		}
+	})
+}
+
+func TestBroadcastCloseNil(t *testing.T) {
+	var b Broadcast[int]
+	s := b.Subscribe(0, BlockPublisher)
+	b.Close(nil)
+	if _, err := s.Get(context.Background()); err != io.EOF {
+		t.Errorf("Get after Close(nil) = %v, want io.EOF", err)
+	}
+	if err := b.Publish(context.Background(), 1); err != io.EOF {
+		t.Errorf("Publish after Close(nil) = %v, want io.EOF", err)
+	}
+}
@@ -0,0 +1,98 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate
+
+import (
+	"context"
+	"math/rand/v2"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ChaosConfig configures chaos mode, which perturbs the timing of gate operations
+// to expose assumptions about scheduling in code built on gates.
+// Chaos mode is intended for tests.
+type ChaosConfig struct {
+	// Seed seeds the random source, for reproducible perturbations.
+	// A zero Seed uses a random seed.
+	Seed uint64
+
+	// MaxDelay is the maximum delay injected before each lock attempt and after each unlock.
+	// Delays before locking randomize the order in which contending goroutines
+	// acquire a gate. If MaxDelay is zero, gates yield the processor instead of sleeping.
+	MaxDelay time.Duration
+
+	// CancelProbability is the probability that a WaitAndLock call
+	// with a cancelable context fails with context.Canceled
+	// as if its context had been canceled, without waiting or acquiring the gate.
+	CancelProbability float64
+}
+
+type chaosState struct {
+	c    ChaosConfig
+	mu   sync.Mutex
+	rand *rand.Rand
+}
+
+var chaos atomic.Pointer[chaosState]
+
+// SetChaos enables chaos mode with the given configuration.
+// A nil c disables chaos mode.
+//
+// Chaos mode affects every gate, including gates created before it was enabled.
+func SetChaos(c *ChaosConfig) {
+	setDiagnostic(func() {
+		if c == nil {
+			chaos.Store(nil)
+			return
+		}
+		seed := c.Seed
+		if seed == 0 {
+			seed = rand.Uint64()
+		}
+		chaos.Store(&chaosState{
+			c:    *c,
+			rand: rand.New(rand.NewPCG(seed, seed)),
+		})
+	})
+}
+
+// chaosDelay injects a random delay, in chaos mode.
+func chaosDelay() {
+	cs := chaos.Load()
+	if cs == nil {
+		return
+	}
+	cs.mu.Lock()
+	var d time.Duration
+	yield := cs.rand.IntN(2) == 0
+	if cs.c.MaxDelay > 0 {
+		d = time.Duration(cs.rand.Int64N(int64(cs.c.MaxDelay)))
+	}
+	cs.mu.Unlock()
+	switch {
+	case d > 0:
+		time.Sleep(d)
+	case yield:
+		runtime.Gosched()
+	}
+}
+
+// chaosCancel returns a spurious context.Canceled error, in chaos mode.
+func chaosCancel(ctx context.Context) error {
+	cs := chaos.Load()
+	if cs == nil || cs.c.CancelProbability <= 0 || ctx.Done() == nil {
+		return nil
+	}
+	cs.mu.Lock()
+	cancel := cs.rand.Float64() < cs.c.CancelProbability
+	cs.mu.Unlock()
+	if cancel {
+		return context.Canceled
+	}
+	return nil
+}
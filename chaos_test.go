@@ -0,0 +1,56 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/neild/gate"
+)
+
+func TestChaosCancel(t *testing.T) {
+	gate.SetChaos(&gate.ChaosConfig{CancelProbability: 1})
+	defer gate.SetChaos(nil)
+
+	g := gate.New(true)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := g.WaitAndLock(ctx); err != context.Canceled {
+		t.Fatalf("g.WaitAndLock(cancelable) = %v, want spurious context.Canceled", err)
+	}
+	// Contexts which can never be canceled are not spuriously canceled.
+	if err := g.WaitAndLock(context.Background()); err != nil {
+		t.Fatalf("g.WaitAndLock(Background) = %v, want nil", err)
+	}
+	g.Unlock(true)
+}
+
+func TestChaosDelay(t *testing.T) {
+	gate.SetChaos(&gate.ChaosConfig{Seed: 1, MaxDelay: 100 * time.Microsecond})
+	defer gate.SetChaos(nil)
+
+	// Chaos perturbs timing, but not correctness.
+	mu := gate.New(false)
+	count := 0
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				mu.Lock()
+				count++
+				mu.Unlock(false)
+			}
+		}()
+	}
+	wg.Wait()
+	if count != 100 {
+		t.Fatalf("count = %v, want 100", count)
+	}
+}
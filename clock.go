@@ -0,0 +1,282 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+)
+
+// A Clock is a source of time for the package's time-dependent primitives:
+// AdmissionController, CircuitBreaker, Committer, Debouncer, IdleTracker, Leaser, Loader,
+// Periodic, RateLimiter, Retrier, Scheduler, SlidingWindowLimiter, Watchdog,
+// the stagger delay of Race, and the wait times reported to an Observer
+// by gates, Semaphore, and WeightedSemaphore.
+// Constructors accept a clock with WithClock, and Race with ContextWithClock.
+//
+// Limiters compare a context's deadline with their clock's time
+// when deciding whether a wait can end before the deadline,
+// but contexts themselves expire according to the system's time.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+
+	// NewTimer returns a timer which sends the current time on its channel after d.
+	NewTimer(d time.Duration) Timer
+
+	// AfterFunc returns a timer which calls f after d.
+	AfterFunc(d time.Duration, f func()) Timer
+}
+
+// A Timer is a timer created by a Clock.
+type Timer interface {
+	// C returns the timer's channel, or nil for timers created by AfterFunc.
+	C() <-chan time.Time
+
+	// Stop prevents the timer from firing.
+	// It reports whether the call stopped the timer.
+	Stop() bool
+
+	// Reset changes the timer to fire after d.
+	// It reports whether the timer had been active.
+	Reset(d time.Duration) bool
+}
+
+type systemClock struct{}
+
+// SystemClock returns a Clock which uses the system's time.
+func SystemClock() Clock {
+	return systemClock{}
+}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) NewTimer(d time.Duration) Timer {
+	return systemTimer{time.NewTimer(d)}
+}
+
+func (systemClock) AfterFunc(d time.Duration, f func()) Timer {
+	return systemTimer{time.AfterFunc(d, f)}
+}
+
+type systemTimer struct {
+	t *time.Timer
+}
+
+func (t systemTimer) C() <-chan time.Time        { return t.t.C }
+func (t systemTimer) Stop() bool                 { return t.t.Stop() }
+func (t systemTimer) Reset(d time.Duration) bool { return t.t.Reset(d) }
+
+// An Option configures a primitive created by one of the package's constructors.
+type Option func(*options)
+
+type options struct {
+	clock    Clock
+	name     string
+	observer Observer
+}
+
+// WithClock returns an Option which makes a time-dependent primitive use c as its source of time.
+// The primitive uses c for its entire life.
+// By default, primitives use the system clock.
+func WithClock(c Clock) Option {
+	return func(o *options) {
+		o.clock = c
+	}
+}
+
+// WithName returns an Option which names a primitive.
+// The primitive's gates are named after it, as by NewNamed,
+// so the name identifies the primitive in diagnostics.
+// A primitive with several gates names its main gate after itself,
+// and each other gate after the field which holds it:
+// the alive gate of a Watchdog named "conn.7.watchdog" is "conn.7.watchdog.alive".
+func WithName(name string) Option {
+	return func(o *options) {
+		o.name = name
+	}
+}
+
+// WithObserver returns an Option which makes a primitive report its measurements to o
+// rather than to the package-wide observer.
+// The primitive's gates report their measurements to o as well,
+// under the names described by SetObserver.
+func WithObserver(o Observer) Option {
+	return func(opt *options) {
+		opt.observer = o
+	}
+}
+
+// newGate returns a new gate for a primitive configured with o.
+// If the primitive is named, the gate is given the primitive's name,
+// followed by field if field is not "".
+func (o options) newGate(set bool, field string) Gate {
+	return newGate(set, o.fieldName(field), o.observer, o.clock)
+}
+
+// fieldOptions returns the options for a primitive held in field of a primitive configured with o.
+func (o options) fieldOptions(field string) []Option {
+	return []Option{WithClock(o.clock), WithName(o.fieldName(field)), WithObserver(o.observer)}
+}
+
+// fieldName returns the name of the gate or primitive held in field,
+// or the primitive's own name if field is "".
+func (o options) fieldName(field string) string {
+	if o.name == "" || field == "" {
+		return o.name
+	}
+	return o.name + "." + field
+}
+
+func newOptions(opts []Option) options {
+	o := options{clock: systemClock{}}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+type clockContextKey struct{}
+
+// ContextWithClock returns a copy of ctx which carries c,
+// for functions such as Race which take a context rather than options.
+func ContextWithClock(ctx context.Context, c Clock) context.Context {
+	return context.WithValue(ctx, clockContextKey{}, c)
+}
+
+// contextClock returns the clock carried by ctx, or the system clock.
+func contextClock(ctx context.Context) Clock {
+	if c, ok := ctx.Value(clockContextKey{}).(Clock); ok {
+		return c
+	}
+	return systemClock{}
+}
+
+// A FakeClock is a Clock whose time changes only when advanced.
+// It is intended for tests.
+type FakeClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []*fakeTimer // active timers
+}
+
+// NewFakeClock returns a FakeClock with the given current time.
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now}
+}
+
+// Now returns the clock's current time.
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// NewTimer returns a timer which fires when the clock is advanced by at least d.
+func (c *FakeClock) NewTimer(d time.Duration) Timer {
+	t := &fakeTimer{c: c, ch: make(chan time.Time, 1)}
+	t.Reset(d)
+	return t
+}
+
+// AfterFunc returns a timer which calls f when the clock is advanced by at least d.
+// Unlike time.AfterFunc, f is called synchronously by Advance.
+func (c *FakeClock) AfterFunc(d time.Duration, f func()) Timer {
+	t := &fakeTimer{c: c, f: f}
+	t.Reset(d)
+	return t
+}
+
+// Advance advances the clock by d, firing timers in the order of their deadlines.
+// When Advance returns, every function started by an AfterFunc timer which fired has returned.
+//
+// A timer which fires at a time sees that time as the clock's current time,
+// so a timer reset by another timer's function fires during the same Advance
+// if its new deadline has been reached.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	end := c.now.Add(d)
+	c.mu.Unlock()
+	for {
+		c.mu.Lock()
+		var next *fakeTimer
+		for _, t := range c.timers {
+			if !t.when.After(end) && (next == nil || t.when.Before(next.when)) {
+				next = t
+			}
+		}
+		if next == nil {
+			c.now = end
+			c.mu.Unlock()
+			return
+		}
+		c.now = next.when
+		now := c.now
+		c.remove(next)
+		c.mu.Unlock()
+		if next.f != nil {
+			next.f()
+		} else {
+			select {
+			case next.ch <- now:
+			default:
+			}
+		}
+	}
+}
+
+// remove removes a timer from c.timers. The clock's mu must be held.
+func (c *FakeClock) remove(t *fakeTimer) bool {
+	i := slices.Index(c.timers, t)
+	if i < 0 {
+		return false
+	}
+	c.timers = slices.Delete(c.timers, i, i+1)
+	return true
+}
+
+type fakeTimer struct {
+	c    *FakeClock
+	when time.Time // guarded by c.mu
+	ch   chan time.Time
+	f    func()
+}
+
+func (t *fakeTimer) C() <-chan time.Time {
+	if t.ch == nil {
+		return nil
+	}
+	return t.ch
+}
+
+func (t *fakeTimer) Stop() bool {
+	t.c.mu.Lock()
+	defer t.c.mu.Unlock()
+	t.drain()
+	return t.c.remove(t)
+}
+
+func (t *fakeTimer) Reset(d time.Duration) bool {
+	t.c.mu.Lock()
+	defer t.c.mu.Unlock()
+	t.drain()
+	active := t.c.remove(t)
+	t.when = t.c.now.Add(d)
+	t.c.timers = append(t.c.timers, t)
+	return active
+}
+
+// drain discards an unreceived value from the timer's channel,
+// as Stop and Reset do for timers created by time.NewTimer.
+func (t *fakeTimer) drain() {
+	select {
+	case <-t.ch:
+	default:
+	}
+}
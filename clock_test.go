@@ -0,0 +1,208 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/neild/gate"
+)
+
+func newFakeClock() *gate.FakeClock {
+	// Start at the current time, so deadlines derived from the clock have not passed.
+	return gate.NewFakeClock(time.Now())
+}
+
+func TestFakeClockTimers(t *testing.T) {
+	c := newFakeClock()
+	start := c.Now()
+	var fired []time.Duration
+	c.AfterFunc(2*time.Second, func() {
+		fired = append(fired, c.Now().Sub(start))
+	})
+	tm := c.NewTimer(1 * time.Second)
+	stopped := c.AfterFunc(1*time.Second, func() {
+		t.Errorf("stopped timer fired")
+	})
+	if !stopped.Stop() {
+		t.Fatalf("Stop of active timer = false, want true")
+	}
+
+	c.Advance(999 * time.Millisecond)
+	select {
+	case <-tm.C():
+		t.Fatalf("timer fired early")
+	default:
+	}
+	c.Advance(1 * time.Millisecond)
+	select {
+	case now := <-tm.C():
+		if got, want := now.Sub(start), 1*time.Second; got != want {
+			t.Fatalf("timer fired at %v, want %v", got, want)
+		}
+	default:
+		t.Fatalf("timer did not fire")
+	}
+	c.Advance(5 * time.Second)
+	if len(fired) != 1 || fired[0] != 2*time.Second {
+		t.Fatalf("AfterFunc fired at %v, want [2s]", fired)
+	}
+	if got, want := c.Now().Sub(start), 6*time.Second; got != want {
+		t.Fatalf("clock advanced by %v, want %v", got, want)
+	}
+}
+
+func TestFakeClockIdleTracker(t *testing.T) {
+	c := newFakeClock()
+	it := gate.NewIdleTracker(1*time.Minute, gate.WithClock(c))
+	defer it.Stop()
+	c.Advance(30 * time.Second)
+	it.Activity()
+	c.Advance(59 * time.Second)
+	if it.Idle() {
+		t.Fatalf("tracker idle 59s after activity")
+	}
+	c.Advance(1 * time.Second)
+	if !it.Idle() {
+		t.Fatalf("tracker not idle 1m after activity")
+	}
+}
+
+func TestFakeClockRateLimiter(t *testing.T) {
+	c := newFakeClock()
+	l := gate.NewRateLimiter(1, 1, gate.WithClock(c))
+	if !l.Allow() {
+		t.Fatalf("l.Allow() = false with full bucket")
+	}
+	if l.Allow() {
+		t.Fatalf("l.Allow() = true with empty bucket")
+	}
+	r := l.ReserveN(1)
+	if got, want := r.Delay(), 1*time.Second; got != want {
+		t.Fatalf("r.Delay() = %v, want %v", got, want)
+	}
+	c.Advance(400 * time.Millisecond)
+	if got, want := r.Delay(), 600*time.Millisecond; got != want {
+		t.Fatalf("r.Delay() = %v, want %v", got, want)
+	}
+	c.Advance(600 * time.Millisecond)
+	if got := r.Delay(); got != 0 {
+		t.Fatalf("r.Delay() = %v, want 0", got)
+	}
+	if got, want := l.ReserveN(1).Delay(), 1*time.Second; got != want {
+		t.Fatalf("l.ReserveN(1).Delay() = %v, want %v", got, want)
+	}
+
+	// The deadline is compared with the limiter's clock.
+	ctx, cancel := context.WithDeadline(context.Background(), c.Now().Add(1*time.Second))
+	defer cancel()
+	if err := l.Wait(ctx); err != gate.ErrWouldExceedDeadline {
+		t.Fatalf("l.Wait = %v, want ErrWouldExceedDeadline", err)
+	}
+}
+
+func TestFakeClockLease(t *testing.T) {
+	c := newFakeClock()
+	l := gate.NewLeaser(gate.WithClock(c))
+	lease, err := l.Acquire(context.Background(), 1*time.Minute)
+	if err != nil {
+		t.Fatalf("l.Acquire = %v", err)
+	}
+	c.Advance(30 * time.Second)
+	if err := lease.Renew(1 * time.Minute); err != nil {
+		t.Fatalf("lease.Renew = %v, want nil", err)
+	}
+	c.Advance(59 * time.Second)
+	if err := lease.Renew(1 * time.Minute); err != nil {
+		t.Fatalf("lease.Renew = %v, want nil before expiry", err)
+	}
+	c.Advance(1 * time.Minute)
+	if err := lease.Renew(1 * time.Minute); err != gate.ErrLeaseLost {
+		t.Fatalf("lease.Renew = %v, want ErrLeaseLost after expiry", err)
+	}
+}
+
+func TestFakeClockScheduler(t *testing.T) {
+	c := newFakeClock()
+	s := gate.NewScheduler[int](gate.WithClock(c))
+	s.Schedule(c.Now().Add(1*time.Hour), 1)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := s.Get(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("s.Get before due = %v, want context.DeadlineExceeded", err)
+	}
+	c.Advance(1 * time.Hour)
+	if got, err := s.Get(context.Background()); err != nil || got != 1 {
+		t.Fatalf("s.Get = %v, %v, want 1, nil", got, err)
+	}
+}
+
+func TestFakeClockIndependent(t *testing.T) {
+	c1, c2 := newFakeClock(), newFakeClock()
+	w1 := gate.NewWatchdog(1*time.Minute, gate.WithClock(c1))
+	defer w1.Stop()
+	w2 := gate.NewWatchdog(1*time.Minute, gate.WithClock(c2))
+	defer w2.Stop()
+	c1.Advance(1 * time.Minute)
+	if !w1.Expired() {
+		t.Fatalf("w1.Expired() = false after its clock advanced past the interval")
+	}
+	if w2.Expired() {
+		t.Fatalf("w2.Expired() = true after another clock advanced")
+	}
+}
+
+func TestFakeClockAdmissionTimeout(t *testing.T) {
+	c := newFakeClock()
+	a := gate.NewAdmissionController(1, 1, 1*time.Second, gate.WithClock(c))
+	if err := a.Acquire(context.Background()); err != nil {
+		t.Fatalf("a.Acquire = %v, want nil", err)
+	}
+	defer a.Release()
+	errc := make(chan error)
+	go func() {
+		errc <- a.Acquire(context.Background())
+	}()
+	for a.Queued() == 0 {
+		time.Sleep(1 * time.Millisecond)
+	}
+	c.Advance(1 * time.Second)
+	var oerr *gate.OverloadError
+	if err := <-errc; !errors.As(err, &oerr) || !oerr.Timeout {
+		t.Fatalf("a.Acquire = %v, want timeout OverloadError", err)
+	}
+}
+
+func TestFakeClockRace(t *testing.T) {
+	c := newFakeClock()
+	ctx := gate.ContextWithClock(context.Background(), c)
+	second := make(chan struct{})
+	resc := make(chan int)
+	go func() {
+		v, _ := gate.Race(ctx, 1*time.Minute,
+			func(ctx context.Context) (int, error) {
+				<-ctx.Done()
+				return 0, ctx.Err()
+			},
+			func(ctx context.Context) (int, error) {
+				close(second)
+				return 2, nil
+			},
+		)
+		resc <- v
+	}()
+	select {
+	case <-second:
+		t.Fatalf("second function started before stagger")
+	case <-time.After(10 * time.Millisecond):
+	}
+	c.Advance(1 * time.Minute)
+	if got := <-resc; got != 2 {
+		t.Fatalf("Race = %v, want 2", got)
+	}
+}
@@ -0,0 +1,111 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate_test
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/neild/gate"
+)
+
+// A CoalescingQueue is an unbounded queue of items identified by a key.
+// When an item is put while another item with the same key is pending,
+// the two are merged into a single item, which keeps its original place in the queue.
+type CoalescingQueue[K comparable, T any] struct {
+	gate  gate.Gate // set if queue is non-empty or closed
+	key   func(T) K
+	merge func(old, new T) T
+	err   error
+	keys  []K     // pending keys, in order
+	items map[K]T // pending items
+}
+
+// NewCoalescingQueue returns a new queue.
+// The key function returns the key of an item,
+// and the merge function combines a pending item with a new item with the same key.
+func NewCoalescingQueue[K comparable, T any](key func(T) K, merge func(old, new T) T) *CoalescingQueue[K, T] {
+	return &CoalescingQueue[K, T]{
+		gate:  gate.New(false),
+		key:   key,
+		merge: merge,
+		items: make(map[K]T),
+	}
+}
+
+// Close closes the queue, causing pending and future pop operations
+// to return immediately with err.
+func (q *CoalescingQueue[K, T]) Close(err error) {
+	q.gate.Lock()
+	defer q.unlock()
+	if q.err == nil {
+		q.err = err
+	}
+}
+
+// Put adds an item to the queue, merging it with any pending item with the same key.
+// It returns true if the item was added, false if the queue is closed.
+func (q *CoalescingQueue[K, T]) Put(v T) bool {
+	q.gate.Lock()
+	defer q.unlock()
+	if q.err != nil {
+		return false
+	}
+	k := q.key(v)
+	if old, ok := q.items[k]; ok {
+		q.items[k] = q.merge(old, v)
+		return true
+	}
+	q.keys = append(q.keys, k)
+	q.items[k] = v
+	return true
+}
+
+// Get removes the first item from the queue, blocking until ctx is done, an item is available,
+// or the queue is closed.
+func (q *CoalescingQueue[K, T]) Get(ctx context.Context) (T, error) {
+	var zero T
+	if err := q.gate.WaitAndLock(ctx); err != nil {
+		return zero, err
+	}
+	defer q.unlock()
+	if q.err != nil {
+		return zero, q.err
+	}
+	k := q.keys[0]
+	q.keys = slices.Delete(q.keys, 0, 1)
+	v := q.items[k]
+	delete(q.items, k)
+	return v, nil
+}
+
+// unlock unlocks the queue's gate,
+// setting the condition to true if the queue is non-empty or closed.
+func (q *CoalescingQueue[K, T]) unlock() {
+	q.gate.Unlock(q.err != nil || len(q.keys) > 0)
+}
+
+func Example_coalescingQueue() {
+	type update struct {
+		key   string
+		value int
+	}
+	// Successive updates to the same key collapse into the latest one.
+	q := NewCoalescingQueue(
+		func(u update) string { return u.key },
+		func(old, new update) update { return new },
+	)
+	q.Put(update{"a", 1})
+	q.Put(update{"b", 1})
+	q.Put(update{"a", 2})
+	q.Put(update{"a", 3})
+
+	fmt.Println(q.Get(context.Background()))
+	fmt.Println(q.Get(context.Background()))
+	// Output:
+	// {a 3} <nil>
+	// {b 1} <nil>
+}
@@ -0,0 +1,83 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate
+
+import (
+	"context"
+	"slices"
+)
+
+// A Collector gathers results from concurrent producers,
+// allowing a consumer to wait for the first results to arrive.
+type Collector[T any] struct {
+	mu      Gate // guards the fields below
+	results []T
+	closed  bool
+	changed chan struct{} // closed when a result is added or the collector is closed
+}
+
+// NewCollector returns a new, empty collector.
+func NewCollector[T any]() *Collector[T] {
+	return &Collector[T]{
+		mu: New(false),
+	}
+}
+
+// Add adds a result.
+// It reports whether the result was added, which it is not if the collector is closed.
+func (c *Collector[T]) Add(v T) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock(false)
+	if c.closed {
+		return false
+	}
+	c.results = append(c.results, v)
+	c.notify()
+	return true
+}
+
+// Close indicates that no more results will be added.
+func (c *Collector[T]) Close() {
+	c.mu.Lock()
+	defer c.mu.Unlock(false)
+	c.closed = true
+	c.mu.recordClose()
+	c.notify()
+}
+
+// Wait blocks until k results have been added, the collector is closed,
+// or the context expires.
+// It returns the first k results, or all results if fewer have been added.
+// If the context expires, it returns the results added so far, up to k,
+// along with the context's error.
+func (c *Collector[T]) Wait(ctx context.Context, k int) ([]T, error) {
+	for {
+		c.mu.Lock()
+		if len(c.results) >= k || c.closed {
+			results := slices.Clone(c.results[:min(k, len(c.results))])
+			c.mu.Unlock(false)
+			return results, nil
+		}
+		if c.changed == nil {
+			c.changed = make(chan struct{})
+		}
+		changed := c.changed
+		c.mu.Unlock(false)
+		if err := c.mu.WaitFor(ctx, changed); err != nil {
+			c.mu.Lock()
+			defer c.mu.Unlock(false)
+			return slices.Clone(c.results[:min(k, len(c.results))]), err
+		}
+	}
+}
+
+// notify wakes callers of Wait.
+// The collector's mu must be held.
+func (c *Collector[T]) notify() {
+	if c.changed != nil {
+		close(c.changed)
+		c.changed = nil
+	}
+}
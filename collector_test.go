@@ -0,0 +1,55 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate_test
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/neild/gate"
+)
+
+func TestCollector(t *testing.T) {
+	c := gate.NewCollector[int]()
+	for i := 0; i < 5; i++ {
+		go func() {
+			time.Sleep(time.Duration(i) * time.Millisecond)
+			c.Add(i)
+		}()
+	}
+	got, err := c.Wait(context.Background(), 3)
+	if err != nil || len(got) != 3 {
+		t.Fatalf("c.Wait(3) = %v, %v; want 3 results, nil", got, err)
+	}
+}
+
+func TestCollectorContextExpired(t *testing.T) {
+	c := gate.NewCollector[int]()
+	c.Add(1)
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Millisecond)
+	defer cancel()
+	got, err := c.Wait(ctx, 2)
+	if err != context.DeadlineExceeded || !slices.Equal(got, []int{1}) {
+		t.Fatalf("c.Wait(2) with one result = %v, %v; want [1], context.DeadlineExceeded", got, err)
+	}
+}
+
+func TestCollectorClose(t *testing.T) {
+	c := gate.NewCollector[int]()
+	c.Add(1)
+	go func() {
+		time.Sleep(1 * time.Millisecond)
+		c.Close()
+	}()
+	got, err := c.Wait(context.Background(), 2)
+	if err != nil || !slices.Equal(got, []int{1}) {
+		t.Fatalf("c.Wait(2) on closed collector = %v, %v; want [1], nil", got, err)
+	}
+	if ok := c.Add(2); ok {
+		t.Fatalf("c.Add after Close = %v, want false", ok)
+	}
+}
@@ -0,0 +1,114 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+)
+
+// ErrCommitterClosed is returned by Committer.Add after the committer is closed.
+var ErrCommitterClosed = errors.New("gate: committer closed")
+
+// A Committer groups items into batches and flushes each batch with a single call,
+// reporting the result of the flush to every caller whose item was in the batch.
+//
+// A batch is flushed when it reaches its maximum size,
+// or when the flush interval has passed since its first item was added.
+// Batches are flushed one at a time, in the order they were started.
+type Committer[T any] struct {
+	flushMu  Mutex // held while flushing
+	mu       Gate  // guards the fields below
+	clock    Clock
+	size     int
+	interval time.Duration
+	flush    func([]T) error
+	batches  []*commitBatch[T] // batches not yet flushed; only the last may have room
+	closed   bool
+}
+
+type commitBatch[T any] struct {
+	items []T
+	timer Timer
+	done  *Event // set with the flush error when the batch is flushed
+}
+
+// NewCommitter returns a committer which flushes batches of up to size items
+// by calling flush, and flushes partial batches after interval.
+func NewCommitter[T any](size int, interval time.Duration, flush func([]T) error, opts ...Option) *Committer[T] {
+	o := newOptions(opts)
+	return &Committer[T]{
+		flushMu:  Mutex{o.newGate(true, "flushMu")},
+		mu:       o.newGate(false, ""),
+		clock:    o.clock,
+		size:     size,
+		interval: interval,
+		flush:    flush,
+	}
+}
+
+// Add adds an item to the current batch and blocks until the batch has been flushed,
+// returning the error returned by the flush function.
+//
+// If the context expires, Add returns the context's error.
+// The item remains in its batch and is flushed with it.
+func (c *Committer[T]) Add(ctx context.Context, v T) error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock(false)
+		return ErrCommitterClosed
+	}
+	var b *commitBatch[T]
+	if n := len(c.batches); n > 0 && len(c.batches[n-1].items) < c.size {
+		b = c.batches[n-1]
+	} else {
+		b = &commitBatch[T]{done: NewEvent()}
+		b.timer = c.clock.AfterFunc(c.interval, func() { c.flushThrough(b) })
+		c.batches = append(c.batches, b)
+	}
+	b.items = append(b.items, v)
+	full := len(b.items) >= c.size
+	c.mu.Unlock(false)
+	if full {
+		go c.flushThrough(b)
+	}
+	return b.done.Wait(ctx)
+}
+
+// Close flushes all pending batches and causes future calls to Add to fail.
+func (c *Committer[T]) Close() {
+	c.mu.Lock()
+	c.closed = true
+	c.mu.recordClose()
+	var last *commitBatch[T]
+	if n := len(c.batches); n > 0 {
+		last = c.batches[n-1]
+	}
+	c.mu.Unlock(false)
+	if last != nil {
+		c.flushThrough(last)
+	}
+}
+
+// flushThrough flushes batches in order until batch b has been flushed.
+// It does nothing if b has already been flushed.
+func (c *Committer[T]) flushThrough(b *commitBatch[T]) {
+	c.flushMu.Lock(context.Background())
+	defer c.flushMu.Unlock()
+	for {
+		c.mu.Lock()
+		if !slices.Contains(c.batches, b) {
+			c.mu.Unlock(false)
+			return
+		}
+		oldest := c.batches[0]
+		c.batches = slices.Delete(c.batches, 0, 1)
+		c.mu.Unlock(false)
+		oldest.timer.Stop()
+		oldest.done.SetError(c.flush(oldest.items))
+	}
+}
@@ -0,0 +1,114 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/neild/gate"
+)
+
+func TestCommitterFlushBySize(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		batches [][]int
+	)
+	c := gate.NewCommitter(2, 1*time.Hour, func(items []int) error {
+		mu.Lock()
+		defer mu.Unlock()
+		batches = append(batches, slices.Clone(items))
+		return nil
+	})
+	errc := make(chan error)
+	for i := 0; i < 4; i++ {
+		go func() {
+			errc <- c.Add(context.Background(), i)
+		}()
+	}
+	for i := 0; i < 4; i++ {
+		if err := <-errc; err != nil {
+			t.Fatalf("c.Add = %v, want nil", err)
+		}
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(batches) != 2 || len(batches[0]) != 2 || len(batches[1]) != 2 {
+		t.Fatalf("flushed batches = %v, want two batches of two items", batches)
+	}
+}
+
+func TestCommitterFlushByInterval(t *testing.T) {
+	wantErr := errors.New("flush failed")
+	c := gate.NewCommitter(10, 1*time.Millisecond, func(items []int) error {
+		if len(items) != 1 {
+			t.Errorf("flushed %v items, want 1", len(items))
+		}
+		return wantErr
+	})
+	if err := c.Add(context.Background(), 1); err != wantErr {
+		t.Fatalf("c.Add = %v, want %v", err, wantErr)
+	}
+}
+
+func TestCommitterClose(t *testing.T) {
+	flushed := make(chan []int, 1)
+	c := gate.NewCommitter(10, 1*time.Hour, func(items []int) error {
+		flushed <- slices.Clone(items)
+		return nil
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Millisecond)
+	defer cancel()
+	if err := c.Add(ctx, 1); err != context.DeadlineExceeded {
+		t.Fatalf("c.Add with expired context = %v, want context.DeadlineExceeded", err)
+	}
+	c.Close()
+	if got := <-flushed; !slices.Equal(got, []int{1}) {
+		t.Fatalf("Close flushed %v, want [1]", got)
+	}
+	if err := c.Add(context.Background(), 2); err != gate.ErrCommitterClosed {
+		t.Fatalf("c.Add after Close = %v, want %v", err, gate.ErrCommitterClosed)
+	}
+}
+
+func TestCommitterStaleTimer(t *testing.T) {
+	clock := gate.NewFakeClock(time.Now())
+	flushed := make(chan []int, 10)
+	c := gate.NewCommitter(2, 1*time.Minute, func(items []int) error {
+		flushed <- slices.Clone(items)
+		return nil
+	}, gate.WithClock(clock))
+	// Add returns immediately with a canceled context, leaving its item in the batch.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	c.Add(ctx, 1)
+	c.Add(ctx, 2)
+	if got := <-flushed; len(got) != 2 {
+		t.Fatalf("flushed %v, want full batch", got)
+	}
+
+	clock.Advance(30 * time.Second)
+	c.Add(ctx, 3)
+	// The timer of the full batch must not flush the new batch.
+	clock.Advance(30 * time.Second)
+	select {
+	case got := <-flushed:
+		t.Fatalf("flushed %v before the batch's interval passed", got)
+	default:
+	}
+	clock.Advance(30 * time.Second)
+	select {
+	case got := <-flushed:
+		if len(got) != 1 || got[0] != 3 {
+			t.Fatalf("flushed %v, want [3]", got)
+		}
+	default:
+		t.Fatalf("batch not flushed after its interval")
+	}
+}
@@ -0,0 +1,158 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// A ShutdownError is returned by Coordinator.Shutdown
+// when one or more components did not shut down cleanly.
+type ShutdownError struct {
+	// Errors maps the names of components which failed to shut down
+	// to the errors they failed with.
+	// A component which did not finish shutting down before
+	// the context expired has the context's error.
+	Errors map[string]error
+}
+
+func (e *ShutdownError) Error() string {
+	names := make([]string, 0, len(e.Errors))
+	for name := range e.Errors {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	var b strings.Builder
+	b.WriteString("gate: shutdown failed:")
+	for i, name := range names {
+		if i > 0 {
+			b.WriteString(";")
+		}
+		fmt.Fprintf(&b, " %v: %v", name, e.Errors[name])
+	}
+	return b.String()
+}
+
+// A Coordinator shuts down a set of components in dependency order.
+//
+// Each component is registered with a function which stops it accepting new work
+// and drains the work it has, such as WorkerPool.Shutdown or Pool.Close.
+// A component is shut down only after every component which depends on it,
+// so a server is drained before the database it uses.
+type Coordinator struct {
+	mu         Gate // guards the fields below
+	components map[string]*component
+	order      []string // component names, in registration order
+	shutdown   bool
+}
+
+type component struct {
+	shutdown   func(context.Context) error
+	dependents []*component
+	done       *Event // set when the component has finished shutting down
+}
+
+// NewCoordinator returns a new coordinator with no components.
+func NewCoordinator() *Coordinator {
+	return &Coordinator{
+		mu:         New(false),
+		components: make(map[string]*component),
+	}
+}
+
+// Register adds a component with the given name and shutdown function.
+// The component depends on the named components, which must already be registered.
+func (c *Coordinator) Register(name string, shutdown func(context.Context) error, dependsOn ...string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock(false)
+	if c.shutdown {
+		return fmt.Errorf("gate: component %q registered after shutdown", name)
+	}
+	if _, ok := c.components[name]; ok {
+		return fmt.Errorf("gate: component %q already registered", name)
+	}
+	for _, dep := range dependsOn {
+		if _, ok := c.components[dep]; !ok {
+			return fmt.Errorf("gate: component %q depends on unknown component %q", name, dep)
+		}
+	}
+	comp := &component{
+		shutdown: shutdown,
+		done:     NewEvent(),
+	}
+	for _, dep := range dependsOn {
+		d := c.components[dep]
+		d.dependents = append(d.dependents, comp)
+	}
+	c.components[name] = comp
+	c.order = append(c.order, name)
+	return nil
+}
+
+// Shutdown shuts down all components, each after the components which depend on it.
+// Components with no ordering constraint between them shut down concurrently.
+//
+// If any component fails to shut down, or has not finished when the context expires,
+// Shutdown returns a *ShutdownError.
+// A component whose dependents have not finished shutting down when the context expires
+// is not shut down at all.
+// Shutdown may be called only once.
+func (c *Coordinator) Shutdown(ctx context.Context) error {
+	c.mu.Lock()
+	if c.shutdown {
+		c.mu.Unlock(false)
+		panic("gate: Coordinator.Shutdown called twice")
+	}
+	c.shutdown = true
+	c.mu.Unlock(false)
+
+	var (
+		resultMu = New(false) // guards errs
+		errs     = make(map[string]error)
+		wg       = NewWaitGroup()
+	)
+	for _, name := range c.order {
+		comp := c.components[name]
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := comp.run(ctx)
+			resultMu.Lock()
+			defer resultMu.Unlock(false)
+			if err != nil {
+				errs[name] = err
+			}
+		}()
+	}
+	wg.Wait(ctx)
+
+	resultMu.Lock()
+	defer resultMu.Unlock(false)
+	for _, name := range c.order {
+		if _, ok := errs[name]; !ok && !c.components[name].done.IsSet() {
+			errs[name] = ctx.Err()
+		}
+	}
+	if len(errs) > 0 {
+		return &ShutdownError{Errors: maps.Clone(errs)}
+	}
+	return nil
+}
+
+// run shuts down the component once all its dependents have finished.
+func (comp *component) run(ctx context.Context) error {
+	for _, d := range comp.dependents {
+		if err := d.done.Wait(ctx); err != nil {
+			return err
+		}
+	}
+	err := comp.shutdown(ctx)
+	comp.done.Set()
+	return err
+}
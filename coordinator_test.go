@@ -0,0 +1,83 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/neild/gate"
+)
+
+func TestCoordinator(t *testing.T) {
+	c := gate.NewCoordinator()
+	var (
+		mu    sync.Mutex
+		order []string
+	)
+	stop := func(name string) func(context.Context) error {
+		return func(context.Context) error {
+			time.Sleep(1 * time.Millisecond)
+			mu.Lock()
+			defer mu.Unlock()
+			order = append(order, name)
+			return nil
+		}
+	}
+	c.Register("db", stop("db"))
+	c.Register("cache", stop("cache"), "db")
+	c.Register("server", stop("server"), "cache", "db")
+	if err := c.Shutdown(context.Background()); err != nil {
+		t.Fatalf("c.Shutdown = %v, want nil", err)
+	}
+	if want := []string{"server", "cache", "db"}; !slices.Equal(order, want) {
+		t.Fatalf("shutdown order = %v, want %v", order, want)
+	}
+}
+
+func TestCoordinatorRegisterErrors(t *testing.T) {
+	c := gate.NewCoordinator()
+	nop := func(context.Context) error { return nil }
+	if err := c.Register("a", nop, "missing"); err == nil {
+		t.Errorf("c.Register with unknown dependency = nil, want error")
+	}
+	c.Register("a", nop)
+	if err := c.Register("a", nop); err == nil {
+		t.Errorf("c.Register of duplicate name = nil, want error")
+	}
+}
+
+func TestCoordinatorFailures(t *testing.T) {
+	c := gate.NewCoordinator()
+	wantErr := errors.New("drain failed")
+	c.Register("db", func(context.Context) error { return nil })
+	c.Register("queue", func(ctx context.Context) error {
+		// Never finishes draining.
+		<-ctx.Done()
+		return ctx.Err()
+	}, "db")
+	c.Register("cache", func(context.Context) error { return wantErr })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Millisecond)
+	defer cancel()
+	err := c.Shutdown(ctx)
+	var serr *gate.ShutdownError
+	if !errors.As(err, &serr) {
+		t.Fatalf("c.Shutdown = %v, want *ShutdownError", err)
+	}
+	for name, want := range map[string]error{
+		"queue": context.DeadlineExceeded,
+		"db":    context.DeadlineExceeded,
+		"cache": wantErr,
+	} {
+		if got := serr.Errors[name]; got != want {
+			t.Errorf("serr.Errors[%q] = %v, want %v", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+)
+
+// A CoveragePath is one of the paths a gate operation can take.
+type CoveragePath int
+
+const (
+	// CoverLockFast is a Lock call which acquired the gate without blocking.
+	CoverLockFast CoveragePath = iota
+	// CoverLockContended is a Lock call which blocked before acquiring the gate.
+	CoverLockContended
+	// CoverWaitAndLockFast is a WaitAndLock call which acquired a set gate without blocking.
+	CoverWaitAndLockFast
+	// CoverWaitAndLockExpiredAvailable is a WaitAndLock call
+	// whose context had expired, but which acquired the gate because it was set.
+	CoverWaitAndLockExpiredAvailable
+	// CoverWaitAndLockContended is a WaitAndLock call which blocked before acquiring the gate.
+	CoverWaitAndLockContended
+	// CoverWaitAndLockCanceled is a WaitAndLock call which returned because its context expired.
+	CoverWaitAndLockCanceled
+	// CoverLockIfSetAcquired is a LockIfSet call which acquired the gate.
+	CoverLockIfSetAcquired
+	// CoverLockIfSetFailed is a LockIfSet call which did not acquire the gate.
+	CoverLockIfSetFailed
+	// CoverUnlockSet is an Unlock call which set the condition.
+	// Primitives built on gates close by unlocking with the condition set,
+	// so this is also the path which wakes waiters on a closed primitive.
+	CoverUnlockSet
+	// CoverUnlockUnset is an Unlock call which cleared the condition.
+	CoverUnlockUnset
+
+	numCoveragePaths
+)
+
+var coveragePathNames = [numCoveragePaths]string{
+	CoverLockFast:                    "Lock fast",
+	CoverLockContended:               "Lock contended",
+	CoverWaitAndLockFast:             "WaitAndLock fast",
+	CoverWaitAndLockExpiredAvailable: "WaitAndLock expired context, gate available",
+	CoverWaitAndLockContended:        "WaitAndLock contended",
+	CoverWaitAndLockCanceled:         "WaitAndLock canceled",
+	CoverLockIfSetAcquired:           "LockIfSet acquired",
+	CoverLockIfSetFailed:             "LockIfSet failed",
+	CoverUnlockSet:                   "Unlock set",
+	CoverUnlockUnset:                 "Unlock unset",
+}
+
+func (p CoveragePath) String() string {
+	if p < 0 || p >= numCoveragePaths {
+		return fmt.Sprintf("CoveragePath(%d)", int(p))
+	}
+	return coveragePathNames[p]
+}
+
+// Coverage records how often gate operations took each path.
+// It is intended for tests, to find paths which a test never exercises.
+type Coverage struct {
+	counts [numCoveragePaths]atomic.Int64
+}
+
+var coverage atomic.Pointer[Coverage]
+
+// SetCoverage makes c record the paths taken by gate operations.
+// A nil c disables recording.
+//
+// Operations of goroutines in an Explore scenario are not recorded.
+func SetCoverage(c *Coverage) {
+	setDiagnostic(func() {
+		coverage.Store(c)
+	})
+}
+
+// cover records that a gate operation took the path p, if coverage is enabled.
+func cover(p CoveragePath) {
+	if c := coverage.Load(); c != nil {
+		c.counts[p].Add(1)
+	}
+}
+
+// Count returns the number of operations which took the path p.
+func (c *Coverage) Count(p CoveragePath) int64 {
+	return c.counts[p].Load()
+}
+
+// Gaps returns the paths which no operation has taken.
+func (c *Coverage) Gaps() []CoveragePath {
+	var gaps []CoveragePath
+	for p := range numCoveragePaths {
+		if c.Count(p) == 0 {
+			gaps = append(gaps, p)
+		}
+	}
+	return gaps
+}
+
+// String returns a report of the number of operations which took each path,
+// with paths which no operation took marked as not covered.
+func (c *Coverage) String() string {
+	var b strings.Builder
+	for p := range numCoveragePaths {
+		if n := c.Count(p); n == 0 {
+			fmt.Fprintf(&b, "%v: not covered\n", p)
+		} else {
+			fmt.Fprintf(&b, "%v: %v\n", p, n)
+		}
+	}
+	return b.String()
+}
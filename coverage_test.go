@@ -0,0 +1,73 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate_test
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/neild/gate"
+)
+
+func TestCoverage(t *testing.T) {
+	c := &gate.Coverage{}
+	gate.SetCoverage(c)
+	defer gate.SetCoverage(nil)
+
+	g := gate.New(true)
+	g.Lock()
+	g.Unlock(false)
+	if g.LockIfSet() {
+		t.Fatalf("g.LockIfSet() = true with unset gate")
+	}
+	if got, want := c.Gaps(), gate.CoverWaitAndLockExpiredAvailable; !slices.Contains(got, want) {
+		t.Fatalf("c.Gaps() = %v, want it to contain %v", got, want)
+	}
+	if !strings.Contains(c.String(), "WaitAndLock expired context, gate available: not covered") {
+		t.Errorf("c.String() does not report the uncovered path:\n%v", c)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := g.WaitAndLock(ctx); err != context.Canceled {
+		t.Fatalf("g.WaitAndLock with expired context and unset gate = %v, want context.Canceled", err)
+	}
+	g.Lock()
+	g.Unlock(true)
+	if err := g.WaitAndLock(ctx); err != nil {
+		t.Fatalf("g.WaitAndLock with expired context and set gate = %v, want nil", err)
+	}
+	g.Unlock(true)
+	if err := g.WaitAndLock(context.Background()); err != nil {
+		t.Fatalf("g.WaitAndLock = %v", err)
+	}
+	g.Unlock(true)
+	if !g.LockIfSet() {
+		t.Fatalf("g.LockIfSet() = false with set gate")
+	}
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		g.Unlock(false)
+	}()
+	g.Lock()
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		g.Unlock(true)
+	}()
+	if err := g.WaitAndLock(context.Background()); err != nil {
+		t.Fatalf("g.WaitAndLock = %v", err)
+	}
+	g.Unlock(true)
+
+	if gaps := c.Gaps(); len(gaps) != 0 {
+		t.Errorf("c.Gaps() = %v, want none\n%v", gaps, c)
+	}
+	if got := c.Count(gate.CoverWaitAndLockExpiredAvailable); got != 1 {
+		t.Errorf("c.Count(CoverWaitAndLockExpiredAvailable) = %v, want 1", got)
+	}
+}
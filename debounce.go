@@ -0,0 +1,107 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate
+
+import (
+	"context"
+	"time"
+)
+
+// A Debouncer collapses bursts of triggers into a single signal.
+//
+// After Trigger is called, the debouncer fires once no trigger has occurred
+// for the quiet period, or once the maximum delay has passed since the first
+// trigger of the burst, whichever comes first.
+type Debouncer struct {
+	gate     Gate // set if the debouncer has fired and the signal has not been consumed
+	clock    Clock
+	quiet    time.Duration
+	maxDelay time.Duration
+	timer    Timer
+	pending  bool      // triggered but not yet fired
+	first    time.Time // time of first trigger in the current burst
+	last     time.Time // time of most recent trigger
+	fired    bool
+}
+
+// NewDebouncer returns a new debouncer with the given quiet period and maximum delay.
+// If maxDelay is zero, bursts may be delayed indefinitely.
+func NewDebouncer(quiet, maxDelay time.Duration, opts ...Option) *Debouncer {
+	o := newOptions(opts)
+	return &Debouncer{
+		gate:     o.newGate(false, ""),
+		clock:    o.clock,
+		quiet:    quiet,
+		maxDelay: maxDelay,
+	}
+}
+
+// Trigger records an event.
+func (d *Debouncer) Trigger() {
+	d.gate.Lock()
+	defer d.unlock()
+	now := d.clock.Now()
+	if !d.pending {
+		d.pending = true
+		d.first = now
+	}
+	d.last = now
+	d.schedule(now)
+}
+
+// Wait blocks until the debouncer fires or the context expires.
+// Each firing is delivered to a single call to Wait.
+func (d *Debouncer) Wait(ctx context.Context) error {
+	if err := d.gate.WaitAndLock(ctx); err != nil {
+		return err
+	}
+	d.fired = false
+	d.unlock()
+	return nil
+}
+
+// Stop discards any pending trigger.
+func (d *Debouncer) Stop() {
+	d.gate.Lock()
+	defer d.unlock()
+	d.pending = false
+	if d.timer != nil {
+		d.timer.Stop()
+	}
+}
+
+// schedule arranges for the debouncer to check whether to fire.
+func (d *Debouncer) schedule(now time.Time) {
+	delay := d.last.Add(d.quiet).Sub(now)
+	if d.maxDelay > 0 {
+		delay = min(delay, d.first.Add(d.maxDelay).Sub(now))
+	}
+	if d.timer == nil {
+		d.timer = d.clock.AfterFunc(delay, d.check)
+	} else {
+		d.timer.Reset(delay)
+	}
+}
+
+func (d *Debouncer) check() {
+	d.gate.Lock()
+	defer d.unlock()
+	if !d.pending {
+		return
+	}
+	now := d.clock.Now()
+	quiet := now.Sub(d.last) >= d.quiet
+	overdue := d.maxDelay > 0 && now.Sub(d.first) >= d.maxDelay
+	if !quiet && !overdue {
+		d.schedule(now)
+		return
+	}
+	d.pending = false
+	d.fired = true
+}
+
+func (d *Debouncer) unlock() {
+	d.gate.Unlock(d.fired)
+}
@@ -0,0 +1,62 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/neild/gate"
+)
+
+func TestDebouncerCollapsesBurst(t *testing.T) {
+	d := gate.NewDebouncer(5*time.Millisecond, 0)
+	for i := 0; i < 10; i++ {
+		d.Trigger()
+	}
+	if err := d.Wait(context.Background()); err != nil {
+		t.Fatalf("d.Wait = %v, want nil", err)
+	}
+	// The burst fired only once.
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := d.Wait(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("second d.Wait after one burst = %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestDebouncerMaxDelay(t *testing.T) {
+	d := gate.NewDebouncer(1*time.Hour, 5*time.Millisecond)
+	stopc := make(chan struct{})
+	defer close(stopc)
+	go func() {
+		// Trigger continuously, so the debouncer is never quiet.
+		for {
+			select {
+			case <-stopc:
+				return
+			case <-time.After(1 * time.Millisecond):
+				d.Trigger()
+			}
+		}
+	}()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	if err := d.Wait(ctx); err != nil {
+		t.Fatalf("d.Wait with continuous triggers = %v, want nil", err)
+	}
+}
+
+func TestDebouncerStop(t *testing.T) {
+	d := gate.NewDebouncer(1*time.Millisecond, 0)
+	d.Trigger()
+	d.Stop()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := d.Wait(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("d.Wait after Stop = %v, want context.DeadlineExceeded", err)
+	}
+}
@@ -0,0 +1,571 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"os"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Debug tracking records which goroutines hold and wait on each gate.
+// It is expensive, and is intended for use in tests and while diagnosing hangs.
+//
+// Gates record the goroutine which locked them as the holder until they are unlocked.
+// Tracking assumes that a gate is unlocked by the goroutine which locked it.
+
+var (
+	debugEnabled atomic.Bool
+	debugSeq     atomic.Uint64 // sequence number of the last tracked gate created
+)
+
+// SetDebug enables or disables debug tracking for gates created after it is called.
+// Gates created while tracking is disabled are never tracked.
+func SetDebug(on bool) {
+	debugEnabled.Store(on)
+}
+
+// SetDeadlockHandler sets the function called when debug tracking detects a deadlock.
+// The function is called by the goroutine which completed the deadlock, before it blocks.
+// The default handler writes the report to standard error.
+// A nil f restores the default.
+func SetDeadlockHandler(f func(*DeadlockReport)) {
+	debugMu.Lock()
+	defer debugMu.Unlock()
+	deadlockHandler = f
+}
+
+// SetLockOrderHandler sets the function called when debug tracking detects
+// gates acquired in inconsistent orders: for example, when one goroutine
+// acquires gate A and then gate B while holding A, and another acquires B and then A.
+// Such an inversion may deadlock, even if it did not do so in this run.
+//
+// Each inversion is reported once, by the goroutine whose acquisition completed it.
+// The default handler writes the report to standard error.
+// A nil f restores the default.
+//
+// To detect inversions, debug tracking retains every pair of gates
+// which have been held at the same time.
+func SetLockOrderHandler(f func(*LockOrderReport)) {
+	debugMu.Lock()
+	defer debugMu.Unlock()
+	lockOrderHandler = f
+}
+
+// A DeadlockReport describes a set of goroutines blocked on gates held by one another.
+//
+// Only waits which cannot end on their own are considered:
+// Lock, and WaitAndLock with a context that has no deadline.
+// Waits in WaitFor, such as a blocked WeightedSemaphore.Acquire,
+// end when a notification is sent rather than when a gate is released,
+// so they are not considered.
+// A wait whose context is later canceled breaks the deadlock.
+type DeadlockReport struct {
+	// Goroutines lists the goroutines in the cycle.
+	// Each goroutine waits for a gate held by the next,
+	// and the last waits for a gate held by the first.
+	Goroutines []DeadlockedGoroutine
+}
+
+// A DeadlockedGoroutine is one member of a DeadlockReport.
+type DeadlockedGoroutine struct {
+	ID            int64  // goroutine ID
+	Op            string // blocked operation: "Lock" or "WaitAndLock"
+	Gate          string // name of the gate the goroutine waits for, or ""
+	Stack         []byte // stack of the blocked operation
+	AcquiredStack []byte // stack at which the holder acquired the gate this goroutine waits for
+}
+
+func (r *DeadlockReport) String() string {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "gate: deadlock among %v goroutines\n", len(r.Goroutines))
+	for i, g := range r.Goroutines {
+		holder := r.Goroutines[(i+1)%len(r.Goroutines)].ID
+		fmt.Fprintf(&b, "\ngoroutine %v blocked in %v on %v held by goroutine %v:\n%s\n", g.ID, g.Op, gateString(g.Gate), holder, g.Stack)
+		fmt.Fprintf(&b, "%v acquired by goroutine %v at:\n%s\n", gateString(g.Gate), holder, g.AcquiredStack)
+	}
+	return b.String()
+}
+
+// A LockOrderReport describes a cycle of gates acquired in inconsistent orders.
+type LockOrderReport struct {
+	// Edges lists the acquisitions in the cycle.
+	// In each edge, a gate was acquired while holding the gate acquired in the previous edge,
+	// and the gate acquired in the last edge was held while acquiring the gate in the first.
+	Edges []LockOrderEdge
+}
+
+// A LockOrderEdge is the acquisition of a gate while holding another.
+type LockOrderEdge struct {
+	Goroutine int64  // goroutine ID
+	Gate      string // name of the gate acquired, or ""
+	Stack     []byte // stack at which the gate was acquired
+}
+
+func (r *LockOrderReport) String() string {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "gate: lock order inversion among %v gates\n", len(r.Edges))
+	for i, e := range r.Edges {
+		prev := (i + len(r.Edges) - 1) % len(r.Edges)
+		fmt.Fprintf(&b, "\n%v acquired while holding %v by goroutine %v at:\n%s\n",
+			r.gate(i), r.gate(prev), e.Goroutine, e.Stack)
+	}
+	return b.String()
+}
+
+// gate describes the gate acquired in edge i, by name or else by its index.
+func (r *LockOrderReport) gate(i int) string {
+	if name := r.Edges[i].Gate; name != "" {
+		return gateString(name)
+	}
+	return fmt.Sprintf("gate %v", i)
+}
+
+// gateString describes a gate with the given name, which may be "".
+func gateString(name string) string {
+	if name == "" {
+		return "gate"
+	}
+	return fmt.Sprintf("gate %q", name)
+}
+
+var (
+	debugMu      sync.Mutex                                      // guards the variables below, and all gateDebug fields
+	debugWaits   = map[int64]*debugWait{}                        // blocked goroutines, by goroutine ID
+	debugHeld    = map[*gateDebug]bool{}                         // locked gates
+	debugHolding = map[int64][]*gateDebug{}                      // gates held, by goroutine ID, in acquisition order
+	debugOrder   = map[*gateDebug]map[*gateDebug]LockOrderEdge{} // [a][b] is the first acquisition of b while holding a
+
+	deadlockHandler  func(*DeadlockReport)  // nil for the default
+	lockOrderHandler func(*LockOrderReport) // nil for the default
+)
+
+// gateDebug is the debug tracking state of a gate.
+type gateDebug struct {
+	seq         uint64      // creation order
+	name        string      // gate name, or ""
+	holder      int64       // ID of goroutine holding the gate, or 0 if none
+	holderSince time.Time   // time at which the holder acquired the gate
+	holderStack []byte      // stack at which the holder acquired the gate
+	stalled     bool        // the current hold has been reported as a stall
+	events      []GateEvent // ring of recent events, or nil if not recorded
+	nevents     int         // total events recorded
+	logger      atomic.Pointer[slog.Logger]
+	invariant   atomic.Pointer[func(set bool) error]
+}
+
+// A debugWait is a goroutine blocked on a gate.
+type debugWait struct {
+	d       *gateDebug
+	id      int64
+	op      string
+	since   time.Time
+	stack   []byte
+	pcs     []uintptr // callers of the blocked operation
+	stuck   bool      // the wait cannot end on its own
+	stalled bool      // the wait has been reported as a stall
+	notify  bool      // a WaitFor wait for a notification rather than for the gate
+}
+
+func newGateDebug(name string) *gateDebug {
+	if !debugEnabled.Load() && leakChecks.Load() == 0 {
+		return nil
+	}
+	d := &gateDebug{seq: debugSeq.Add(1), name: name}
+	if n := eventHistory.Load(); n > 0 {
+		d.events = make([]GateEvent, n)
+	}
+	return d
+}
+
+// acquired records that the current goroutine has locked the gate.
+func (d *gateDebug) acquired() {
+	id, stack := currentGoroutine()
+	d.acquiredBy(id, stack)
+}
+
+// released records that the gate has been unlocked.
+func (d *gateDebug) released(set bool) {
+	var id int64
+	if d.recording() {
+		id = currentGoroutineID()
+	}
+	debugMu.Lock()
+	e := d.record(EventRelease, id, set)
+	if held := debugHolding[d.holder]; held != nil {
+		held = slices.DeleteFunc(held, func(h *gateDebug) bool { return h == d })
+		if len(held) == 0 {
+			delete(debugHolding, d.holder)
+		} else {
+			debugHolding[d.holder] = held
+		}
+	}
+	d.holder = 0
+	d.holderSince = time.Time{}
+	d.holderStack = nil
+	delete(debugHeld, d)
+	debugMu.Unlock()
+	d.log(e)
+}
+
+// acquiredBy records that a goroutine has locked the gate.
+func (d *gateDebug) acquiredBy(id int64, stack []byte) {
+	debugMu.Lock()
+	d.holder = id
+	d.holderSince = time.Now()
+	d.holderStack = stack
+	d.stalled = false
+	debugHeld[d] = true
+	e := d.record(EventAcquire, id, false)
+	var report *LockOrderReport
+	for _, h := range debugHolding[id] {
+		if r := addLockOrder(h, d, id, stack); r != nil && report == nil {
+			report = r
+		}
+	}
+	debugHolding[id] = append(debugHolding[id], d)
+	handler := lockOrderHandler
+	debugMu.Unlock()
+	d.log(e)
+	if report != nil {
+		if handler == nil {
+			handler = func(r *LockOrderReport) {
+				os.Stderr.WriteString(r.String())
+			}
+		}
+		handler(report)
+	}
+}
+
+// startWait records that the current goroutine is about to block on the gate.
+// If ctx is nil, the wait is unconditional.
+func (d *gateDebug) startWait(op string, ctx context.Context) *debugWait {
+	w := d.newWait(op)
+	if ctx != nil {
+		_, hasDeadline := ctx.Deadline()
+		w.stuck = !hasDeadline
+	}
+	return d.addWait(w)
+}
+
+// startNotifyWait records that the current goroutine is about to block in WaitFor.
+func (d *gateDebug) startNotifyWait() *debugWait {
+	w := d.newWait("WaitFor")
+	w.notify = true
+	w.stuck = false // the wait ends when a channel is closed, not when the gate is released
+	return d.addWait(w)
+}
+
+func (d *gateDebug) newWait(op string) *debugWait {
+	id, stack := currentGoroutine()
+	pcs := make([]uintptr, 32)
+	return &debugWait{
+		d:     d,
+		id:    id,
+		op:    op,
+		since: time.Now(),
+		stack: stack,
+		pcs:   pcs[:runtime.Callers(4, pcs)],
+		stuck: true,
+	}
+}
+
+// addWait records a wait, and reports a deadlock if it completes one.
+func (d *gateDebug) addWait(w *debugWait) *debugWait {
+	id := w.id
+	debugMu.Lock()
+	debugWaits[id] = w
+	e := d.record(EventWait, id, false)
+	report := w.deadlock()
+	handler := deadlockHandler
+	debugMu.Unlock()
+	d.log(e)
+	if report != nil {
+		if handler == nil {
+			handler = func(r *DeadlockReport) {
+				os.Stderr.WriteString(r.String())
+			}
+		}
+		handler(report)
+	}
+	return w
+}
+
+// endNotify records that a WaitFor wait has ended,
+// because its context expired if expired is true.
+func (w *debugWait) endNotify(expired bool) {
+	debugMu.Lock()
+	if debugWaits[w.id] == w {
+		delete(debugWaits, w.id)
+	}
+	var e GateEvent
+	if expired {
+		e = w.d.record(EventWaitExpired, w.id, false)
+	}
+	debugMu.Unlock()
+	w.d.log(e)
+}
+
+// end records that the wait has ended.
+// If acquired is true, the waiting goroutine now holds the gate.
+func (w *debugWait) end(acquired bool) {
+	debugMu.Lock()
+	if debugWaits[w.id] == w {
+		delete(debugWaits, w.id)
+	}
+	var e GateEvent
+	if !acquired {
+		e = w.d.record(EventWaitExpired, w.id, false)
+	}
+	debugMu.Unlock()
+	w.d.log(e)
+	if acquired {
+		w.d.acquiredBy(w.id, w.stack)
+	}
+}
+
+// deadlock returns a report if w completes a cycle of stuck waits.
+// The debugMu must be held.
+func (w *debugWait) deadlock() *DeadlockReport {
+	if !w.stuck {
+		return nil
+	}
+	var cycle []*debugWait
+	for cur := w; ; {
+		cycle = append(cycle, cur)
+		next := debugWaits[cur.d.holder]
+		if next == nil || !next.stuck || len(cycle) > len(debugWaits) {
+			return nil
+		}
+		if next == w {
+			break
+		}
+		cur = next
+	}
+	r := &DeadlockReport{}
+	for _, cur := range cycle {
+		r.Goroutines = append(r.Goroutines, DeadlockedGoroutine{
+			ID:            cur.id,
+			Op:            cur.op,
+			Gate:          cur.d.name,
+			Stack:         cur.stack,
+			AcquiredStack: cur.d.holderStack,
+		})
+	}
+	return r
+}
+
+// currentGoroutineID returns the ID of the calling goroutine.
+func currentGoroutineID() int64 {
+	var buf [64]byte
+	return parseGoroutineID(buf[:runtime.Stack(buf[:], false)])
+}
+
+// currentGoroutine returns the ID and stack of the calling goroutine.
+func currentGoroutine() (id int64, stack []byte) {
+	buf := make([]byte, 4096)
+	for {
+		n := runtime.Stack(buf, false)
+		if n < len(buf) {
+			stack = buf[:n]
+			break
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+	return parseGoroutineID(stack), stack
+}
+
+// parseGoroutineID returns the goroutine ID from the header of a stack,
+// which begins with "goroutine N [status]:".
+func parseGoroutineID(stack []byte) int64 {
+	f := bytes.Fields(stack[:min(len(stack), 64)])
+	if len(f) < 2 {
+		return 0
+	}
+	id, _ := strconv.ParseInt(string(f[1]), 10, 64)
+	return id
+}
+
+// addLockOrder records that b was acquired while a was held.
+// If this is the first such acquisition and an earlier acquisition requires b to be acquired before a,
+// it returns a report of the inversion.
+// The debugMu must be held.
+func addLockOrder(a, b *gateDebug, id int64, stack []byte) *LockOrderReport {
+	if a == b {
+		return nil
+	}
+	if _, ok := debugOrder[a][b]; ok {
+		return nil
+	}
+	if debugOrder[a] == nil {
+		debugOrder[a] = map[*gateDebug]LockOrderEdge{}
+	}
+	edge := LockOrderEdge{Goroutine: id, Gate: b.name, Stack: stack}
+	debugOrder[a][b] = edge
+	path := lockOrderPath(b, a, map[*gateDebug]bool{})
+	if path == nil {
+		return nil
+	}
+	return &LockOrderReport{Edges: append([]LockOrderEdge{edge}, path...)}
+}
+
+// lockOrderPath returns a sequence of acquisitions leading from gate from to gate to.
+// The debugMu must be held.
+func lockOrderPath(from, to *gateDebug, seen map[*gateDebug]bool) []LockOrderEdge {
+	seen[from] = true
+	for next, edge := range debugOrder[from] {
+		if next == to {
+			return []LockOrderEdge{edge}
+		}
+		if seen[next] {
+			continue
+		}
+		if path := lockOrderPath(next, to, seen); path != nil {
+			return append([]LockOrderEdge{edge}, path...)
+		}
+	}
+	return nil
+}
+
+// A Waiter describes a goroutine blocked on a tracked gate.
+type Waiter struct {
+	Goroutine int64     // goroutine ID
+	Op        string    // blocked operation: "Lock" or "WaitAndLock"
+	Since     time.Time // time at which the goroutine blocked
+	CallSite  string    // innermost caller outside this package, as "function file:line"
+	Stack     []byte
+}
+
+// A GateDump describes a tracked gate which is locked or has blocked waiters.
+type GateDump struct {
+	Name        string      // gate name, or ""
+	Holder      int64       // ID of goroutine holding the gate, or 0 if none
+	HolderSince time.Time   // time at which the holder acquired the gate
+	HolderStack []byte      // stack at which the holder acquired the gate
+	Waiters     []Waiter    // blocked goroutines, longest waiting first
+	Events      []GateEvent // recent events, oldest first; see SetEventHistory
+}
+
+// Waiters returns the goroutines blocked on the gate, longest waiting first.
+// It returns nil if the gate is not tracked.
+func (g *Gate) Waiters() []Waiter {
+	d := g.debug()
+	if d == nil {
+		return nil
+	}
+	debugMu.Lock()
+	defer debugMu.Unlock()
+	return d.waiters()
+}
+
+// Dump returns the state of every tracked gate which is locked or has blocked waiters.
+// Gates are ordered by the time of their oldest holder or waiter.
+func Dump() []GateDump {
+	debugMu.Lock()
+	gates := maps.Clone(debugHeld)
+	for _, w := range debugWaits {
+		gates[w.d] = true
+	}
+	var dumps []GateDump
+	for d := range gates {
+		dumps = append(dumps, d.dump())
+	}
+	debugMu.Unlock()
+	slices.SortFunc(dumps, func(a, b GateDump) int {
+		return a.since().Compare(b.since())
+	})
+	return dumps
+}
+
+// WriteDump writes a human-readable description of every tracked gate
+// which is locked or has blocked waiters, as returned by Dump.
+func WriteDump(w io.Writer) error {
+	var b bytes.Buffer
+	now := time.Now()
+	for _, d := range Dump() {
+		if d.Holder != 0 {
+			fmt.Fprintf(&b, "%v held by goroutine %v for %v:\n%s\n", gateString(d.Name), d.Holder, now.Sub(d.HolderSince), d.HolderStack)
+		} else {
+			fmt.Fprintf(&b, "%v not held:\n", gateString(d.Name))
+		}
+		for _, w := range d.Waiters {
+			fmt.Fprintf(&b, "\tgoroutine %v blocked in %v for %v at %v\n", w.Goroutine, w.Op, now.Sub(w.Since), w.CallSite)
+		}
+		if len(d.Events) > 0 {
+			fmt.Fprintf(&b, "recent events:\n")
+		}
+		for _, e := range d.Events {
+			fmt.Fprintf(&b, "\t%v ago: %v\n", now.Sub(e.Time), e)
+		}
+		b.WriteString("\n")
+	}
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// dump returns the state of the gate.
+// The debugMu must be held.
+func (d *gateDebug) dump() GateDump {
+	return GateDump{
+		Name:        d.name,
+		Holder:      d.holder,
+		HolderSince: d.holderSince,
+		HolderStack: d.holderStack,
+		Waiters:     d.waiters(),
+		Events:      d.recentEvents(),
+	}
+}
+
+func (d GateDump) since() time.Time {
+	t := d.HolderSince
+	if len(d.Waiters) > 0 && (t.IsZero() || d.Waiters[0].Since.Before(t)) {
+		t = d.Waiters[0].Since
+	}
+	return t
+}
+
+// waiters returns the goroutines blocked on the gate.
+// The debugMu must be held.
+func (d *gateDebug) waiters() []Waiter {
+	var waiters []Waiter
+	for _, w := range debugWaits {
+		if w.d != d {
+			continue
+		}
+		waiters = append(waiters, Waiter{
+			Goroutine: w.id,
+			Op:        w.op,
+			Since:     w.since,
+			CallSite:  callSite(w.pcs),
+			Stack:     w.stack,
+		})
+	}
+	slices.SortFunc(waiters, func(a, b Waiter) int {
+		return a.Since.Compare(b.Since)
+	})
+	return waiters
+}
+
+// callSite returns the innermost frame of pcs outside this package.
+func callSite(pcs []uintptr) string {
+	frames := runtime.CallersFrames(pcs)
+	for {
+		f, more := frames.Next()
+		if !strings.HasPrefix(f.Function, "github.com/neild/gate.") || !more {
+			return fmt.Sprintf("%v %v:%v", f.Function, f.File, f.Line)
+		}
+	}
+}
@@ -0,0 +1,197 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/neild/gate"
+)
+
+func enableDebug(t *testing.T) {
+	gate.SetDebug(true)
+	t.Cleanup(func() {
+		gate.SetDebug(false)
+	})
+}
+
+func TestDebugDeadlock(t *testing.T) {
+	enableDebug(t)
+	reports := make(chan *gate.DeadlockReport, 1)
+	gate.SetDeadlockHandler(func(r *gate.DeadlockReport) {
+		reports <- r
+	})
+	defer gate.SetDeadlockHandler(nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	a, b := gate.New(true), gate.New(true)
+	lockedA := make(chan struct{})
+	lockedB := make(chan struct{})
+	done := make(chan error)
+	go func() {
+		a.Lock()
+		close(lockedA)
+		<-lockedB
+		err := b.WaitAndLock(ctx)
+		a.Unlock(true)
+		done <- err
+	}()
+	go func() {
+		b.Lock()
+		close(lockedB)
+		<-lockedA
+		err := a.WaitAndLock(ctx)
+		b.Unlock(true)
+		done <- err
+	}()
+	r := <-reports
+	if got, want := len(r.Goroutines), 2; got != want {
+		t.Fatalf("len(r.Goroutines) = %v, want %v", got, want)
+	}
+	for _, g := range r.Goroutines {
+		if g.Op != "WaitAndLock" {
+			t.Errorf("goroutine %v: Op = %q, want WaitAndLock", g.ID, g.Op)
+		}
+		if !strings.Contains(string(g.Stack), "TestDebugDeadlock") {
+			t.Errorf("goroutine %v: Stack does not contain test function:\n%s", g.ID, g.Stack)
+		}
+	}
+	if s := r.String(); !strings.Contains(s, "deadlock among 2 goroutines") {
+		t.Errorf("r.String() = %q, missing summary", s)
+	}
+
+	// Canceling the context breaks the deadlock.
+	cancel()
+	for i := 0; i < 2; i++ {
+		if err := <-done; err != context.Canceled {
+			t.Fatalf("WaitAndLock = %v, want context.Canceled", err)
+		}
+	}
+}
+
+func TestDebugNoDeadlockWithDeadline(t *testing.T) {
+	enableDebug(t)
+	reports := make(chan *gate.DeadlockReport, 1)
+	gate.SetDeadlockHandler(func(r *gate.DeadlockReport) {
+		reports <- r
+	})
+	defer gate.SetDeadlockHandler(nil)
+
+	g := gate.New(true)
+	g.Lock()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Millisecond)
+	defer cancel()
+	if err := g.WaitAndLock(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("g.WaitAndLock = %v, want context.DeadlineExceeded", err)
+	}
+	select {
+	case r := <-reports:
+		t.Fatalf("deadlock reported for wait with deadline:\n%v", r)
+	default:
+	}
+	g.Unlock(true)
+}
+
+func TestDebugWaiters(t *testing.T) {
+	enableDebug(t)
+	g := gate.New(false)
+	if got := g.Waiters(); len(got) != 0 {
+		t.Fatalf("g.Waiters() = %v, want none", got)
+	}
+	g.Lock()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		g.Lock()
+		g.Unlock(false)
+		if err := g.WaitAndLock(ctx); err != context.Canceled {
+			t.Errorf("g.WaitAndLock = %v, want context.Canceled", err)
+		}
+	}()
+	for len(g.Waiters()) == 0 {
+		time.Sleep(1 * time.Millisecond)
+	}
+	w := g.Waiters()[0]
+	if w.Op != "Lock" {
+		t.Errorf("w.Op = %q, want Lock", w.Op)
+	}
+	if !strings.Contains(w.CallSite, "TestDebugWaiters") {
+		t.Errorf("w.CallSite = %q, want test function", w.CallSite)
+	}
+
+	dumps := gate.Dump()
+	if len(dumps) != 1 {
+		t.Fatalf("len(gate.Dump()) = %v, want 1", len(dumps))
+	}
+	if dumps[0].Holder == 0 || len(dumps[0].Waiters) != 1 {
+		t.Fatalf("gate.Dump()[0] = %+v, want holder and one waiter", dumps[0])
+	}
+	var b strings.Builder
+	if err := gate.WriteDump(&b); err != nil {
+		t.Fatalf("gate.WriteDump = %v", err)
+	}
+	if !strings.Contains(b.String(), "blocked in Lock") {
+		t.Errorf("gate.WriteDump wrote:\n%v\nwant waiter blocked in Lock", b.String())
+	}
+
+	g.Unlock(false)
+	for len(g.Waiters()) == 0 || g.Waiters()[0].Op != "WaitAndLock" {
+		time.Sleep(1 * time.Millisecond)
+	}
+	cancel()
+	<-done
+	if got := gate.Dump(); len(got) != 0 {
+		t.Fatalf("gate.Dump() = %+v, want none after waiters return", got)
+	}
+}
+
+func TestDebugLockOrder(t *testing.T) {
+	enableDebug(t)
+	var reports []*gate.LockOrderReport
+	gate.SetLockOrderHandler(func(r *gate.LockOrderReport) {
+		reports = append(reports, r)
+	})
+	defer gate.SetLockOrderHandler(nil)
+
+	a, b, c := gate.New(false), gate.New(false), gate.New(false)
+	lockInOrder := func(gates ...*gate.Gate) {
+		for _, g := range gates {
+			g.Lock()
+		}
+		for _, g := range gates {
+			g.Unlock(false)
+		}
+	}
+	lockInOrder(&a, &b)
+	lockInOrder(&b, &c)
+	lockInOrder(&a, &c)
+	if len(reports) != 0 {
+		t.Fatalf("inversion reported for consistent order:\n%v", reports[0])
+	}
+
+	// c then a inverts the ordering a, b, c.
+	lockInOrder(&c, &a)
+	if len(reports) != 1 {
+		t.Fatalf("got %v reports, want 1", len(reports))
+	}
+	if got := len(reports[0].Edges); got < 2 {
+		t.Fatalf("len(r.Edges) = %v, want at least 2", got)
+	}
+	if s := reports[0].String(); !strings.Contains(s, "TestDebugLockOrder") {
+		t.Errorf("r.String() = %q, want stacks containing test function", s)
+	}
+
+	// Each inversion is reported once.
+	lockInOrder(&c, &a)
+	if len(reports) != 1 {
+		t.Fatalf("got %v reports after repeating inversion, want 1", len(reports))
+	}
+}
@@ -0,0 +1,119 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate
+
+import "context"
+
+// An Epoch is a counter advanced by an owner and acknowledged by participants.
+// The owner may wait until every participant has acknowledged an epoch,
+// for example to know that no participant still uses state from an earlier one.
+type Epoch struct {
+	mu      Gate // guards the fields below
+	epoch   uint64
+	parts   map[*EpochParticipant]struct{}
+	changed chan struct{} // closed when a participant acknowledges or deregisters
+}
+
+// An EpochParticipant is a participant registered with an Epoch.
+type EpochParticipant struct {
+	e     *Epoch
+	acked uint64 // guarded by e.mu
+}
+
+// NewEpoch returns a new Epoch at epoch 0 with no participants.
+func NewEpoch() *Epoch {
+	return &Epoch{
+		mu:    New(false),
+		parts: make(map[*EpochParticipant]struct{}),
+	}
+}
+
+// Advance advances the epoch and returns the new epoch.
+func (e *Epoch) Advance() uint64 {
+	e.mu.Lock()
+	defer e.mu.Unlock(false)
+	e.epoch++
+	return e.epoch
+}
+
+// Current returns the current epoch.
+func (e *Epoch) Current() uint64 {
+	e.mu.Lock()
+	defer e.mu.Unlock(false)
+	return e.epoch
+}
+
+// Register adds a participant, which is considered to have acknowledged the current epoch.
+func (e *Epoch) Register() *EpochParticipant {
+	e.mu.Lock()
+	defer e.mu.Unlock(false)
+	p := &EpochParticipant{
+		e:     e,
+		acked: e.epoch,
+	}
+	e.parts[p] = struct{}{}
+	return p
+}
+
+// Wait blocks until every participant has acknowledged the given epoch
+// or a later one, or the context expires.
+func (e *Epoch) Wait(ctx context.Context, epoch uint64) error {
+	for {
+		e.mu.Lock()
+		if e.reached(epoch) {
+			e.mu.Unlock(false)
+			return nil
+		}
+		if e.changed == nil {
+			e.changed = make(chan struct{})
+		}
+		changed := e.changed
+		e.mu.Unlock(false)
+		if err := e.mu.WaitFor(ctx, changed); err != nil {
+			return err
+		}
+	}
+}
+
+// Ack acknowledges that the participant has reached the current epoch,
+// and returns that epoch.
+func (p *EpochParticipant) Ack() uint64 {
+	e := p.e
+	e.mu.Lock()
+	defer e.mu.Unlock(false)
+	p.acked = e.epoch
+	e.notify()
+	return p.acked
+}
+
+// Deregister removes the participant.
+// Callers of Wait no longer wait for it.
+func (p *EpochParticipant) Deregister() {
+	e := p.e
+	e.mu.Lock()
+	defer e.mu.Unlock(false)
+	delete(e.parts, p)
+	e.notify()
+}
+
+// reached reports whether every participant has acknowledged epoch.
+// The epoch's mu must be held.
+func (e *Epoch) reached(epoch uint64) bool {
+	for p := range e.parts {
+		if p.acked < epoch {
+			return false
+		}
+	}
+	return true
+}
+
+// notify wakes callers of Wait.
+// The epoch's mu must be held.
+func (e *Epoch) notify() {
+	if e.changed != nil {
+		close(e.changed)
+		e.changed = nil
+	}
+}
@@ -0,0 +1,67 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/neild/gate"
+)
+
+func TestEpoch(t *testing.T) {
+	e := gate.NewEpoch()
+	a := e.Register()
+	b := e.Register()
+	epoch := e.Advance()
+	if got, want := e.Current(), uint64(1); got != want {
+		t.Fatalf("e.Current() = %v, want %v", got, want)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Millisecond)
+	defer cancel()
+	if err := e.Wait(ctx, epoch); err != context.DeadlineExceeded {
+		t.Fatalf("e.Wait before acks = %v, want context.DeadlineExceeded", err)
+	}
+
+	donec := make(chan error)
+	go func() {
+		donec <- e.Wait(context.Background(), epoch)
+	}()
+	time.Sleep(1 * time.Millisecond)
+	if got := a.Ack(); got != epoch {
+		t.Fatalf("a.Ack() = %v, want %v", got, epoch)
+	}
+	select {
+	case err := <-donec:
+		t.Fatalf("e.Wait returned %v before all participants acknowledged", err)
+	case <-time.After(1 * time.Millisecond):
+	}
+	b.Ack()
+	if err := <-donec; err != nil {
+		t.Fatalf("e.Wait = %v, want nil", err)
+	}
+}
+
+func TestEpochDeregister(t *testing.T) {
+	e := gate.NewEpoch()
+	p := e.Register()
+	epoch := e.Advance()
+	donec := make(chan error)
+	go func() {
+		donec <- e.Wait(context.Background(), epoch)
+	}()
+	time.Sleep(1 * time.Millisecond)
+	p.Deregister()
+	if err := <-donec; err != nil {
+		t.Fatalf("e.Wait after Deregister = %v, want nil", err)
+	}
+
+	// A new participant starts at the current epoch.
+	e.Register()
+	if err := e.Wait(context.Background(), epoch); err != nil {
+		t.Fatalf("e.Wait with new participant = %v, want nil", err)
+	}
+}
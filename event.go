@@ -0,0 +1,83 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate
+
+import "context"
+
+// An Event is a one-shot signal.
+// Once set, it remains set forever.
+type Event struct {
+	gate Gate // set if the event has been set
+	err  error
+}
+
+// NewEvent returns a new, unset event.
+func NewEvent() *Event {
+	return &Event{
+		gate: New(false),
+	}
+}
+
+// Set sets the event, waking all waiters.
+// It reports whether this call set the event.
+func (e *Event) Set() bool {
+	return e.SetError(nil)
+}
+
+// SetError sets the event with an error, which is returned by Wait.
+// It reports whether this call set the event.
+// If the event is already set, its error is not changed.
+func (e *Event) SetError(err error) bool {
+	if set := e.gate.Lock(); set {
+		e.gate.Unlock(true)
+		return false
+	}
+	e.err = err
+	e.gate.Unlock(true)
+	return true
+}
+
+// Wait blocks until the event is set or the context expires.
+// It returns the error the event was set with,
+// or the context's error if the context expired before the event was set.
+func (e *Event) Wait(ctx context.Context) error {
+	if err := e.gate.WaitAndLock(ctx); err != nil {
+		return err
+	}
+	defer e.gate.Unlock(true)
+	return e.err
+}
+
+// IsSet reports whether the event has been set.
+func (e *Event) IsSet() bool {
+	if !e.gate.LockIfSet() {
+		return false
+	}
+	e.gate.Unlock(true)
+	return true
+}
+
+// Signal returns a read-only view of the event.
+func (e *Event) Signal() Signal {
+	return Signal{e}
+}
+
+// A Signal is a read-only view of an Event.
+// It may be waited on, but not set.
+type Signal struct {
+	e *Event
+}
+
+// Wait blocks until the event is set or the context expires.
+// It returns the error the event was set with,
+// or the context's error if the context expired before the event was set.
+func (s Signal) Wait(ctx context.Context) error {
+	return s.e.Wait(ctx)
+}
+
+// IsSet reports whether the event has been set.
+func (s Signal) IsSet() bool {
+	return s.e.IsSet()
+}
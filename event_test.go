@@ -0,0 +1,74 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/neild/gate"
+)
+
+func TestEvent(t *testing.T) {
+	e := gate.NewEvent()
+	if set := e.IsSet(); set {
+		t.Fatalf("e.IsSet of new event = %v, want false", set)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Millisecond)
+	defer cancel()
+	if err := e.Wait(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("e.Wait of unset event = %v, want context.DeadlineExceeded", err)
+	}
+
+	donec := make(chan error)
+	go func() {
+		donec <- e.Wait(context.Background())
+	}()
+	time.Sleep(1 * time.Millisecond)
+	if ok := e.Set(); !ok {
+		t.Fatalf("first e.Set = %v, want true", ok)
+	}
+	if err := <-donec; err != nil {
+		t.Fatalf("e.Wait = %v, want nil", err)
+	}
+	if ok := e.Set(); ok {
+		t.Fatalf("second e.Set = %v, want false", ok)
+	}
+	if set := e.IsSet(); !set {
+		t.Fatalf("e.IsSet of set event = %v, want true", set)
+	}
+	// Wait succeeds when the event is set and the context is canceled.
+	if err := e.Wait(ctx); err != nil {
+		t.Fatalf("e.Wait of set event with expired context = %v, want nil", err)
+	}
+}
+
+func TestEventSetError(t *testing.T) {
+	e := gate.NewEvent()
+	wantErr := errors.New("failed")
+	e.SetError(wantErr)
+	e.SetError(errors.New("ignored"))
+	if err := e.Wait(context.Background()); err != wantErr {
+		t.Fatalf("e.Wait = %v, want %v", err, wantErr)
+	}
+}
+
+func TestEventSignal(t *testing.T) {
+	e := gate.NewEvent()
+	s := e.Signal()
+	if set := s.IsSet(); set {
+		t.Fatalf("s.IsSet of new event = %v, want false", set)
+	}
+	wantErr := errors.New("failed")
+	e.SetError(wantErr)
+	if set := s.IsSet(); !set {
+		t.Fatalf("s.IsSet of set event = %v, want true", set)
+	}
+	if err := s.Wait(context.Background()); err != wantErr {
+		t.Fatalf("s.Wait = %v, want %v", err, wantErr)
+	}
+}
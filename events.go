@@ -0,0 +1,167 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+	"time"
+)
+
+var eventHistory atomic.Int64
+
+// SetEventHistory sets the number of recent events recorded by each tracked gate
+// created after it is called. Zero disables recording.
+//
+// Only tracked gates record events, and a gate is tracked only if it was created
+// while debug tracking was enabled, so call SetDebug(true) before creating the gates.
+//
+// A gate's recent events are returned by its Events method, and are included in Dump.
+func SetEventHistory(n int) {
+	eventHistory.Store(int64(max(n, 0)))
+}
+
+// A GateEventKind is the kind of a GateEvent.
+type GateEventKind int
+
+const (
+	EventAcquire     GateEventKind = iota // the gate was locked
+	EventRelease                          // the gate was unlocked
+	EventWait                             // a goroutine began blocking on the gate
+	EventWaitExpired                      // a goroutine stopped blocking when its context expired
+	EventClose                            // a primitive built on the gate was closed
+)
+
+func (k GateEventKind) String() string {
+	switch k {
+	case EventAcquire:
+		return "acquire"
+	case EventRelease:
+		return "release"
+	case EventWait:
+		return "wait"
+	case EventWaitExpired:
+		return "wait expired"
+	case EventClose:
+		return "close"
+	}
+	return fmt.Sprintf("GateEventKind(%d)", int(k))
+}
+
+// A GateEvent is an operation on a tracked gate.
+type GateEvent struct {
+	Kind      GateEventKind
+	Goroutine int64 // ID of the goroutine performing the operation
+	Time      time.Time
+	Set       bool // for EventRelease, the condition the gate was unlocked with
+}
+
+func (e GateEvent) String() string {
+	if e.Kind == EventRelease {
+		return fmt.Sprintf("goroutine %v: release (set=%v)", e.Goroutine, e.Set)
+	}
+	return fmt.Sprintf("goroutine %v: %v", e.Goroutine, e.Kind)
+}
+
+// Events returns the gate's recent events, oldest first.
+// It returns nil if the gate is not tracked or does not record events.
+func (g *Gate) Events() []GateEvent {
+	d := g.debug()
+	if d == nil {
+		return nil
+	}
+	debugMu.Lock()
+	defer debugMu.Unlock()
+	return d.recentEvents()
+}
+
+// SetLogger sets a logger which receives the gate's events.
+// Events are logged at slog.LevelDebug.
+// A nil l disables logging.
+//
+// SetLogger has no effect if the gate is not tracked.
+// A gate is tracked only if it was created while debug tracking was enabled,
+// so call SetDebug(true) before creating the gates to be logged.
+func (g *Gate) SetLogger(l *slog.Logger) {
+	d := g.debug()
+	if d == nil {
+		return
+	}
+	d.logger.Store(l)
+}
+
+// recording reports whether the gate records or logs events.
+func (d *gateDebug) recording() bool {
+	return d.events != nil || d.logger.Load() != nil
+}
+
+// record adds an event to the gate's history.
+// It returns the event, which should be passed to log once the debugMu is released.
+// The debugMu must be held.
+func (d *gateDebug) record(kind GateEventKind, id int64, set bool) GateEvent {
+	if !d.recording() {
+		return GateEvent{}
+	}
+	e := GateEvent{
+		Kind:      kind,
+		Goroutine: id,
+		Time:      time.Now(),
+		Set:       set,
+	}
+	if d.events != nil {
+		d.events[d.nevents%len(d.events)] = e
+		d.nevents++
+	}
+	return e
+}
+
+// log writes an event to the gate's logger, if any.
+func (d *gateDebug) log(e GateEvent) {
+	l := d.logger.Load()
+	if l == nil || e.Time.IsZero() {
+		return
+	}
+	attrs := []slog.Attr{
+		slog.String("event", e.Kind.String()),
+		slog.Int64("goroutine", e.Goroutine),
+	}
+	if d.name != "" {
+		attrs = append(attrs, slog.String("gate", d.name))
+	}
+	if e.Kind == EventRelease {
+		attrs = append(attrs, slog.Bool("set", e.Set))
+	}
+	l.LogAttrs(context.Background(), slog.LevelDebug, "gate event", attrs...)
+}
+
+// recordClose records that the primitive built on the gate has been closed.
+// The primitives' Close methods call it with the gate held.
+func (g *Gate) recordClose() {
+	d := g.debug()
+	if d == nil || !d.recording() {
+		return
+	}
+	id := currentGoroutineID()
+	debugMu.Lock()
+	e := d.record(EventClose, id, false)
+	debugMu.Unlock()
+	d.log(e)
+}
+
+// recentEvents returns the gate's history, oldest first.
+// The debugMu must be held.
+func (d *gateDebug) recentEvents() []GateEvent {
+	if d.nevents == 0 {
+		return nil
+	}
+	n := len(d.events)
+	if d.nevents < n {
+		return append([]GateEvent(nil), d.events[:d.nevents]...)
+	}
+	i := d.nevents % n
+	return append(append([]GateEvent(nil), d.events[i:]...), d.events[:i]...)
+}
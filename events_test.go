@@ -0,0 +1,100 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate_test
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/neild/gate"
+)
+
+func TestEventHistory(t *testing.T) {
+	enableDebug(t)
+	gate.SetEventHistory(4)
+	defer gate.SetEventHistory(0)
+
+	g := gate.New(false)
+	g.Lock()
+	g.Unlock(true)
+	g.WaitAndLock(context.Background())
+	g.Unlock(false)
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Millisecond)
+	defer cancel()
+	g.WaitAndLock(ctx)
+
+	type event struct {
+		kind gate.GateEventKind
+		set  bool
+	}
+	want := []event{
+		{gate.EventAcquire, false},
+		{gate.EventRelease, false},
+		{gate.EventWait, false},
+		{gate.EventWaitExpired, false},
+	}
+	got := g.Events()
+	if len(got) != len(want) {
+		t.Fatalf("g.Events() = %v, want %v events", got, len(want))
+	}
+	for i, e := range got {
+		if (event{e.Kind, e.Set}) != want[i] {
+			t.Errorf("g.Events()[%v] = %v, want %v (set=%v)", i, e, want[i].kind, want[i].set)
+		}
+		if e.Goroutine == 0 {
+			t.Errorf("g.Events()[%v].Goroutine = 0, want goroutine ID", i)
+		}
+		if i > 0 && e.Time.Before(got[i-1].Time) {
+			t.Errorf("g.Events() out of order: %v before %v", got[i-1].Time, e.Time)
+		}
+	}
+}
+
+func TestEventClose(t *testing.T) {
+	enableDebug(t)
+	gate.SetEventHistory(4)
+	defer gate.SetEventHistory(0)
+
+	r := gate.NewRefCount()
+	r.Close()
+	var kinds []gate.GateEventKind
+	for _, e := range gate.RefCountGate(r).Events() {
+		kinds = append(kinds, e.Kind)
+	}
+	want := []gate.GateEventKind{gate.EventRelease, gate.EventAcquire, gate.EventClose, gate.EventRelease}
+	if !slices.Equal(kinds, want) {
+		t.Errorf("RefCount events after Close = %v, want %v", kinds, want)
+	}
+}
+
+func TestSetLogger(t *testing.T) {
+	enableDebug(t)
+	var buf bytes.Buffer
+	l := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	g := gate.New(false)
+	g.SetLogger(l)
+	g.Lock()
+	g.Unlock(true)
+	g.SetLogger(nil)
+	g.Lock()
+	g.Unlock(false)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("logged %v lines, want 2:\n%v", len(lines), buf.String())
+	}
+	if !strings.Contains(lines[0], "event=acquire") {
+		t.Errorf("first line = %q, want acquire event", lines[0])
+	}
+	if !strings.Contains(lines[1], "event=release") || !strings.Contains(lines[1], "set=true") {
+		t.Errorf("second line = %q, want release event with set=true", lines[1])
+	}
+}
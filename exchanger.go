@@ -0,0 +1,61 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate
+
+import "context"
+
+// An Exchanger is a rendezvous point at which pairs of goroutines swap values.
+type Exchanger[T any] struct {
+	mu      Gate // guards waiting
+	waiting *exchange[T]
+}
+
+// An exchange is a party waiting at an Exchanger for a partner.
+type exchange[T any] struct {
+	v     T
+	reply T
+	done  *Event // set when a partner has arrived
+}
+
+// NewExchanger returns a new Exchanger.
+func NewExchanger[T any]() *Exchanger[T] {
+	return &Exchanger[T]{
+		mu: New(false),
+	}
+}
+
+// Exchange waits for another goroutine to call Exchange,
+// then gives it v and returns the value it provided.
+// It returns an error if the context expires before a partner arrives.
+func (x *Exchanger[T]) Exchange(ctx context.Context, v T) (T, error) {
+	x.mu.Lock()
+	if w := x.waiting; w != nil {
+		x.waiting = nil
+		x.mu.Unlock(false)
+		w.reply = v
+		w.done.Set()
+		return w.v, nil
+	}
+	w := &exchange[T]{
+		v:    v,
+		done: NewEvent(),
+	}
+	x.waiting = w
+	x.mu.Unlock(false)
+
+	if err := w.done.Wait(ctx); err != nil {
+		x.mu.Lock()
+		if x.waiting == w {
+			x.waiting = nil
+			x.mu.Unlock(false)
+			var zero T
+			return zero, err
+		}
+		x.mu.Unlock(false)
+		// A partner took our value before we could withdraw it.
+		w.done.Wait(context.Background())
+	}
+	return w.reply, nil
+}
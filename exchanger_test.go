@@ -0,0 +1,72 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/neild/gate"
+)
+
+func TestExchanger(t *testing.T) {
+	x := gate.NewExchanger[string]()
+	donec := make(chan string)
+	go func() {
+		v, _ := x.Exchange(context.Background(), "a")
+		donec <- v
+	}()
+	time.Sleep(1 * time.Millisecond)
+	v, err := x.Exchange(context.Background(), "b")
+	if err != nil || v != "a" {
+		t.Fatalf("x.Exchange(b) = %q, %v; want %q, nil", v, err, "a")
+	}
+	if v := <-donec; v != "b" {
+		t.Fatalf("x.Exchange(a) = %q, want %q", v, "b")
+	}
+}
+
+func TestExchangerContextExpired(t *testing.T) {
+	x := gate.NewExchanger[int]()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Millisecond)
+	defer cancel()
+	if _, err := x.Exchange(ctx, 1); err != context.DeadlineExceeded {
+		t.Fatalf("x.Exchange with no partner = %v, want context.DeadlineExceeded", err)
+	}
+
+	// The abandoned value is not given to later parties.
+	donec := make(chan int)
+	go func() {
+		v, _ := x.Exchange(context.Background(), 2)
+		donec <- v
+	}()
+	time.Sleep(1 * time.Millisecond)
+	if v, err := x.Exchange(context.Background(), 3); err != nil || v != 2 {
+		t.Fatalf("x.Exchange(3) = %v, %v; want 2, nil", v, err)
+	}
+	if v := <-donec; v != 3 {
+		t.Fatalf("x.Exchange(2) = %v, want 3", v)
+	}
+}
+
+func TestExchangerMany(t *testing.T) {
+	x := gate.NewExchanger[int]()
+	const n = 100
+	gotc := make(chan int, n)
+	for i := 0; i < n; i++ {
+		go func() {
+			v, _ := x.Exchange(context.Background(), i)
+			gotc <- v
+		}()
+	}
+	sum := 0
+	for i := 0; i < n; i++ {
+		sum += <-gotc
+	}
+	if want := n * (n - 1) / 2; sum != want {
+		t.Fatalf("sum of exchanged values = %v, want %v", sum, want)
+	}
+}
@@ -0,0 +1,103 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate
+
+import (
+	"context"
+	"errors"
+	"slices"
+)
+
+// ErrExecutorShutdown is returned when submitting work to an Executor which has been shut down.
+var ErrExecutorShutdown = errors.New("gate: executor shut down")
+
+// An Executor runs functions associated with keys.
+// Functions with the same key run one at a time, in the order they were submitted.
+// Functions with different keys run concurrently, up to a limit.
+type Executor[K comparable] struct {
+	gate     Gate // set if no functions are queued or running
+	limit    int
+	running  int            // worker goroutines
+	pending  map[K][]func() // queued functions, by key
+	ready    []K            // keys with queued functions and none running
+	busy     map[K]bool     // keys with a function running
+	shutdown bool
+}
+
+// NewExecutor returns a new executor which runs at most limit functions at once.
+func NewExecutor[K comparable](limit int) *Executor[K] {
+	return &Executor[K]{
+		gate:    New(true),
+		limit:   limit,
+		pending: make(map[K][]func()),
+		busy:    make(map[K]bool),
+	}
+}
+
+// Submit queues fn to run after all functions previously submitted with the same key.
+// It does not block.
+// It returns ErrExecutorShutdown if the executor has been shut down.
+func (e *Executor[K]) Submit(key K, fn func()) error {
+	e.gate.Lock()
+	defer e.unlock()
+	if e.shutdown {
+		return ErrExecutorShutdown
+	}
+	e.pending[key] = append(e.pending[key], fn)
+	if len(e.pending[key]) == 1 && !e.busy[key] {
+		e.ready = append(e.ready, key)
+	}
+	if e.running < e.limit && len(e.ready) > 0 {
+		e.running++
+		go e.work()
+	}
+	return nil
+}
+
+// Shutdown stops the executor accepting new functions,
+// and blocks until all queued functions have run or the context expires.
+func (e *Executor[K]) Shutdown(ctx context.Context) error {
+	e.gate.Lock()
+	e.shutdown = true
+	e.unlock()
+	if err := e.gate.WaitAndLock(ctx); err != nil {
+		return err
+	}
+	e.unlock()
+	return nil
+}
+
+// work runs functions for ready keys until there are none.
+func (e *Executor[K]) work() {
+	for {
+		e.gate.Lock()
+		if len(e.ready) == 0 {
+			e.running--
+			e.unlock()
+			return
+		}
+		key := e.ready[0]
+		e.ready = slices.Delete(e.ready, 0, 1)
+		fn := e.pending[key][0]
+		e.pending[key] = slices.Delete(e.pending[key], 0, 1)
+		e.busy[key] = true
+		e.unlock()
+
+		fn()
+
+		e.gate.Lock()
+		delete(e.busy, key)
+		if len(e.pending[key]) > 0 {
+			e.ready = append(e.ready, key)
+		} else {
+			delete(e.pending, key)
+		}
+		e.unlock()
+	}
+}
+
+func (e *Executor[K]) unlock() {
+	e.gate.Unlock(len(e.pending) == 0 && len(e.busy) == 0)
+}
@@ -0,0 +1,81 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate_test
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/neild/gate"
+)
+
+func TestExecutor(t *testing.T) {
+	e := gate.NewExecutor[string](4)
+	var (
+		mu             sync.Mutex
+		got            = map[string][]int{}
+		running        = map[string]bool{}
+		active, maxAct atomic.Int32
+	)
+	for i := 0; i < 10; i++ {
+		for _, key := range []string{"a", "b", "c", "d", "e", "f"} {
+			e.Submit(key, func() {
+				n := active.Add(1)
+				defer active.Add(-1)
+				for {
+					m := maxAct.Load()
+					if n <= m || maxAct.CompareAndSwap(m, n) {
+						break
+					}
+				}
+				mu.Lock()
+				if running[key] {
+					t.Errorf("two functions for key %q ran concurrently", key)
+				}
+				running[key] = true
+				mu.Unlock()
+				time.Sleep(100 * time.Microsecond)
+				mu.Lock()
+				running[key] = false
+				got[key] = append(got[key], i)
+				mu.Unlock()
+			})
+		}
+	}
+	if err := e.Shutdown(context.Background()); err != nil {
+		t.Fatalf("e.Shutdown = %v, want nil", err)
+	}
+	want := []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}
+	for key, order := range got {
+		if !slices.Equal(order, want) {
+			t.Errorf("functions for key %q ran in order %v, want %v", key, order, want)
+		}
+	}
+	if got := maxAct.Load(); got > 4 {
+		t.Errorf("%v functions ran at once, want at most 4", got)
+	}
+	if err := e.Submit("a", func() {}); err != gate.ErrExecutorShutdown {
+		t.Fatalf("e.Submit after Shutdown = %v, want %v", err, gate.ErrExecutorShutdown)
+	}
+}
+
+func TestExecutorShutdownContextExpired(t *testing.T) {
+	e := gate.NewExecutor[int](1)
+	releasec := make(chan struct{})
+	e.Submit(1, func() { <-releasec })
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Millisecond)
+	defer cancel()
+	if err := e.Shutdown(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("e.Shutdown with running function = %v, want context.DeadlineExceeded", err)
+	}
+	close(releasec)
+	if err := e.Shutdown(context.Background()); err != nil {
+		t.Fatalf("e.Shutdown = %v, want nil", err)
+	}
+}
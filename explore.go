@@ -0,0 +1,494 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// A Scenario is a concurrent test case run under controlled schedules by Explore.
+//
+// The goroutines of a scenario run one at a time, and switch only at gate operations:
+// Lock, WaitAndLock, LockIfSet, and Unlock. A schedule determines which goroutine runs
+// at each switch, so a scenario run under the same schedule behaves the same way.
+//
+// Scenario goroutines must synchronize only through gates,
+// and through primitives which are built solely on gates, such as Semaphore and Event.
+// Blocking any other way, such as on a channel, a sync.Mutex, or time.Sleep,
+// hangs the exploration.
+// Gates used by a scenario must be created by it, and unlocked only by scenario goroutines.
+//
+// A WaitAndLock whose context has a deadline may time out at any switch while it is blocked,
+// returning context.DeadlineExceeded, so schedules explore both the wait ending and the deadline passing.
+type Scenario struct {
+	x      *exploration
+	checks []func() error
+}
+
+// Go starts f in a new scenario goroutine.
+func (s *Scenario) Go(f func()) {
+	s.x.spawn(f)
+}
+
+// Check registers a function which is called once all the scenario's goroutines have exited,
+// to verify the scenario's results. A non-nil error fails the schedule.
+func (s *Scenario) Check(f func() error) {
+	s.checks = append(s.checks, f)
+}
+
+// A ScheduleError describes a schedule under which a scenario failed.
+type ScheduleError struct {
+	// Schedule is the choice of goroutine made at each switch with more than one runnable goroutine,
+	// as an index into the runnable goroutines in the order they were started.
+	// It may be passed to RunSchedule to reproduce the failure.
+	Schedule []int
+
+	// Steps records every switch of the failing run, in order.
+	Steps []ScheduleStep
+
+	Err error
+}
+
+// A ScheduleStep is one switch in a scenario run:
+// the goroutine which was scheduled to run, and the gate operation at which it had stopped.
+type ScheduleStep struct {
+	// Goroutine is the index of the goroutine, in the order the scenario's goroutines were started.
+	Goroutine int
+
+	// Op is the operation at which the goroutine had stopped:
+	//   - "start", for a goroutine which has not yet run;
+	//   - "Lock", "WaitAndLock", or "LockIfSet", for a goroutine about to attempt a lock;
+	//   - "Unlock", for a goroutine which has just unlocked a gate;
+	//   - "wake", for a blocked goroutine whose gate was unlocked;
+	//   - "timeout", for a blocked goroutine whose wait is ending because of its context.
+	Op string
+
+	// Gate is the name of the gate operated on, or "" if it has none.
+	Gate string
+
+	// Runnable is the number of goroutines which could have been scheduled.
+	Runnable int
+}
+
+func (s ScheduleStep) String() string {
+	str := fmt.Sprintf("goroutine %v %v", s.Goroutine, s.Op)
+	if s.Gate != "" {
+		str += " " + strconv.Quote(s.Gate)
+	}
+	return str
+}
+
+func (e *ScheduleError) Error() string {
+	return fmt.Sprintf("gate: scenario failed under schedule %v: %v", e.Schedule, e.Err)
+}
+
+func (e *ScheduleError) Unwrap() error {
+	return e.Err
+}
+
+var (
+	// ErrScenarioDeadlock is reported when every goroutine in a scenario is blocked on a gate.
+	ErrScenarioDeadlock = errors.New("gate: all scenario goroutines blocked")
+
+	// ErrScheduleMismatch is returned by RunSchedule when a schedule does not match the scenario's behavior.
+	ErrScheduleMismatch = errors.New("gate: schedule does not match scenario")
+)
+
+// exploreMaxSteps bounds the number of switches in a single schedule.
+const exploreMaxSteps = 10000
+
+var (
+	exploreMu sync.Mutex // held while exploring
+	exploring atomic.Pointer[exploration]
+)
+
+// Explore runs scenario once for each of up to limit distinct schedules,
+// systematically exploring the possible interleavings of its goroutines.
+// A schedule fails if a scenario goroutine panics, if all of its goroutines block,
+// or if a function registered with Check returns an error.
+//
+// Explore returns a *ScheduleError describing the first failing schedule,
+// or nil if no explored schedule failed.
+// Only one exploration may run at a time; concurrent calls to Explore and RunSchedule
+// wait for each other.
+func Explore(limit int, scenario func(*Scenario)) error {
+	exploreMu.Lock()
+	defer exploreMu.Unlock()
+	var prefix []int
+	for n := 0; n < limit; n++ {
+		r := runScenario(prefix, nil, scenario)
+		if r.err != nil {
+			return r.scheduleError()
+		}
+		choices, counts := r.choices, r.counts
+		// Advance to the next unexplored schedule: change the last choice
+		// which has an untried alternative.
+		prefix = nil
+		for i := len(choices) - 1; i >= 0; i-- {
+			if choices[i]+1 < counts[i] {
+				prefix = append(choices[:i:i], choices[i]+1)
+				break
+			}
+		}
+		if prefix == nil {
+			return nil
+		}
+	}
+	return nil
+}
+
+// ExploreRandom runs scenario under runs random schedules,
+// for scenarios with too many interleavings for Explore to cover.
+// The schedules are determined by seed, so a run with the same seed repeats them.
+//
+// ExploreRandom returns a *ScheduleError describing the first failing schedule,
+// which RunSchedule replays, or nil if no schedule failed.
+func ExploreRandom(seed uint64, runs int, scenario func(*Scenario)) error {
+	exploreMu.Lock()
+	defer exploreMu.Unlock()
+	rng := rand.New(rand.NewPCG(seed, seed))
+	for n := 0; n < runs; n++ {
+		r := runScenario(nil, rng.IntN, scenario)
+		if r.err != nil {
+			return r.scheduleError()
+		}
+	}
+	return nil
+}
+
+// RunSchedule runs scenario once under a schedule, such as one reported by Explore.
+// It returns a *ScheduleError if the scenario fails.
+//
+// At switches beyond the end of the schedule, RunSchedule runs the first runnable goroutine,
+// so a prefix of a schedule replays the run up to the end of the prefix.
+func RunSchedule(schedule []int, scenario func(*Scenario)) error {
+	exploreMu.Lock()
+	defer exploreMu.Unlock()
+	r := runScenario(schedule, nil, scenario)
+	if r.err != nil {
+		return r.scheduleError()
+	}
+	return nil
+}
+
+// ParseSchedule parses a schedule in the format used by ScheduleError's Error method,
+// such as "[0 1 1 0]", for passing to RunSchedule.
+func ParseSchedule(s string) ([]int, error) {
+	fields, ok := strings.CutPrefix(s, "[")
+	if ok {
+		fields, ok = strings.CutSuffix(fields, "]")
+	}
+	if !ok {
+		return nil, fmt.Errorf("gate: invalid schedule %q", s)
+	}
+	var schedule []int
+	for _, f := range strings.Fields(fields) {
+		i, err := strconv.Atoi(f)
+		if err != nil || i < 0 {
+			return nil, fmt.Errorf("gate: invalid schedule %q", s)
+		}
+		schedule = append(schedule, i)
+	}
+	return schedule, nil
+}
+
+// An exploration is a single run of a scenario.
+type exploration struct {
+	mu      sync.Mutex // guards the fields below
+	threads []*xthread // in the order started
+	byID    map[int64]*xthread
+	err     error // failure of a scenario goroutine
+
+	yieldc chan *xthread // receives each goroutine as it stops running
+	abort  chan struct{} // closed to abandon the run
+}
+
+// An xthread is a scenario goroutine.
+type xthread struct {
+	x       *exploration
+	run     chan struct{} // receives when the goroutine may run
+	blocked chan struct{} // the set chan of the gate the goroutine is blocked on, or nil
+	ctx     context.Context
+	done    bool
+	op      string // operation at which the goroutine stopped, as in ScheduleStep
+	gate    string // name of the gate operated on
+}
+
+// opWait is the op of a goroutine blocked on a gate.
+const opWait = "wait"
+
+// A scenarioRun is the result of runScenario.
+type scenarioRun struct {
+	choices []int // choice made at each switch with more than one runnable goroutine
+	counts  []int // number of runnable goroutines at each such switch
+	steps   []ScheduleStep
+	err     error
+}
+
+func (r *scenarioRun) scheduleError() *ScheduleError {
+	return &ScheduleError{Schedule: r.choices, Steps: r.steps, Err: r.err}
+}
+
+// runScenario runs scenario under a schedule beginning with prefix.
+// Choices after the prefix are made by choose, which returns a number in [0, n),
+// or are the first runnable goroutine if choose is nil.
+func runScenario(prefix []int, choose func(n int) int, scenario func(*Scenario)) (r scenarioRun) {
+	x := &exploration{
+		byID:   map[int64]*xthread{},
+		yieldc: make(chan *xthread),
+		abort:  make(chan struct{}),
+	}
+	exploring.Store(x)
+	defer exploring.Store(nil)
+	s := &Scenario{x: x}
+	x.spawn(func() { scenario(s) })
+
+	for steps := 0; ; steps++ {
+		if steps > exploreMaxSteps {
+			r.err = fmt.Errorf("schedule exceeded %v steps", exploreMaxSteps)
+			break
+		}
+		x.mu.Lock()
+		r.err = x.err
+		var runnable []int // indexes in x.threads
+		alive := false
+		for j, t := range x.threads {
+			alive = alive || !t.done
+			if t.runnable() {
+				runnable = append(runnable, j)
+			}
+		}
+		x.mu.Unlock()
+		if r.err != nil {
+			break
+		}
+		if len(runnable) == 0 {
+			if alive {
+				r.err = ErrScenarioDeadlock
+			}
+			break
+		}
+		i := 0
+		if len(runnable) > 1 {
+			if n := len(r.choices); n < len(prefix) {
+				i = prefix[n]
+				if i < 0 || i >= len(runnable) {
+					r.err = ErrScheduleMismatch
+					break
+				}
+			} else if choose != nil {
+				i = choose(len(runnable))
+			}
+			r.choices = append(r.choices, i)
+			r.counts = append(r.counts, len(runnable))
+		}
+		t := x.threads[runnable[i]]
+		r.steps = append(r.steps, t.step(runnable[i], len(runnable)))
+		t.run <- struct{}{}
+		<-x.yieldc
+	}
+	if r.err != nil {
+		close(x.abort)
+		return r
+	}
+	for _, f := range s.checks {
+		if err := f(); err != nil {
+			r.err = err
+			return r
+		}
+	}
+	return r
+}
+
+// step returns the switch which schedules the goroutine, which has index i in the scenario.
+func (t *xthread) step(i, runnable int) ScheduleStep {
+	t.x.mu.Lock()
+	defer t.x.mu.Unlock()
+	op := t.op
+	if op == opWait {
+		if t.blocked != nil {
+			op = "timeout"
+		} else {
+			op = "wake"
+		}
+	}
+	return ScheduleStep{Goroutine: i, Op: op, Gate: t.gate, Runnable: runnable}
+}
+
+// spawn starts a scenario goroutine, which waits to be scheduled.
+func (x *exploration) spawn(f func()) {
+	t := &xthread{
+		x:   x,
+		run: make(chan struct{}),
+		op:  "start",
+	}
+	x.mu.Lock()
+	x.threads = append(x.threads, t)
+	x.mu.Unlock()
+	registered := make(chan struct{})
+	go func() {
+		x.mu.Lock()
+		x.byID[currentGoroutineID()] = t
+		x.mu.Unlock()
+		close(registered)
+		select {
+		case <-t.run:
+		case <-x.abort:
+			return
+		}
+		defer t.exit()
+		defer func() {
+			if p := recover(); p != nil {
+				x.mu.Lock()
+				if x.err == nil {
+					x.err = fmt.Errorf("panic: %v", p)
+				}
+				x.mu.Unlock()
+			}
+		}()
+		f()
+	}()
+	<-registered
+}
+
+// creatingExploration returns the running scenario, if the calling goroutine is part of it.
+// Gates created by a scenario goroutine are scheduled by the scenario.
+func creatingExploration() *exploration {
+	x := exploring.Load()
+	if x == nil || x.thread() == nil {
+		return nil
+	}
+	return x
+}
+
+// thread returns the calling goroutine, if it is part of the scenario.
+func (x *exploration) thread() *xthread {
+	id := currentGoroutineID()
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	return x.byID[id]
+}
+
+// runnable reports whether the goroutine may be scheduled.
+// The exploration's mu must be held.
+func (t *xthread) runnable() bool {
+	if t.done {
+		return false
+	}
+	if t.blocked == nil {
+		return true
+	}
+	if t.ctx == nil {
+		return false
+	}
+	_, hasDeadline := t.ctx.Deadline()
+	return hasDeadline || t.ctx.Err() != nil
+}
+
+// yield stops the goroutine until it is scheduled again,
+// recording that it stopped at the operation op on g.
+// If blocked is non-nil, the goroutine is not runnable until the gate with that set chan is unlocked
+// or ctx expires, or at any time if ctx has a deadline.
+// It reports whether the gate was unlocked.
+func (t *xthread) yield(op string, g *Gate, blocked chan struct{}, ctx context.Context) (woken bool) {
+	t.x.mu.Lock()
+	t.blocked, t.ctx = blocked, ctx
+	t.op, t.gate = op, g.Name()
+	t.x.mu.Unlock()
+	select {
+	case t.x.yieldc <- t:
+	case <-t.x.abort:
+		runtime.Goexit()
+	}
+	select {
+	case <-t.run:
+	case <-t.x.abort:
+		runtime.Goexit()
+	}
+	t.x.mu.Lock()
+	defer t.x.mu.Unlock()
+	woken = t.blocked == nil
+	t.blocked, t.ctx = nil, nil
+	return woken
+}
+
+// exit records that the goroutine has exited.
+func (t *xthread) exit() {
+	t.x.mu.Lock()
+	t.done = true
+	t.x.mu.Unlock()
+	select {
+	case t.x.yieldc <- t:
+	case <-t.x.abort:
+	}
+}
+
+// lock is Lock, WaitAndLock, or LockIfSet for a scenario goroutine.
+// If wait is false, it acquires the gate regardless of its condition.
+// If ctx is nil, it does not block.
+func (t *xthread) lock(g *Gate, ctx context.Context, wait bool) (set bool, err error) {
+	op := "WaitAndLock"
+	switch {
+	case !wait:
+		op = "Lock"
+	case ctx == nil:
+		op = "LockIfSet"
+	}
+	t.yield(op, g, nil, nil)
+	for {
+		select {
+		case <-g.set:
+			return true, nil
+		default:
+		}
+		if !wait {
+			select {
+			case <-g.unset:
+				return false, nil
+			default:
+			}
+		}
+		if ctx == nil {
+			return false, errWouldBlock
+		}
+		if err := ctx.Err(); err != nil {
+			return false, err
+		}
+		if !t.yield(opWait, g, g.set, ctx) {
+			// Scheduled while the gate is unavailable: the wait times out.
+			if err := ctx.Err(); err != nil {
+				return false, err
+			}
+			return false, context.DeadlineExceeded
+		}
+	}
+}
+
+var errWouldBlock = errors.New("would block")
+
+// unlock is Unlock for a scenario goroutine.
+func (t *xthread) unlock(g *Gate, set bool) {
+	if set {
+		g.set <- struct{}{}
+	} else {
+		g.unset <- struct{}{}
+	}
+	t.x.mu.Lock()
+	for _, u := range t.x.threads {
+		if u.blocked == g.set {
+			u.blocked, u.ctx = nil, nil
+		}
+	}
+	t.x.mu.Unlock()
+	t.yield("Unlock", g, nil, nil)
+}
@@ -0,0 +1,216 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/neild/gate"
+)
+
+// racyIncrement increments n non-atomically, releasing mu between the read and the write.
+func racyIncrement(mu *gate.Gate, n *int) {
+	mu.Lock()
+	v := *n
+	mu.Unlock(false)
+	mu.Lock()
+	*n = v + 1
+	mu.Unlock(false)
+}
+
+func incrementScenario(increment func(mu *gate.Gate, n *int)) func(*gate.Scenario) {
+	return func(s *gate.Scenario) {
+		mu := gate.New(false)
+		n := 0
+		for i := 0; i < 2; i++ {
+			s.Go(func() { increment(&mu, &n) })
+		}
+		s.Check(func() error {
+			if n != 2 {
+				return fmt.Errorf("n = %v, want 2", n)
+			}
+			return nil
+		})
+	}
+}
+
+func TestExploreFindsFailure(t *testing.T) {
+	scenario := incrementScenario(racyIncrement)
+	err := gate.Explore(1000, scenario)
+	var serr *gate.ScheduleError
+	if !errors.As(err, &serr) {
+		t.Fatalf("gate.Explore = %v, want ScheduleError", err)
+	}
+	// The failing schedule reproduces the failure.
+	for i := 0; i < 10; i++ {
+		if err := gate.RunSchedule(serr.Schedule, scenario); err == nil {
+			t.Fatalf("gate.RunSchedule(%v) = nil, want failure", serr.Schedule)
+		}
+	}
+}
+
+func TestExploreCorrectScenario(t *testing.T) {
+	scenario := incrementScenario(func(mu *gate.Gate, n *int) {
+		mu.Lock()
+		*n++
+		mu.Unlock(false)
+	})
+	if err := gate.Explore(1000, scenario); err != nil {
+		t.Fatalf("gate.Explore = %v, want nil", err)
+	}
+}
+
+func TestExploreDeadlock(t *testing.T) {
+	err := gate.Explore(1000, func(s *gate.Scenario) {
+		a, b := gate.New(false), gate.New(false)
+		lockBoth := func(x, y *gate.Gate) {
+			x.Lock()
+			y.Lock()
+			y.Unlock(false)
+			x.Unlock(false)
+		}
+		s.Go(func() { lockBoth(&a, &b) })
+		s.Go(func() { lockBoth(&b, &a) })
+	})
+	if !errors.Is(err, gate.ErrScenarioDeadlock) {
+		t.Fatalf("gate.Explore = %v, want ErrScenarioDeadlock", err)
+	}
+}
+
+func TestExplorePanic(t *testing.T) {
+	err := gate.Explore(1000, func(s *gate.Scenario) {
+		sem := gate.NewSemaphore(1)
+		s.Go(func() {
+			if !sem.TryAcquire() {
+				panic("semaphore unavailable")
+			}
+		})
+		s.Go(func() {
+			if !sem.TryAcquire() {
+				panic("semaphore unavailable")
+			}
+		})
+	})
+	if err == nil {
+		t.Fatalf("gate.Explore = nil, want panic reported")
+	}
+}
+
+func TestExploreDeadline(t *testing.T) {
+	var timeouts, acquired int
+	err := gate.Explore(1000, func(s *gate.Scenario) {
+		g := gate.New(false)
+		var err error
+		s.Go(func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 1*time.Hour)
+			defer cancel()
+			if err = g.WaitAndLock(ctx); err == nil {
+				g.Unlock(true)
+			}
+		})
+		s.Go(func() {
+			g.Lock()
+			g.Unlock(true)
+		})
+		s.Check(func() error {
+			switch err {
+			case nil:
+				acquired++
+			case context.DeadlineExceeded:
+				timeouts++
+			default:
+				return fmt.Errorf("WaitAndLock = %v", err)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		t.Fatalf("gate.Explore = %v, want nil", err)
+	}
+	if timeouts == 0 || acquired == 0 {
+		t.Fatalf("explored %v timeouts and %v acquisitions, want both", timeouts, acquired)
+	}
+}
+
+func TestExploreRandom(t *testing.T) {
+	scenario := incrementScenario(racyIncrement)
+	err := gate.ExploreRandom(1, 1000, scenario)
+	var serr *gate.ScheduleError
+	if !errors.As(err, &serr) {
+		t.Fatalf("gate.ExploreRandom = %v, want ScheduleError", err)
+	}
+	// The same seed finds the same failure.
+	err2 := gate.ExploreRandom(1, 1000, scenario)
+	if err2 == nil || err2.Error() != err.Error() {
+		t.Fatalf("gate.ExploreRandom with the same seed = %v, want %v", err2, err)
+	}
+	// The failing schedule, as printed in the error, replays the failure.
+	schedule, perr := gate.ParseSchedule(fmt.Sprint(serr.Schedule))
+	if perr != nil {
+		t.Fatalf("gate.ParseSchedule: %v", perr)
+	}
+	err = gate.RunSchedule(schedule, scenario)
+	var rerr *gate.ScheduleError
+	if !errors.As(err, &rerr) {
+		t.Fatalf("gate.RunSchedule(%v) = %v, want failure", schedule, err)
+	}
+	if got, want := fmt.Sprint(rerr.Steps), fmt.Sprint(serr.Steps); got != want {
+		t.Errorf("replayed steps:\n%v\nwant:\n%v", got, want)
+	}
+
+	if err := gate.ExploreRandom(1, 100, incrementScenario(func(mu *gate.Gate, n *int) {
+		mu.Lock()
+		*n++
+		mu.Unlock(false)
+	})); err != nil {
+		t.Errorf("gate.ExploreRandom of a correct scenario = %v, want nil", err)
+	}
+}
+
+func TestScheduleSteps(t *testing.T) {
+	err := gate.RunSchedule(nil, func(s *gate.Scenario) {
+		g := gate.NewNamed(false, "g")
+		s.Go(func() {
+			g.Lock()
+			g.Unlock(true)
+		})
+		s.Check(func() error {
+			return errors.New("fail")
+		})
+	})
+	var serr *gate.ScheduleError
+	if !errors.As(err, &serr) {
+		t.Fatalf("gate.RunSchedule = %v, want ScheduleError", err)
+	}
+	// NewNamed unlocks the gate it creates, which is a switch.
+	want := `[goroutine 0 start goroutine 0 Unlock "g" goroutine 1 start goroutine 1 Lock "g" goroutine 1 Unlock "g"]`
+	if got := fmt.Sprint(serr.Steps); got != want {
+		t.Errorf("steps = %v, want %v", got, want)
+	}
+}
+
+func TestParseSchedule(t *testing.T) {
+	for _, test := range []struct {
+		s    string
+		want []int
+	}{
+		{"[]", nil},
+		{"[0 1 2]", []int{0, 1, 2}},
+	} {
+		got, err := gate.ParseSchedule(test.s)
+		if err != nil || fmt.Sprint(got) != fmt.Sprint(test.want) {
+			t.Errorf("gate.ParseSchedule(%q) = %v, %v, want %v", test.s, got, err, test.want)
+		}
+	}
+	for _, s := range []string{"", "0 1", "[0 x]", "[-1]"} {
+		if _, err := gate.ParseSchedule(s); err == nil {
+			t.Errorf("gate.ParseSchedule(%q) succeeded, want error", s)
+		}
+	}
+}
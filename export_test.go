@@ -0,0 +1,29 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate
+
+import "time"
+
+func KeyedMutexLen[K comparable](km *KeyedMutex[K]) int {
+	return km.len()
+}
+
+func LoaderLen[K comparable, V any](l *Loader[K, V]) int {
+	return l.len()
+}
+
+func RegistryLen(r *Registry) int {
+	return r.len()
+}
+
+func RefCountGate(r *RefCount) *Gate {
+	return &r.gate
+}
+
+func SetLeakGracePeriod(d time.Duration) (restore func()) {
+	old := leakGracePeriod
+	leakGracePeriod = d
+	return func() { leakGracePeriod = old }
+}
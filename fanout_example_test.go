@@ -0,0 +1,102 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"time"
+
+	"github.com/neild/gate"
+)
+
+// FanOut moves items from src to dst using several worker goroutines,
+// until ctx is done or one of the queues is closed.
+// Each item is passed through fn by a worker.
+// If ordered is true, results are put in dst in the order their items were taken from src;
+// otherwise, they are put in dst as soon as they are ready.
+//
+// FanOut closes queues as Pipe does.
+// When src is closed, FanOut closes dst with the same error once every worker has finished.
+// It returns the error which ended it.
+func FanOut[T, U any](ctx context.Context, src *Queue[T], dst *Queue[U], workers int, ordered bool, fn func(T) (U, error)) error {
+	var (
+		mu   = gate.NewMutex() // guards taking an item and assigning it a sequence number
+		next uint64
+		seq  = gate.NewSequencer(0)
+	)
+	s := gate.NewScope()
+	for range workers {
+		s.Go(func() error {
+			for {
+				if err := mu.Lock(ctx); err != nil {
+					return err
+				}
+				v, err := src.Get(ctx)
+				if err != nil {
+					mu.Unlock()
+					return err
+				}
+				n := next
+				next++
+				mu.Unlock()
+				u, err := fn(v)
+				if err != nil {
+					src.Close(err)
+					dst.Close(err)
+					seq.Release(n)
+					return err
+				}
+				if ordered {
+					// Wait for every earlier item to be put in dst.
+					if err := seq.WaitForTurn(ctx, n); err != nil {
+						return err
+					}
+				}
+				ok := dst.Put(u)
+				seq.Release(n)
+				if !ok {
+					err := dst.Err()
+					src.Close(err)
+					return err
+				}
+			}
+		})
+	}
+	err := s.Wait(context.Background())
+	if ctx.Err() == nil {
+		dst.Close(err)
+	}
+	return err
+}
+
+func Example_fanOut() {
+	src := NewQueue[int]()
+	dst := NewQueue[int]()
+	go FanOut(context.Background(), src, dst, 4, true, func(v int) (int, error) {
+		// Items take varying amounts of time to process.
+		time.Sleep(time.Duration(rand.IntN(1000)) * time.Microsecond)
+		return v * v, nil
+	})
+
+	for i := range 5 {
+		src.Put(i)
+	}
+	for range 5 {
+		fmt.Println(dst.Get(context.Background()))
+	}
+	// Closing the source closes the destination.
+	src.Close(io.EOF)
+	fmt.Println(dst.Get(context.Background()))
+	// Output:
+	// 0 <nil>
+	// 1 <nil>
+	// 4 <nil>
+	// 9 <nil>
+	// 16 <nil>
+	// 0 EOF
+}
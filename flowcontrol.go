@@ -0,0 +1,158 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+var (
+	// ErrFlowWindowOverflow is returned by FlowControl.Grant when a grant
+	// would increase the window beyond its maximum size.
+	ErrFlowWindowOverflow = errors.New("gate: flow control window overflow")
+
+	// ErrInsufficientCredit is returned by FlowControl.Consume
+	// when fewer credits are available than requested.
+	ErrInsufficientCredit = errors.New("gate: insufficient flow control credit")
+)
+
+// A FlowControl is a credit-based flow control window,
+// such as an HTTP/2 or QUIC stream or connection window.
+//
+// The receiver grants credits, and the sender consumes them.
+type FlowControl struct {
+	gate    Gate // set if credits are available or the window is closed
+	avail   int64
+	max     int64
+	err     error
+	changed chan struct{} // closed when credits are granted or the window is closed
+}
+
+// NewFlowControl returns a new window with the given initial credit.
+// Grants may not increase the available credit above max.
+func NewFlowControl(initial, max int64) *FlowControl {
+	return &FlowControl{
+		gate:  New(initial > 0),
+		avail: initial,
+		max:   max,
+	}
+}
+
+// Grant adds n credits to the window.
+// It returns ErrFlowWindowOverflow and adds no credits if
+// the window would exceed its maximum size.
+// It panics if n is negative.
+func (f *FlowControl) Grant(n int64) error {
+	if n < 0 {
+		panic("gate: FlowControl granted negative credit")
+	}
+	f.gate.Lock()
+	defer f.unlock()
+	if f.avail+n > f.max || f.avail+n < f.avail {
+		return ErrFlowWindowOverflow
+	}
+	f.avail += n
+	f.wake()
+	return nil
+}
+
+// Consume consumes n credits without blocking.
+// It returns ErrInsufficientCredit if fewer than n credits are available,
+// or the close error if the window is closed.
+// It panics if n is negative.
+func (f *FlowControl) Consume(n int64) error {
+	if n < 0 {
+		panic("gate: FlowControl consumed negative credit")
+	}
+	f.gate.Lock()
+	defer f.unlock()
+	if f.err != nil {
+		return f.err
+	}
+	if f.avail < n {
+		return ErrInsufficientCredit
+	}
+	f.avail -= n
+	return nil
+}
+
+// Acquire consumes up to n credits, blocking until at least one is available,
+// the window is closed, or the context expires.
+// It returns the number of credits consumed.
+// It panics if n is negative.
+func (f *FlowControl) Acquire(ctx context.Context, n int64) (int64, error) {
+	if n < 0 {
+		panic("gate: FlowControl consumed negative credit")
+	}
+	if err := f.gate.WaitAndLock(ctx); err != nil {
+		return 0, err
+	}
+	defer f.unlock()
+	if f.err != nil {
+		return 0, f.err
+	}
+	n = min(n, f.avail)
+	f.avail -= n
+	return n, nil
+}
+
+// Wait blocks until at least n credits are available, the window is closed,
+// or the context expires. It does not consume any credits.
+func (f *FlowControl) Wait(ctx context.Context, n int64) error {
+	for {
+		f.gate.Lock()
+		if err := f.err; err != nil {
+			f.unlock()
+			return err
+		}
+		if f.avail >= n {
+			f.unlock()
+			return nil
+		}
+		if f.changed == nil {
+			f.changed = make(chan struct{})
+		}
+		changed := f.changed
+		f.unlock()
+		if err := f.gate.WaitFor(ctx, changed); err != nil {
+			return err
+		}
+	}
+}
+
+// Available returns the number of available credits.
+func (f *FlowControl) Available() int64 {
+	f.gate.Lock()
+	defer f.unlock()
+	return f.avail
+}
+
+// Close closes the window, causing pending and future operations to return err,
+// or io.EOF if err is nil.
+func (f *FlowControl) Close(err error) {
+	f.gate.Lock()
+	defer f.unlock()
+	if err == nil {
+		err = io.EOF
+	}
+	if f.err == nil {
+		f.err = err
+	}
+	f.gate.recordClose()
+	f.wake()
+}
+
+func (f *FlowControl) wake() {
+	if f.changed != nil {
+		close(f.changed)
+		f.changed = nil
+	}
+}
+
+func (f *FlowControl) unlock() {
+	f.gate.Unlock(f.err != nil || f.avail > 0)
+}
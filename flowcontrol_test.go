@@ -0,0 +1,111 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate_test
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/neild/gate"
+)
+
+func TestFlowControlConsumeAndGrant(t *testing.T) {
+	f := gate.NewFlowControl(10, 100)
+	if err := f.Consume(11); err != gate.ErrInsufficientCredit {
+		t.Fatalf("f.Consume(11) with 10 credits = %v, want ErrInsufficientCredit", err)
+	}
+	if err := f.Consume(10); err != nil {
+		t.Fatalf("f.Consume(10) with 10 credits = %v, want nil", err)
+	}
+	if err := f.Grant(101); err != gate.ErrFlowWindowOverflow {
+		t.Fatalf("f.Grant(101) with max 100 = %v, want ErrFlowWindowOverflow", err)
+	}
+	if err := f.Grant(5); err != nil {
+		t.Fatalf("f.Grant(5) = %v, want nil", err)
+	}
+	if got, want := f.Available(), int64(5); got != want {
+		t.Fatalf("f.Available() = %v, want %v", got, want)
+	}
+}
+
+func TestFlowControlAcquirePartial(t *testing.T) {
+	f := gate.NewFlowControl(0, 100)
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Millisecond)
+	defer cancel()
+	if _, err := f.Acquire(ctx, 10); err != context.DeadlineExceeded {
+		t.Fatalf("f.Acquire with no credit = %v, want context.DeadlineExceeded", err)
+	}
+	go func() {
+		time.Sleep(1 * time.Millisecond)
+		f.Grant(4)
+	}()
+	if n, err := f.Acquire(context.Background(), 10); n != 4 || err != nil {
+		t.Fatalf("f.Acquire(10) after partial grant = %v, %v; want 4, nil", n, err)
+	}
+}
+
+func TestFlowControlWait(t *testing.T) {
+	f := gate.NewFlowControl(0, 100)
+	go func() {
+		for i := 0; i < 3; i++ {
+			time.Sleep(1 * time.Millisecond)
+			f.Grant(2)
+		}
+	}()
+	if err := f.Wait(context.Background(), 5); err != nil {
+		t.Fatalf("f.Wait(5) = %v, want nil", err)
+	}
+	if got := f.Available(); got < 5 {
+		t.Fatalf("f.Available() after Wait(5) = %v, want at least 5", got)
+	}
+}
+
+func TestFlowControlClose(t *testing.T) {
+	f := gate.NewFlowControl(0, 100)
+	go func() {
+		time.Sleep(1 * time.Millisecond)
+		f.Close(io.EOF)
+	}()
+	if _, err := f.Acquire(context.Background(), 1); err != io.EOF {
+		t.Fatalf("f.Acquire on closed window = %v, want io.EOF", err)
+	}
+	if err := f.Wait(context.Background(), 1); err != io.EOF {
+		t.Fatalf("f.Wait on closed window = %v, want io.EOF", err)
+	}
+}
+
+func TestFlowControlCloseNil(t *testing.T) {
+	f := gate.NewFlowControl(0, 100)
+	f.Close(nil)
+	if _, err := f.Acquire(context.Background(), 1); err != io.EOF {
+		t.Fatalf("f.Acquire after Close(nil) = %v, want io.EOF", err)
+	}
+}
+
+func TestFlowControlNegativeCredit(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		f    func(f *gate.FlowControl)
+	}{
+		{"Grant", func(f *gate.FlowControl) { f.Grant(-1) }},
+		{"Consume", func(f *gate.FlowControl) { f.Consume(-1) }},
+		{"Acquire", func(f *gate.FlowControl) { f.Acquire(context.Background(), -1) }},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			f := gate.NewFlowControl(10, 100)
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%v(-1) did not panic", test.name)
+				}
+				if got := f.Available(); got != 10 {
+					t.Errorf("f.Available() after %v(-1) = %v, want 10", test.name, got)
+				}
+			}()
+			test.f(f)
+		})
+	}
+}
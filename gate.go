@@ -20,6 +20,13 @@
 //     For example, if a gate's condition is set when a queue is non-empty,
 //     then a successful return from Wait guarantees that an item is in the queue.
 //   - No need to call Signal/Broadcast to notify waiters of a change in the condition.
+//
+// The package also provides synchronization primitives built on gates,
+// such as Semaphore and WeightedSemaphore.
+//
+// Gates and the primitives built on them may be used within a testing/synctest bubble.
+// Blocked waiters are durably blocked, and internal timers use the bubble's fake clock.
+// A value created inside a bubble must not be used outside it, or in another bubble.
 package gate
 
 import "context"
@@ -30,23 +37,62 @@ type Gate struct {
 	// When locked, neither chan contains a value.
 	set   chan struct{}
 	unset chan struct{}
+
+	h *gateHooks // per-gate diagnostics and name, or nil
 }
 
 // New returns a new, unlocked gate with the given condition state.
-func New(set bool) Gate {
+// The options WithName, WithObserver, and WithClock apply to gates;
+// a gate's clock times the waits it reports to an observer.
+// New(set, WithName(name)) is equivalent to NewNamed(set, name).
+func New(set bool, opts ...Option) Gate {
+	o := newOptions(opts)
+	return newGate(set, o.name, o.observer, o.clock)
+}
+
+// NewNamed returns a new, unlocked gate with the given condition state and name.
+// The name identifies the gate in diagnostics: dumps, deadlock, lock order, and stall reports,
+// invariant violations, event logs, traces, and observer measurements.
+func NewNamed(set bool, name string) Gate {
+	return newGate(set, name, nil, systemClock{})
+}
+
+// newGate returns a new, unlocked gate with the given condition state and name,
+// which reports its measurements to obs if it is not nil, timed by clock.
+func newGate(set bool, name string, obs Observer, clock Clock) Gate {
 	g := Gate{
 		set:   make(chan struct{}, 1),
 		unset: make(chan struct{}, 1),
+		h:     newGateHooks(name, obs, clock),
 	}
 	g.Unlock(set)
 	return g
 }
 
+// Clock returns the clock the gate was created with by WithClock, or the system clock.
+func (g *Gate) Clock() Clock {
+	if g.h == nil || g.h.clock == nil {
+		return systemClock{}
+	}
+	return g.h.clock
+}
+
+// Name returns the gate's name, or "" if it has none.
+func (g *Gate) Name() string {
+	if g.h == nil {
+		return ""
+	}
+	return g.h.name
+}
+
 // Lock acquires the gate unconditionally.
 // It reports whether the condition was set.
 func (g *Gate) Lock() (set bool) {
 	// This doesn't take a Context parameter because
 	// we don't expect unconditional lock operations to be time-bounded.
+	if g.hooked() {
+		return g.lockHooked()
+	}
 	select {
 	case <-g.set:
 		return true
@@ -58,6 +104,9 @@ func (g *Gate) Lock() (set bool) {
 // WaitAndLock waits until the condition is set before acquiring the gate.
 // If the context expires, WaitAndLock returns an error and does not acquire the gate.
 func (g *Gate) WaitAndLock(ctx context.Context) error {
+	if g.hooked() {
+		return g.waitAndLockHooked(ctx)
+	}
 	// If the gate is available and the context is expired,
 	// prefer locking the gate.
 	select {
@@ -75,6 +124,9 @@ func (g *Gate) WaitAndLock(ctx context.Context) error {
 
 // LockIfSet acquires the gate if and only if the condition is set.
 func (g *Gate) LockIfSet() (acquired bool) {
+	if g.hooked() {
+		return g.lockIfSetHooked()
+	}
 	select {
 	case <-g.set:
 		return true
@@ -85,9 +137,40 @@ func (g *Gate) LockIfSet() (acquired bool) {
 
 // Unlock sets the condition and releases the gate.
 func (g *Gate) Unlock(set bool) {
+	if g.hooked() {
+		g.unlockHooked(set)
+		return
+	}
 	if set {
 		g.set <- struct{}{}
 	} else {
 		g.unset <- struct{}{}
 	}
 }
+
+// WaitFor blocks until ch receives a value or is closed, or until ctx expires,
+// in which case it returns ctx.Err().
+// If ch is ready and ctx has expired, WaitFor returns nil.
+// The caller must not hold the gate.
+//
+// WaitFor is for primitives built on gates which release the gate
+// and wait for a notification channel, rather than waiting for the gate's condition.
+// Diagnostics such as debug tracking, CheckLeaks, WriteWaitGraph, and the blocked profile
+// record the wait as a wait on g. Since such a wait ends when some goroutine closes ch,
+// rather than when one releases the gate, it never forms part of a reported deadlock.
+func (g *Gate) WaitFor(ctx context.Context, ch <-chan struct{}) error {
+	if g.hooked() {
+		return g.waitForHooked(ctx, ch)
+	}
+	select {
+	case <-ch:
+		return nil
+	default:
+	}
+	select {
+	case <-ch:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
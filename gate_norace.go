@@ -0,0 +1,17 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !race
+
+package gate
+
+type raceToken struct{}
+
+const raceEnabled = false
+
+func newRaceToken() raceToken {
+	return raceToken{}
+}
+
+func raceHold(raceToken) {}
@@ -0,0 +1,34 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build race
+
+package gate
+
+import (
+	"runtime"
+	"unsafe"
+)
+
+// A raceToken is a memory location which stands in for the state guarded by a gate.
+//
+// When a gate is acquired or released, the race detector is told that the token is written.
+// Channel operations order each holder's writes after the previous holder's,
+// so the race detector reports a race on the token only if two goroutines hold the gate at once,
+// for example after an extra Unlock. The report then points at the gate operations
+// rather than at whichever guarded data was accessed.
+type raceToken *uint64
+
+const raceEnabled = true
+
+func newRaceToken() raceToken {
+	return new(uint64)
+}
+
+// raceHold records an acquisition or release of a gate.
+func raceHold(t raceToken) {
+	if t != nil {
+		runtime.RaceWrite(unsafe.Pointer(t))
+	}
+}
@@ -0,0 +1,179 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate
+
+import (
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultHistogramBuckets are the bucket bounds used by histograms created without any:
+// powers of 4 from 1µs to about 17s.
+var DefaultHistogramBuckets = []time.Duration{
+	1 * time.Microsecond,
+	4 * time.Microsecond,
+	16 * time.Microsecond,
+	64 * time.Microsecond,
+	256 * time.Microsecond,
+	1024 * time.Microsecond,
+	4096 * time.Microsecond,
+	16384 * time.Microsecond,
+	65536 * time.Microsecond,
+	262144 * time.Microsecond,
+	1048576 * time.Microsecond,
+	4194304 * time.Microsecond,
+	16777216 * time.Microsecond,
+}
+
+// A Histogram counts durations in buckets.
+// It is safe for concurrent use.
+type Histogram struct {
+	bounds []time.Duration
+	counts []atomic.Int64 // counts[i] is durations in (bounds[i-1], bounds[i]]; the last is above every bound
+	sum    atomic.Int64
+}
+
+// NewHistogram returns a histogram with buckets bounded above by the given durations,
+// plus a final bucket for durations greater than every bound.
+// If bounds is empty, NewHistogram uses DefaultHistogramBuckets.
+// It panics if the bounds are not in increasing order.
+func NewHistogram(bounds []time.Duration) *Histogram {
+	if len(bounds) == 0 {
+		bounds = DefaultHistogramBuckets
+	}
+	for i := 1; i < len(bounds); i++ {
+		if bounds[i] <= bounds[i-1] {
+			panic("gate: histogram bounds must be increasing")
+		}
+	}
+	return &Histogram{
+		bounds: slices.Clone(bounds),
+		counts: make([]atomic.Int64, len(bounds)+1),
+	}
+}
+
+// Observe adds a duration to the histogram.
+func (h *Histogram) Observe(d time.Duration) {
+	i, _ := slices.BinarySearch(h.bounds, d)
+	h.counts[i].Add(1)
+	h.sum.Add(int64(d))
+}
+
+// Bounds returns the upper bounds of the histogram's buckets, excluding the final unbounded bucket.
+func (h *Histogram) Bounds() []time.Duration {
+	return slices.Clone(h.bounds)
+}
+
+// Counts returns the number of durations in each bucket.
+// Counts()[i] is the number of durations at most Bounds()[i] and greater than the previous bound,
+// and the final count is the number of durations greater than every bound.
+func (h *Histogram) Counts() []int64 {
+	counts := make([]int64, len(h.counts))
+	for i := range h.counts {
+		counts[i] = h.counts[i].Load()
+	}
+	return counts
+}
+
+// Count returns the number of durations observed.
+func (h *Histogram) Count() int64 {
+	var n int64
+	for i := range h.counts {
+		n += h.counts[i].Load()
+	}
+	return n
+}
+
+// Sum returns the total of the durations observed.
+func (h *Histogram) Sum() time.Duration {
+	return time.Duration(h.sum.Load())
+}
+
+// Quantile returns an upper bound on the q-quantile of the durations observed,
+// for q between 0 and 1: the bound of the first bucket at which
+// the cumulative count reaches the fraction q of all durations.
+// If that is the final unbounded bucket, Quantile returns the largest bound.
+// It returns 0 if no durations have been observed.
+func (h *Histogram) Quantile(q float64) time.Duration {
+	counts := h.Counts()
+	var total int64
+	for _, n := range counts {
+		total += n
+	}
+	if total == 0 {
+		return 0
+	}
+	var cum int64
+	for i, n := range counts[:len(h.bounds)] {
+		cum += n
+		if float64(cum) >= q*float64(total) {
+			return h.bounds[i]
+		}
+	}
+	return h.bounds[len(h.bounds)-1]
+}
+
+// A HistogramObserver is an Observer which records each named timing in a histogram.
+// It passes every measurement on to another observer, if one is provided.
+//
+// Set it as the package-wide observer to record the "gate.wait" times of contended gates,
+// or as the observer of a primitive to record that primitive's wait times.
+type HistogramObserver struct {
+	bounds []time.Duration
+	next   Observer
+	hists  sync.Map // string -> *Histogram
+}
+
+// NewHistogramObserver returns an observer which records timings in histograms
+// with the given bucket bounds, as for NewHistogram,
+// and passes every measurement on to next, if it is not nil.
+func NewHistogramObserver(bounds []time.Duration, next Observer) *HistogramObserver {
+	return &HistogramObserver{
+		bounds: NewHistogram(bounds).bounds,
+		next:   next,
+	}
+}
+
+// Histogram returns the histogram of the named timing,
+// or nil if no timing with that name has been recorded.
+func (o *HistogramObserver) Histogram(name string) *Histogram {
+	h, ok := o.hists.Load(name)
+	if !ok {
+		return nil
+	}
+	return h.(*Histogram)
+}
+
+// Count passes the counter on to the next observer.
+func (o *HistogramObserver) Count(name string, delta int64) {
+	if o.next != nil {
+		o.next.Count(name, delta)
+	}
+}
+
+// Gauge passes the gauge on to the next observer.
+func (o *HistogramObserver) Gauge(name string, value int64) {
+	if o.next != nil {
+		o.next.Gauge(name, value)
+	}
+}
+
+// Timing records d in the histogram of the named timing,
+// and passes it on to the next observer.
+func (o *HistogramObserver) Timing(name string, d time.Duration) {
+	h, ok := o.hists.Load(name)
+	if !ok {
+		h, _ = o.hists.LoadOrStore(name, &Histogram{
+			bounds: o.bounds,
+			counts: make([]atomic.Int64, len(o.bounds)+1),
+		})
+	}
+	h.(*Histogram).Observe(d)
+	if o.next != nil {
+		o.next.Timing(name, d)
+	}
+}
@@ -0,0 +1,128 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate_test
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/neild/gate"
+)
+
+func TestHistogram(t *testing.T) {
+	h := gate.NewHistogram([]time.Duration{1 * time.Millisecond, 10 * time.Millisecond})
+	for _, d := range []time.Duration{
+		0,
+		1 * time.Millisecond,
+		2 * time.Millisecond,
+		10 * time.Millisecond,
+		11 * time.Millisecond,
+	} {
+		h.Observe(d)
+	}
+	if got, want := h.Counts(), []int64{2, 2, 1}; !slices.Equal(got, want) {
+		t.Errorf("h.Counts() = %v, want %v", got, want)
+	}
+	if got, want := h.Count(), int64(5); got != want {
+		t.Errorf("h.Count() = %v, want %v", got, want)
+	}
+	if got, want := h.Sum(), 24*time.Millisecond; got != want {
+		t.Errorf("h.Sum() = %v, want %v", got, want)
+	}
+	for _, test := range []struct {
+		q    float64
+		want time.Duration
+	}{
+		{0.4, 1 * time.Millisecond},
+		{0.5, 10 * time.Millisecond},
+		{0.99, 10 * time.Millisecond},
+	} {
+		if got := h.Quantile(test.q); got != test.want {
+			t.Errorf("h.Quantile(%v) = %v, want %v", test.q, got, test.want)
+		}
+	}
+}
+
+func TestHistogramInvalidBounds(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("NewHistogram with decreasing bounds did not panic")
+		}
+	}()
+	gate.NewHistogram([]time.Duration{2 * time.Second, 1 * time.Second})
+}
+
+func TestHistogramObserverGate(t *testing.T) {
+	next := newTestObserver()
+	o := gate.NewHistogramObserver(nil, next)
+	gate.SetObserver(o)
+	defer gate.SetObserver(nil)
+
+	g := gate.New(false)
+	g.Lock()
+	go func() {
+		time.Sleep(1 * time.Millisecond)
+		g.Unlock(false)
+	}()
+	g.Lock()
+	g.Unlock(false)
+
+	h := o.Histogram("gate.wait")
+	if h == nil {
+		t.Fatalf("no gate.wait histogram recorded")
+	}
+	if got := h.Count(); got != 1 {
+		t.Errorf("gate.wait histogram has %v durations, want 1", got)
+	}
+	if got := next.counts["gate.contended"]; got != 1 {
+		t.Errorf("next observer gate.contended = %v, want 1", got)
+	}
+	if got := next.timings["gate.wait"]; len(got) != 1 {
+		t.Errorf("next observer gate.wait timings = %v, want one", got)
+	}
+}
+
+func TestHistogramObserverSemaphore(t *testing.T) {
+	s := gate.NewSemaphore(1)
+	o := gate.NewHistogramObserver([]time.Duration{1 * time.Millisecond}, nil)
+	s.SetObserver(o)
+	s.Acquire(context.Background())
+	if h := o.Histogram("semaphore.wait"); h != nil {
+		t.Errorf("semaphore.wait recorded for Acquire which did not wait")
+	}
+	go func() {
+		time.Sleep(2 * time.Millisecond)
+		s.Release()
+	}()
+	s.Acquire(context.Background())
+	h := o.Histogram("semaphore.wait")
+	if h == nil {
+		t.Fatalf("no semaphore.wait histogram recorded")
+	}
+	if got, want := h.Counts(), []int64{0, 1}; !slices.Equal(got, want) {
+		t.Errorf("semaphore.wait counts = %v, want %v", got, want)
+	}
+}
+
+func TestHistogramObserverWeightedSemaphore(t *testing.T) {
+	s := gate.NewWeightedSemaphore(2)
+	o := gate.NewHistogramObserver(nil, nil)
+	s.SetObserver(o)
+	s.Acquire(context.Background(), 2)
+	go func() {
+		time.Sleep(1 * time.Millisecond)
+		s.Release(2)
+	}()
+	s.Acquire(context.Background(), 1)
+	h := o.Histogram("weightedsemaphore.wait")
+	if h == nil {
+		t.Fatalf("no weightedsemaphore.wait histogram recorded")
+	}
+	if got := h.Count(); got != 1 {
+		t.Errorf("weightedsemaphore.wait has %v durations, want 1", got)
+	}
+}
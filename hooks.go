@@ -0,0 +1,299 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// Per-gate diagnostics, such as debug tracking and scenario exploration,
+// are attached to a gate when it is created, along with its name, observer, and clock.
+// A gate with none of these has no hooks.
+// In race-enabled builds, every gate has hooks.
+//
+// Package-wide diagnostics, such as tracing, chaos mode, observation, coverage,
+// and the blocked profile, apply to every gate while they are enabled.
+// When none is enabled, the operations of a gate without hooks are plain channel operations.
+
+// gateHooks holds the diagnostics attached to a gate.
+type gateHooks struct {
+	race  raceToken    // stands in for the guarded state, in race-enabled builds
+	d     *gateDebug   // nil unless debug tracking was enabled when the gate was created
+	x     *exploration // the scenario run which created the gate, or nil
+	name  string
+	obs   Observer // set by WithObserver, or nil to use the package-wide observer
+	clock Clock    // set by WithClock, or nil for the system clock
+}
+
+// newGateHooks returns the hooks for a new gate with the given name, observer, and clock,
+// or nil if it needs none.
+func newGateHooks(name string, obs Observer, clock Clock) *gateHooks {
+	d := newGateDebug(name)
+	x := creatingExploration()
+	if clock == (systemClock{}) {
+		clock = nil
+	}
+	if !raceEnabled && d == nil && x == nil && name == "" && obs == nil && clock == nil {
+		return nil
+	}
+	return &gateHooks{
+		race:  newRaceToken(),
+		d:     d,
+		x:     x,
+		name:  name,
+		obs:   obs,
+		clock: clock,
+	}
+}
+
+var (
+	diagnosticsMu sync.Mutex  // serializes changes to package-wide diagnostics
+	instrumented  atomic.Bool // a package-wide diagnostic is enabled
+)
+
+// setDiagnostic calls f, which enables or disables a package-wide diagnostic,
+// and records whether any is now enabled.
+func setDiagnostic(f func()) {
+	diagnosticsMu.Lock()
+	defer diagnosticsMu.Unlock()
+	f()
+	instrumented.Store(instrumenting())
+}
+
+// instrumenting reports whether any package-wide diagnostic which instruments gates is enabled.
+func instrumenting() bool {
+	return chaos.Load() != nil ||
+		tracing.Load() ||
+		blockedEnabled.Load() ||
+		defaultObserver.Load() != nil ||
+		coverage.Load() != nil
+}
+
+// hooked reports whether the gate's operations must take the instrumented paths.
+func (g *Gate) hooked() bool {
+	return g.h != nil || instrumented.Load()
+}
+
+// debug returns the gate's debug tracking state, or nil if it is not tracked.
+func (g *Gate) debug() *gateDebug {
+	if g.h == nil {
+		return nil
+	}
+	return g.h.d
+}
+
+// thread returns the calling goroutine, if it is a goroutine of the scenario which created the gate.
+// The hooks may be nil.
+func (h *gateHooks) thread() *xthread {
+	if h == nil || h.x == nil {
+		return nil
+	}
+	return h.x.thread()
+}
+
+// hold records the gate's acquisition for the race detector.
+// The hooks may be nil, since every gate has hooks in race-enabled builds.
+func (h *gateHooks) hold() {
+	if h != nil {
+		raceHold(h.race)
+	}
+}
+
+func (g *Gate) lockHooked() (set bool) {
+	h := g.h
+	if t := h.thread(); t != nil {
+		set, _ := t.lock(g, context.Background(), false)
+		h.hold()
+		return set
+	}
+	chaosDelay()
+	select {
+	case <-g.set:
+		set = true
+	case <-g.unset:
+	default:
+		return g.lockSlow()
+	}
+	cover(CoverLockFast)
+	g.acquired(context.Background())
+	return set
+}
+
+// lockSlow is Lock when the gate is not immediately available.
+func (g *Gate) lockSlow() (set bool) {
+	var w *debugWait
+	if d := g.debug(); d != nil {
+		w = d.startWait("Lock", nil)
+	}
+	b := startBlocked()
+	r := traceWait(context.Background(), "gate.Lock")
+	o := g.startObserve()
+	select {
+	case <-g.set:
+		set = true
+	case <-g.unset:
+	}
+	cover(CoverLockContended)
+	o.end(nil)
+	if r != nil {
+		r.End()
+	}
+	b.end()
+	if w != nil {
+		w.end(true)
+	}
+	g.h.hold()
+	traceEvent(context.Background(), g.Name(), "acquired")
+	return set
+}
+
+func (g *Gate) waitAndLockHooked(ctx context.Context) error {
+	h := g.h
+	if t := h.thread(); t != nil {
+		_, err := t.lock(g, ctx, true)
+		if err == nil {
+			h.hold()
+		}
+		return err
+	}
+	if err := chaosCancel(ctx); err != nil {
+		cover(CoverWaitAndLockCanceled)
+		return err
+	}
+	chaosDelay()
+	// If the gate is available and the context is expired,
+	// prefer locking the gate.
+	select {
+	case <-g.set:
+		if coverage.Load() != nil && ctx.Err() != nil {
+			cover(CoverWaitAndLockExpiredAvailable)
+		} else {
+			cover(CoverWaitAndLockFast)
+		}
+		g.acquired(ctx)
+		return nil
+	default:
+	}
+	return g.waitAndLockSlow(ctx)
+}
+
+// waitAndLockSlow is WaitAndLock when the gate is not immediately available.
+func (g *Gate) waitAndLockSlow(ctx context.Context) (err error) {
+	var w *debugWait
+	if d := g.debug(); d != nil {
+		w = d.startWait("WaitAndLock", ctx)
+	}
+	b := startBlocked()
+	r := traceWait(ctx, "gate.WaitAndLock")
+	o := g.startObserve()
+	select {
+	case <-g.set:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	if err == nil {
+		cover(CoverWaitAndLockContended)
+	} else {
+		cover(CoverWaitAndLockCanceled)
+	}
+	o.end(err)
+	if r != nil {
+		r.End()
+	}
+	b.end()
+	if w != nil {
+		w.end(err == nil)
+	}
+	if err == nil {
+		g.h.hold()
+		traceEvent(ctx, g.Name(), "acquired")
+	}
+	return err
+}
+
+func (g *Gate) lockIfSetHooked() (acquired bool) {
+	h := g.h
+	if t := h.thread(); t != nil {
+		_, err := t.lock(g, nil, true)
+		if err == nil {
+			h.hold()
+		}
+		return err == nil
+	}
+	chaosDelay()
+	select {
+	case <-g.set:
+		cover(CoverLockIfSetAcquired)
+		g.acquired(context.Background())
+		return true
+	default:
+		cover(CoverLockIfSetFailed)
+		return false
+	}
+}
+
+// acquired records that the gate has been locked without blocking.
+func (g *Gate) acquired(ctx context.Context) {
+	g.h.hold()
+	if d := g.debug(); d != nil {
+		d.acquired()
+	}
+	traceEvent(ctx, g.Name(), "acquired")
+}
+
+func (g *Gate) unlockHooked(set bool) {
+	h := g.h
+	d := g.debug()
+	if d != nil {
+		d.checkInvariant(set)
+	}
+	h.hold()
+	if t := h.thread(); t != nil {
+		t.unlock(g, set)
+		return
+	}
+	if d != nil {
+		d.released(set)
+	}
+	if set {
+		cover(CoverUnlockSet)
+		traceEvent(context.Background(), g.Name(), "released set")
+		g.set <- struct{}{}
+	} else {
+		cover(CoverUnlockUnset)
+		traceEvent(context.Background(), g.Name(), "released unset")
+		g.unset <- struct{}{}
+	}
+	chaosDelay()
+}
+
+func (g *Gate) waitForHooked(ctx context.Context, ch <-chan struct{}) (err error) {
+	select {
+	case <-ch:
+		return nil
+	default:
+	}
+	var w *debugWait
+	if d := g.debug(); d != nil {
+		w = d.startNotifyWait()
+	}
+	b := startBlocked()
+	r := traceWait(ctx, "gate.WaitFor")
+	select {
+	case <-ch:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	if r != nil {
+		r.End()
+	}
+	b.end()
+	if w != nil {
+		w.endNotify(err != nil)
+	}
+	return err
+}
@@ -0,0 +1,99 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate
+
+import (
+	"context"
+	"time"
+)
+
+// An IdleTracker reports when there has been no activity for a period of time.
+type IdleTracker struct {
+	gate      Gate // set if idle
+	clock     Clock
+	timeout   time.Duration
+	last      time.Time // time of last activity
+	idle      bool
+	scheduled bool // timer is running
+	stopped   bool
+	timer     Timer
+}
+
+// NewIdleTracker returns a new tracker which becomes idle
+// after timeout passes with no activity.
+// The tracker starts out active, as if Activity had just been called.
+func NewIdleTracker(timeout time.Duration, opts ...Option) *IdleTracker {
+	o := newOptions(opts)
+	clock := o.clock
+	t := &IdleTracker{
+		gate:      o.newGate(false, ""),
+		clock:     clock,
+		timeout:   timeout,
+		last:      clock.Now(),
+		scheduled: true,
+	}
+	t.timer = clock.AfterFunc(timeout, t.check)
+	return t
+}
+
+// Activity records activity, resetting the idle timeout.
+func (t *IdleTracker) Activity() {
+	t.gate.Lock()
+	defer t.unlock()
+	t.last = t.clock.Now()
+	t.idle = false
+	if !t.scheduled {
+		// When the timer is already running, it reschedules itself
+		// if it finds activity when it fires.
+		// This avoids resetting the timer on every call.
+		t.scheduled = true
+		t.timer.Reset(t.timeout)
+	}
+}
+
+// Idle reports whether the tracker is idle.
+func (t *IdleTracker) Idle() bool {
+	t.gate.Lock()
+	defer t.unlock()
+	return t.idle
+}
+
+// Wait blocks until the tracker is idle or the context expires.
+func (t *IdleTracker) Wait(ctx context.Context) error {
+	if err := t.gate.WaitAndLock(ctx); err != nil {
+		return err
+	}
+	t.unlock()
+	return nil
+}
+
+// Stop stops the tracker's timer.
+// The tracker does not become idle after Stop is called.
+func (t *IdleTracker) Stop() {
+	t.gate.Lock()
+	defer t.unlock()
+	t.timer.Stop()
+	t.stopped = true
+	t.scheduled = true // prevent Activity from restarting the timer
+}
+
+func (t *IdleTracker) check() {
+	t.gate.Lock()
+	defer t.unlock()
+	if t.stopped {
+		// Stop was called after the timer fired.
+		return
+	}
+	if d := t.last.Add(t.timeout).Sub(t.clock.Now()); d > 0 {
+		t.timer.Reset(d)
+		return
+	}
+	t.scheduled = false
+	t.idle = true
+}
+
+func (t *IdleTracker) unlock() {
+	t.gate.Unlock(t.idle)
+}
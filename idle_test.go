@@ -0,0 +1,55 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/neild/gate"
+)
+
+func TestIdleTracker(t *testing.T) {
+	it := gate.NewIdleTracker(5 * time.Millisecond)
+	defer it.Stop()
+	if idle := it.Idle(); idle {
+		t.Fatalf("it.Idle of new tracker = %v, want false", idle)
+	}
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		time.Sleep(1 * time.Millisecond)
+		it.Activity()
+	}
+	if err := it.Wait(context.Background()); err != nil {
+		t.Fatalf("it.Wait = %v, want nil", err)
+	}
+	if elapsed := time.Since(start); elapsed < 10*time.Millisecond {
+		t.Fatalf("tracker became idle after %v, want at least 10ms", elapsed)
+	}
+	if idle := it.Idle(); !idle {
+		t.Fatalf("it.Idle after Wait = %v, want true", idle)
+	}
+
+	// Activity resets the tracker.
+	it.Activity()
+	if idle := it.Idle(); idle {
+		t.Fatalf("it.Idle after Activity = %v, want false", idle)
+	}
+	if err := it.Wait(context.Background()); err != nil {
+		t.Fatalf("it.Wait = %v, want nil", err)
+	}
+}
+
+func TestIdleTrackerStop(t *testing.T) {
+	clock := gate.NewFakeClock(time.Now())
+	it := gate.NewIdleTracker(1*time.Minute, gate.WithClock(clock))
+	it.Stop()
+	it.Activity()
+	clock.Advance(2 * time.Minute)
+	if it.Idle() {
+		t.Fatalf("tracker idle after Stop")
+	}
+}
@@ -0,0 +1,50 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate
+
+import "fmt"
+
+// SetInvariant registers a function which checks the state guarded by the gate.
+// The function is called by every Unlock of the gate, while the gate is still held,
+// with the condition the gate is being unlocked with.
+// If it returns an error, Unlock panics.
+//
+// For example, the invariant of a queue's gate might be
+// that the condition is set if and only if the queue is non-empty or closed:
+//
+//	q.gate.SetInvariant(func(set bool) error {
+//		if want := len(q.items) > 0 || q.closed; set != want {
+//			return fmt.Errorf("condition %v, want %v", set, want)
+//		}
+//		return nil
+//	})
+//
+// A nil f removes the invariant.
+// SetInvariant has no effect if the gate is not tracked; see SetDebug.
+func (g *Gate) SetInvariant(f func(set bool) error) {
+	d := g.debug()
+	if d == nil {
+		return
+	}
+	if f == nil {
+		d.invariant.Store(nil)
+		return
+	}
+	d.invariant.Store(&f)
+}
+
+// checkInvariant panics if the gate's invariant does not hold.
+func (d *gateDebug) checkInvariant(set bool) {
+	f := d.invariant.Load()
+	if f == nil {
+		return
+	}
+	if err := (*f)(set); err != nil {
+		if d.name != "" {
+			panic(fmt.Sprintf("gate: invariant of gate %q violated: %v", d.name, err))
+		}
+		panic(fmt.Sprintf("gate: invariant violated: %v", err))
+	}
+}
@@ -0,0 +1,47 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/neild/gate"
+)
+
+func TestSetInvariant(t *testing.T) {
+	enableDebug(t)
+	g := gate.New(false)
+	var items []int
+	g.SetInvariant(func(set bool) error {
+		if want := len(items) > 0; set != want {
+			return fmt.Errorf("condition %v with %v items", set, len(items))
+		}
+		return nil
+	})
+
+	g.Lock()
+	items = append(items, 1)
+	g.Unlock(true)
+
+	g.Lock()
+	items = items[:0]
+	func() {
+		defer func() {
+			p := recover()
+			if p == nil {
+				t.Fatalf("Unlock with violated invariant did not panic")
+			}
+			if !strings.Contains(fmt.Sprint(p), "condition true with 0 items") {
+				t.Errorf("panic = %v, want invariant error", p)
+			}
+		}()
+		g.Unlock(true)
+	}()
+
+	g.SetInvariant(nil)
+	g.Unlock(true)
+}
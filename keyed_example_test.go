@@ -0,0 +1,143 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate_test
+
+import (
+	"context"
+	"fmt"
+	"hash/maphash"
+	"io"
+	"slices"
+	"sync"
+
+	"github.com/neild/gate"
+)
+
+// A KeyedQueue is an unbounded queue of items with keys, consumed by a fixed set of consumers.
+// Each key is assigned to one consumer for the life of the queue,
+// so items with the same key are delivered in order to the same consumer,
+// while items with keys assigned to different consumers may be processed concurrently.
+type KeyedQueue[K comparable, T any] struct {
+	seed      maphash.Seed
+	consumers []keyedConsumer[K, T]
+}
+
+type keyedConsumer[K comparable, T any] struct {
+	gate gate.Gate // set if items are pending or closed
+	err  error
+	q    []keyedItem[K, T]
+}
+
+type keyedItem[K comparable, T any] struct {
+	key K
+	v   T
+}
+
+// NewKeyedQueue returns a new queue with n consumers, numbered 0 through n-1.
+func NewKeyedQueue[K comparable, T any](n int) *KeyedQueue[K, T] {
+	if n <= 0 {
+		panic("gate: KeyedQueue must have at least one consumer")
+	}
+	q := &KeyedQueue[K, T]{
+		seed:      maphash.MakeSeed(),
+		consumers: make([]keyedConsumer[K, T], n),
+	}
+	for i := range q.consumers {
+		q.consumers[i].gate = gate.New(false)
+	}
+	return q
+}
+
+// Consumer returns the consumer to which key is assigned.
+func (q *KeyedQueue[K, T]) Consumer(key K) int {
+	return int(maphash.Comparable(q.seed, key) % uint64(len(q.consumers)))
+}
+
+// Close closes the queue, causing future calls to Put to fail.
+// Consumers receive the items already in the queue, and then err.
+func (q *KeyedQueue[K, T]) Close(err error) {
+	for i := range q.consumers {
+		c := &q.consumers[i]
+		c.gate.Lock()
+		if c.err == nil {
+			c.err = err
+		}
+		c.unlock()
+	}
+}
+
+// Put appends an item with the given key to the queue of the consumer the key is assigned to.
+// It returns true if the item was added, false if the queue is closed.
+func (q *KeyedQueue[K, T]) Put(key K, v T) bool {
+	c := &q.consumers[q.Consumer(key)]
+	c.gate.Lock()
+	defer c.unlock()
+	if c.err != nil {
+		return false
+	}
+	c.q = append(c.q, keyedItem[K, T]{key, v})
+	return true
+}
+
+// Get removes the first item assigned to the given consumer from the queue,
+// blocking until ctx is done, such an item is available, or the queue is closed.
+func (q *KeyedQueue[K, T]) Get(ctx context.Context, consumer int) (K, T, error) {
+	c := &q.consumers[consumer]
+	if err := c.gate.WaitAndLock(ctx); err != nil {
+		var zero keyedItem[K, T]
+		return zero.key, zero.v, err
+	}
+	defer c.unlock()
+	if len(c.q) == 0 {
+		var zero keyedItem[K, T]
+		return zero.key, zero.v, c.err
+	}
+	it := c.q[0]
+	c.q = slices.Delete(c.q, 0, 1)
+	return it.key, it.v, nil
+}
+
+// unlock unlocks the consumer's gate,
+// setting the condition to true if an item is pending or the queue is closed.
+func (c *keyedConsumer[K, T]) unlock() {
+	c.gate.Unlock(c.err != nil || len(c.q) > 0)
+}
+
+func Example_keyedQueue() {
+	const consumers = 2
+	q := NewKeyedQueue[string, int](consumers)
+	for i := range 3 {
+		q.Put("a", i)
+		q.Put("b", i)
+	}
+	q.Close(io.EOF)
+
+	var (
+		wg  sync.WaitGroup
+		mu  sync.Mutex
+		got = map[string][]int{}
+	)
+	for consumer := range consumers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				key, v, err := q.Get(context.Background(), consumer)
+				if err != nil {
+					return
+				}
+				mu.Lock()
+				got[key] = append(got[key], v)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	// Each key's items were processed in order.
+	fmt.Println(got["a"], got["b"])
+	// Output:
+	// [0 1 2] [0 1 2]
+}
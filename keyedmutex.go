@@ -0,0 +1,90 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate
+
+import "context"
+
+// A KeyedMutex provides a separate mutual exclusion lock for each key.
+// Locks for keys which are neither held nor waited for use no memory.
+type KeyedMutex[K comparable] struct {
+	mu    Gate // guards locks
+	locks map[K]*keyedLock
+}
+
+type keyedLock struct {
+	m    Mutex
+	refs int // holders and waiters; guarded by KeyedMutex.mu
+}
+
+// NewKeyedMutex returns a new KeyedMutex with all keys unlocked.
+func NewKeyedMutex[K comparable]() *KeyedMutex[K] {
+	return &KeyedMutex[K]{
+		mu:    New(false),
+		locks: make(map[K]*keyedLock),
+	}
+}
+
+// Lock acquires the lock for key.
+// If the context expires, Lock returns an error and does not acquire the lock.
+func (km *KeyedMutex[K]) Lock(ctx context.Context, key K) error {
+	l := km.ref(key)
+	if err := l.m.Lock(ctx); err != nil {
+		km.unref(key, l)
+		return err
+	}
+	return nil
+}
+
+// TryLock acquires the lock for key if it is not held.
+// It reports whether the lock was acquired.
+func (km *KeyedMutex[K]) TryLock(key K) (acquired bool) {
+	l := km.ref(key)
+	if !l.m.TryLock() {
+		km.unref(key, l)
+		return false
+	}
+	return true
+}
+
+// Unlock releases the lock for key.
+// It panics if the lock is not held.
+func (km *KeyedMutex[K]) Unlock(key K) {
+	km.mu.Lock()
+	l := km.locks[key]
+	km.mu.Unlock(false)
+	if l == nil {
+		panic("gate: Unlock of unlocked KeyedMutex key")
+	}
+	l.m.Unlock()
+	km.unref(key, l)
+}
+
+func (km *KeyedMutex[K]) ref(key K) *keyedLock {
+	km.mu.Lock()
+	defer km.mu.Unlock(false)
+	l := km.locks[key]
+	if l == nil {
+		l = &keyedLock{m: NewMutex()}
+		km.locks[key] = l
+	}
+	l.refs++
+	return l
+}
+
+func (km *KeyedMutex[K]) unref(key K, l *keyedLock) {
+	km.mu.Lock()
+	defer km.mu.Unlock(false)
+	l.refs--
+	if l.refs == 0 {
+		delete(km.locks, key)
+	}
+}
+
+// len returns the number of keys with lock state, for testing.
+func (km *KeyedMutex[K]) len() int {
+	km.mu.Lock()
+	defer km.mu.Unlock(false)
+	return len(km.locks)
+}
@@ -0,0 +1,44 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/neild/gate"
+)
+
+func TestKeyedMutex(t *testing.T) {
+	km := gate.NewKeyedMutex[string]()
+	if err := km.Lock(context.Background(), "a"); err != nil {
+		t.Fatalf("km.Lock(a) = %v, want nil", err)
+	}
+	// Different keys are independent.
+	if acquired := km.TryLock("b"); !acquired {
+		t.Fatalf("km.TryLock(b) with a locked = %v, want true", acquired)
+	}
+	if acquired := km.TryLock("a"); acquired {
+		t.Fatalf("km.TryLock(a) with a locked = %v, want false", acquired)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Millisecond)
+	defer cancel()
+	if err := km.Lock(ctx, "a"); err != context.DeadlineExceeded {
+		t.Fatalf("km.Lock(a) with a locked = %v, want context.DeadlineExceeded", err)
+	}
+	go func() {
+		time.Sleep(1 * time.Millisecond)
+		km.Unlock("a")
+	}()
+	if err := km.Lock(context.Background(), "a"); err != nil {
+		t.Fatalf("km.Lock(a) = %v, want nil", err)
+	}
+	km.Unlock("a")
+	km.Unlock("b")
+	if n := gate.KeyedMutexLen(km); n != 0 {
+		t.Fatalf("%v keys retained after all locks released, want 0", n)
+	}
+}
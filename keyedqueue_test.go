@@ -0,0 +1,58 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate_test
+
+import (
+	"context"
+	"io"
+	"testing"
+)
+
+func TestKeyedQueueAssignment(t *testing.T) {
+	const consumers = 4
+	q := NewKeyedQueue[int, int](consumers)
+	assigned := map[int]int{}
+	for key := range 100 {
+		assigned[key] = q.Consumer(key)
+	}
+	for i := range 10 {
+		for key := range 100 {
+			q.Put(key, i)
+		}
+	}
+	q.Close(io.EOF)
+
+	for consumer := range consumers {
+		next := map[int]int{}
+		for {
+			key, v, err := q.Get(context.Background(), consumer)
+			if err != nil {
+				break
+			}
+			if want := assigned[key]; consumer != want {
+				t.Errorf("key %v delivered to consumer %v, want %v", key, consumer, want)
+			}
+			if v != next[key] {
+				t.Errorf("key %v: got item %v, want %v", key, v, next[key])
+			}
+			next[key] = v + 1
+		}
+		for key, n := range next {
+			if n != 10 {
+				t.Errorf("key %v: consumer %v received %v items, want 10", key, consumer, n)
+			}
+		}
+		for key, want := range assigned {
+			if _, ok := next[key]; want == consumer && !ok {
+				t.Errorf("key %v: consumer %v received no items", key, consumer)
+			}
+		}
+	}
+	for key, consumer := range assigned {
+		if got := q.Consumer(key); got != consumer {
+			t.Errorf("q.Consumer(%v) = %v, want the original assignment %v", key, got, consumer)
+		}
+	}
+}
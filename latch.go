@@ -0,0 +1,54 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate
+
+import "context"
+
+// A Latch is a countdown latch.
+// It is initialized with a count, which is decremented by calls to Done.
+// Once the count reaches zero, the latch is open and Wait returns immediately.
+type Latch struct {
+	gate  Gate // set if count is zero
+	count int
+}
+
+// NewLatch returns a new latch with the given count.
+func NewLatch(n int) *Latch {
+	return &Latch{
+		gate:  New(n <= 0),
+		count: max(n, 0),
+	}
+}
+
+// Done decrements the latch's count.
+// It panics if the count is already zero.
+func (l *Latch) Done() {
+	l.gate.Lock()
+	defer l.unlock()
+	if l.count == 0 {
+		panic("gate: Latch.Done called more times than the latch count")
+	}
+	l.count--
+}
+
+// Wait blocks until the latch's count reaches zero or the context expires.
+func (l *Latch) Wait(ctx context.Context) error {
+	if err := l.gate.WaitAndLock(ctx); err != nil {
+		return err
+	}
+	l.unlock()
+	return nil
+}
+
+// Count returns the latch's current count.
+func (l *Latch) Count() int {
+	l.gate.Lock()
+	defer l.unlock()
+	return l.count
+}
+
+func (l *Latch) unlock() {
+	l.gate.Unlock(l.count == 0)
+}
@@ -0,0 +1,49 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/neild/gate"
+)
+
+func TestLatch(t *testing.T) {
+	l := gate.NewLatch(2)
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Millisecond)
+	defer cancel()
+	l.Done()
+	if err := l.Wait(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("l.Wait with count 1 = %v, want context.DeadlineExceeded", err)
+	}
+	if got, want := l.Count(), 1; got != want {
+		t.Fatalf("l.Count() = %v, want %v", got, want)
+	}
+	donec := make(chan error)
+	go func() {
+		donec <- l.Wait(context.Background())
+	}()
+	time.Sleep(1 * time.Millisecond)
+	l.Done()
+	if err := <-donec; err != nil {
+		t.Fatalf("l.Wait = %v, want nil", err)
+	}
+	if err := l.Wait(ctx); err != nil {
+		t.Fatalf("l.Wait of open latch with expired context = %v, want nil", err)
+	}
+}
+
+func TestLatchOverDecrement(t *testing.T) {
+	l := gate.NewLatch(1)
+	l.Done()
+	defer func() {
+		if recover() == nil {
+			t.Errorf("l.Done of open latch did not panic")
+		}
+	}()
+	l.Done()
+}
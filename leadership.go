@@ -0,0 +1,94 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate
+
+import "context"
+
+// A Leadership elects a single leader from a set of candidates.
+//
+// Candidates campaign to become leader, and at most one holds leadership at a time.
+// Observers may watch for changes of leader.
+type Leadership struct {
+	gate    Gate // set if there is no leader
+	current *Term
+	leader  *Watchable[string] // current leader's ID, or "" if none
+}
+
+// A Term is a candidate's period of leadership.
+type Term struct {
+	l    *Leadership
+	id   string
+	stop func() bool // stops the context.AfterFunc ending the term
+}
+
+// NewLeadership returns a new Leadership with no leader.
+func NewLeadership() *Leadership {
+	return &Leadership{
+		gate:   New(true),
+		leader: NewWatchable(""),
+	}
+}
+
+// Campaign blocks until the candidate with the given ID becomes leader,
+// or the context expires.
+// The candidate remains leader until it calls Resign or the context is done.
+func (l *Leadership) Campaign(ctx context.Context, id string) (*Term, error) {
+	if err := l.gate.WaitAndLock(ctx); err != nil {
+		return nil, err
+	}
+	defer l.unlock()
+	t := &Term{
+		l:  l,
+		id: id,
+	}
+	l.current = t
+	l.leader.Store(id)
+	t.stop = context.AfterFunc(ctx, func() {
+		t.end()
+	})
+	return t, nil
+}
+
+// Leader returns the ID of the current leader, or "" if there is none,
+// along with a version which may be passed to Wait.
+func (l *Leadership) Leader() (id string, version uint64) {
+	return l.leader.Load()
+}
+
+// Wait blocks until the leader has changed since the given version or the context expires.
+// Each election and each end of a term is a change.
+// It returns the current leader and version.
+func (l *Leadership) Wait(ctx context.Context, version uint64) (id string, newVersion uint64, err error) {
+	return l.leader.Wait(ctx, version)
+}
+
+// Resign ends the term, giving up leadership.
+// It reports whether this call ended the term,
+// which it does not if the term had already ended.
+func (t *Term) Resign() bool {
+	t.stop()
+	return t.end()
+}
+
+// ID returns the ID of the candidate holding the term.
+func (t *Term) ID() string {
+	return t.id
+}
+
+func (t *Term) end() bool {
+	l := t.l
+	l.gate.Lock()
+	defer l.unlock()
+	if l.current != t {
+		return false
+	}
+	l.current = nil
+	l.leader.Store("")
+	return true
+}
+
+func (l *Leadership) unlock() {
+	l.gate.Unlock(l.current == nil)
+}
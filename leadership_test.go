@@ -0,0 +1,75 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/neild/gate"
+)
+
+func TestLeadership(t *testing.T) {
+	l := gate.NewLeadership()
+	if id, _ := l.Leader(); id != "" {
+		t.Fatalf("l.Leader of new Leadership = %q, want none", id)
+	}
+	a, err := l.Campaign(context.Background(), "a")
+	if err != nil {
+		t.Fatalf("l.Campaign(a) = %v, want nil", err)
+	}
+	id, version := l.Leader()
+	if id != "a" {
+		t.Fatalf("l.Leader = %q, want %q", id, "a")
+	}
+
+	// A second candidate waits for the first to resign.
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Millisecond)
+	defer cancel()
+	if _, err := l.Campaign(ctx, "b"); err != context.DeadlineExceeded {
+		t.Fatalf("l.Campaign(b) with leader = %v, want context.DeadlineExceeded", err)
+	}
+	termc := make(chan *gate.Term)
+	go func() {
+		b, _ := l.Campaign(context.Background(), "b")
+		termc <- b
+	}()
+	time.Sleep(1 * time.Millisecond)
+	if ok := a.Resign(); !ok {
+		t.Fatalf("a.Resign = %v, want true", ok)
+	}
+	if ok := a.Resign(); ok {
+		t.Fatalf("second a.Resign = %v, want false", ok)
+	}
+	b := <-termc
+	if got, want := b.ID(), "b"; got != want {
+		t.Fatalf("b.ID() = %q, want %q", got, want)
+	}
+
+	// Observers see each change.
+	id, version, err = l.Wait(context.Background(), version)
+	if err != nil {
+		t.Fatalf("l.Wait = %v, want nil", err)
+	}
+	for id != "b" {
+		id, version, _ = l.Wait(context.Background(), version)
+	}
+}
+
+func TestLeadershipContextEnds(t *testing.T) {
+	l := gate.NewLeadership()
+	ctx, cancel := context.WithCancel(context.Background())
+	l.Campaign(ctx, "a")
+	_, version := l.Leader()
+	cancel()
+	id, _, err := l.Wait(context.Background(), version)
+	if err != nil || id != "" {
+		t.Fatalf("l.Wait after leader's context ended = %q, %v; want no leader", id, err)
+	}
+	if _, err := l.Campaign(context.Background(), "b"); err != nil {
+		t.Fatalf("l.Campaign(b) = %v, want nil", err)
+	}
+}
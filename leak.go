@@ -0,0 +1,87 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate
+
+import (
+	"bytes"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// A TB is the subset of testing.TB used by CheckLeaks.
+type TB interface {
+	Helper()
+	Cleanup(func())
+	Errorf(format string, args ...any)
+}
+
+var leakChecks atomic.Int32 // number of tests checking for leaks
+
+// leakGracePeriod is how long CheckLeaks waits for gates to be released
+// by goroutines still finishing at the end of a test.
+var leakGracePeriod = 1 * time.Second
+
+// CheckLeaks fails the test if, when it ends, any gate created during the test
+// is still locked or has goroutines blocked on it.
+// This includes the gates of primitives built on gates, such as a Semaphore
+// with a blocked Acquire, and waits for primitives' notifications made with Gate.WaitFor,
+// such as a blocked WeightedSemaphore.Acquire or Notifier.Wait.
+//
+// CheckLeaks tracks gates created after it is called until the test ends,
+// as if debug tracking were enabled; see SetDebug.
+// Each failure reports where the gate was acquired or where the waiter blocked.
+// Since gates are attributed to tests by when they were created,
+// CheckLeaks should not be used by parallel tests.
+func CheckLeaks(t TB) {
+	t.Helper()
+	leakChecks.Add(1)
+	start := debugSeq.Load()
+	t.Cleanup(func() {
+		defer leakChecks.Add(-1)
+		var leaks []GateDump
+		for deadline := time.Now().Add(leakGracePeriod); ; {
+			leaks = gateLeaks(start)
+			if len(leaks) == 0 || time.Now().After(deadline) {
+				break
+			}
+			time.Sleep(1 * time.Millisecond)
+		}
+		for _, d := range leaks {
+			var b bytes.Buffer
+			if d.Holder != 0 {
+				fmt.Fprintf(&b, "gate left locked by goroutine %v, acquired at:\n%s\n", d.Holder, d.HolderStack)
+			} else {
+				fmt.Fprintf(&b, "gate has blocked waiters:\n")
+			}
+			for _, w := range d.Waiters {
+				fmt.Fprintf(&b, "\tgoroutine %v blocked in %v at %v\n", w.Goroutine, w.Op, w.CallSite)
+			}
+			t.Errorf("%s", b.String())
+		}
+	})
+}
+
+// gateLeaks returns the tracked gates created after the gate with sequence number start
+// which are locked or have blocked waiters.
+func gateLeaks(start uint64) []GateDump {
+	debugMu.Lock()
+	defer debugMu.Unlock()
+	gates := map[*gateDebug]bool{}
+	for d := range debugHeld {
+		gates[d] = true
+	}
+	for _, w := range debugWaits {
+		gates[w.d] = true
+	}
+	var leaks []GateDump
+	for d := range gates {
+		if d.seq <= start {
+			continue
+		}
+		leaks = append(leaks, d.dump())
+	}
+	return leaks
+}
@@ -0,0 +1,111 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/neild/gate"
+)
+
+// leakTB is a gate.TB which records failures.
+type leakTB struct {
+	cleanups []func()
+	errors   []string
+}
+
+func (t *leakTB) Helper()                      {}
+func (t *leakTB) Cleanup(f func())             { t.cleanups = append(t.cleanups, f) }
+func (t *leakTB) Errorf(f string, args ...any) { t.errors = append(t.errors, fmt.Sprintf(f, args...)) }
+
+func (t *leakTB) end() {
+	for i := len(t.cleanups) - 1; i >= 0; i-- {
+		t.cleanups[i]()
+	}
+}
+
+func TestCheckLeaks(t *testing.T) {
+	defer gate.SetLeakGracePeriod(10 * time.Millisecond)()
+
+	// A gate created before CheckLeaks is not attributed to the test.
+	enableDebug(t)
+	before := gate.New(false)
+	before.Lock()
+	defer before.Unlock(false)
+
+	tb := &leakTB{}
+	gate.CheckLeaks(tb)
+	mu := gate.New(false)
+	mu.Lock()
+	sem := gate.NewSemaphore(0)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go sem.Acquire(ctx)
+	for len(gate.Dump()) < 3 {
+		time.Sleep(1 * time.Millisecond)
+	}
+	tb.end()
+	if len(tb.errors) != 2 {
+		t.Fatalf("CheckLeaks reported %v errors, want 2:\n%v", len(tb.errors), strings.Join(tb.errors, "\n"))
+	}
+	all := strings.Join(tb.errors, "\n")
+	if !strings.Contains(all, "left locked") || !strings.Contains(all, "TestCheckLeaks") {
+		t.Errorf("CheckLeaks did not report locked gate acquired in test:\n%v", all)
+	}
+	if !strings.Contains(all, "blocked in WaitAndLock") {
+		t.Errorf("CheckLeaks did not report blocked Acquire:\n%v", all)
+	}
+	mu.Unlock(false)
+}
+
+func TestCheckLeaksWaitFor(t *testing.T) {
+	defer gate.SetLeakGracePeriod(10 * time.Millisecond)()
+	enableDebug(t)
+
+	tb := &leakTB{}
+	gate.CheckLeaks(tb)
+	sem := gate.NewWeightedSemaphore(1, gate.WithName("sem"))
+	sem.Acquire(context.Background(), 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go sem.Acquire(ctx, 1)
+	blocked := func() bool {
+		for _, d := range gate.Dump() {
+			if d.Name == "sem" && len(d.Waiters) > 0 {
+				return true
+			}
+		}
+		return false
+	}
+	for !blocked() {
+		time.Sleep(1 * time.Millisecond)
+	}
+	tb.end()
+	if len(tb.errors) != 1 {
+		t.Fatalf("CheckLeaks reported %v errors, want 1:\n%v", len(tb.errors), strings.Join(tb.errors, "\n"))
+	}
+	if !strings.Contains(tb.errors[0], "blocked in WaitFor") {
+		t.Errorf("CheckLeaks did not report blocked WeightedSemaphore.Acquire:\n%v", tb.errors[0])
+	}
+}
+
+func TestCheckLeaksNone(t *testing.T) {
+	tb := &leakTB{}
+	gate.CheckLeaks(tb)
+	mu := gate.New(false)
+	mu.Lock()
+	go func() {
+		time.Sleep(1 * time.Millisecond)
+		mu.Unlock(false)
+	}()
+	tb.end()
+	if len(tb.errors) != 0 {
+		t.Fatalf("CheckLeaks reported errors for released gates:\n%v", strings.Join(tb.errors, "\n"))
+	}
+}
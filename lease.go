@@ -0,0 +1,145 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+)
+
+// ErrLeaseLost is returned when renewing a lease which has expired or been released.
+var ErrLeaseLost = errors.New("gate: lease lost")
+
+// A Leaser grants exclusive leases which expire unless renewed.
+//
+// When a lease expires, it is revoked and the next waiter is granted a new lease.
+// This permits failover from a holder which stalls.
+type Leaser struct {
+	gate  Gate   // set if no lease is held
+	cur   *Lease // current lease
+	clock Clock
+}
+
+// A Lease is an exclusive, time-limited grant from a Leaser.
+type Lease struct {
+	l        *Leaser
+	timer    Timer
+	deadline time.Time // guarded by l.gate
+	onLost   []*func() // guarded by l.gate
+	released bool      // guarded by l.gate
+}
+
+// NewLeaser returns a new Leaser with no lease held.
+func NewLeaser(opts ...Option) *Leaser {
+	o := newOptions(opts)
+	return &Leaser{
+		gate:  o.newGate(true, ""),
+		clock: o.clock,
+	}
+}
+
+// Acquire blocks until no lease is held or the context expires,
+// and returns a new lease which expires after ttl unless renewed.
+func (l *Leaser) Acquire(ctx context.Context, ttl time.Duration) (*Lease, error) {
+	if err := l.gate.WaitAndLock(ctx); err != nil {
+		return nil, err
+	}
+	defer l.unlock()
+	lease := &Lease{
+		l:        l,
+		deadline: l.clock.Now().Add(ttl),
+	}
+	l.cur = lease
+	lease.timer = l.clock.AfterFunc(ttl, lease.expire)
+	return lease, nil
+}
+
+// Renew extends the lease to expire after ttl.
+// It returns ErrLeaseLost if the lease has already expired or been released.
+func (lease *Lease) Renew(ttl time.Duration) error {
+	l := lease.l
+	l.gate.Lock()
+	defer l.unlock()
+	if l.cur != lease {
+		return ErrLeaseLost
+	}
+	lease.deadline = l.clock.Now().Add(ttl)
+	lease.timer.Reset(ttl)
+	return nil
+}
+
+// Release gives up the lease, permitting another caller to acquire one.
+// Functions registered with OnLost are not called.
+func (lease *Lease) Release() {
+	l := lease.l
+	l.gate.Lock()
+	defer l.unlock()
+	if l.cur != lease {
+		return
+	}
+	lease.timer.Stop()
+	lease.released = true
+	lease.onLost = nil
+	l.cur = nil
+}
+
+// OnLost registers a function to be called in its own goroutine if the lease expires.
+// If the lease has already expired, the function is called immediately.
+// If the lease has been released, the function is never called.
+//
+// The returned stop function unregisters f.
+// It reports whether it did so before f was called.
+func (lease *Lease) OnLost(f func()) (stop func() bool) {
+	l := lease.l
+	l.gate.Lock()
+	defer l.unlock()
+	if lease.released {
+		return func() bool { return false }
+	}
+	if l.cur != lease {
+		go f()
+		return func() bool { return false }
+	}
+	p := &f
+	lease.onLost = append(lease.onLost, p)
+	return func() bool {
+		l.gate.Lock()
+		defer l.unlock()
+		i := slices.Index(lease.onLost, p)
+		if i < 0 {
+			return false
+		}
+		lease.onLost = slices.Delete(lease.onLost, i, i+1)
+		return true
+	}
+}
+
+func (lease *Lease) expire() {
+	l := lease.l
+	l.gate.Lock()
+	if l.cur != lease {
+		l.unlock()
+		return
+	}
+	if d := lease.deadline.Sub(l.clock.Now()); d > 0 {
+		// The lease was renewed after the timer fired.
+		lease.timer.Reset(d)
+		l.unlock()
+		return
+	}
+	l.cur = nil
+	onLost := lease.onLost
+	lease.onLost = nil
+	l.unlock()
+	for _, f := range onLost {
+		go (*f)()
+	}
+}
+
+func (l *Leaser) unlock() {
+	l.gate.Unlock(l.cur == nil)
+}
@@ -0,0 +1,87 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/neild/gate"
+)
+
+func TestLeaseExclusive(t *testing.T) {
+	l := gate.NewLeaser()
+	lease, err := l.Acquire(context.Background(), 1*time.Hour)
+	if err != nil {
+		t.Fatalf("l.Acquire = %v, want nil error", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Millisecond)
+	defer cancel()
+	if _, err := l.Acquire(ctx, 1*time.Hour); err != context.DeadlineExceeded {
+		t.Fatalf("l.Acquire with lease held = %v, want context.DeadlineExceeded", err)
+	}
+	lease.Release()
+	if err := lease.Renew(1 * time.Hour); err != gate.ErrLeaseLost {
+		t.Fatalf("lease.Renew after Release = %v, want ErrLeaseLost", err)
+	}
+	if _, err := l.Acquire(context.Background(), 1*time.Hour); err != nil {
+		t.Fatalf("l.Acquire after Release = %v, want nil error", err)
+	}
+}
+
+func TestLeaseExpiry(t *testing.T) {
+	l := gate.NewLeaser()
+	lease, _ := l.Acquire(context.Background(), 1*time.Millisecond)
+	lostc := make(chan struct{})
+	lease.OnLost(func() { close(lostc) })
+
+	// The next waiter is admitted when the lease expires.
+	if _, err := l.Acquire(context.Background(), 1*time.Hour); err != nil {
+		t.Fatalf("l.Acquire = %v, want nil error", err)
+	}
+	<-lostc
+	if err := lease.Renew(1 * time.Hour); err != gate.ErrLeaseLost {
+		t.Fatalf("lease.Renew after expiry = %v, want ErrLeaseLost", err)
+	}
+}
+
+func TestLeaseRenew(t *testing.T) {
+	l := gate.NewLeaser()
+	lease, _ := l.Acquire(context.Background(), 20*time.Millisecond)
+	for i := 0; i < 5; i++ {
+		time.Sleep(2 * time.Millisecond)
+		if err := lease.Renew(20 * time.Millisecond); err != nil {
+			t.Fatalf("lease.Renew = %v, want nil", err)
+		}
+	}
+	lease.Release()
+}
+
+func TestLeaseOnLostAfterRelease(t *testing.T) {
+	c := gate.NewFakeClock(time.Now())
+	l := gate.NewLeaser(gate.WithClock(c))
+	lease, _ := l.Acquire(context.Background(), 1*time.Minute)
+	lease.Release()
+	stop := lease.OnLost(func() { t.Errorf("OnLost function called after Release") })
+	if stop() {
+		t.Errorf("stop() = true after Release, want false")
+	}
+	c.Advance(2 * time.Minute)
+}
+
+func TestLeaseOnLostStop(t *testing.T) {
+	c := gate.NewFakeClock(time.Now())
+	l := gate.NewLeaser(gate.WithClock(c))
+	lease, _ := l.Acquire(context.Background(), 1*time.Minute)
+	stop := lease.OnLost(func() { t.Errorf("stopped OnLost function called") })
+	if !stop() {
+		t.Errorf("stop() = false before expiry, want true")
+	}
+	c.Advance(2 * time.Minute)
+	if err := lease.Renew(1 * time.Minute); err != gate.ErrLeaseLost {
+		t.Fatalf("lease.Renew after expiry = %v, want ErrLeaseLost", err)
+	}
+}
@@ -0,0 +1,260 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate_test
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/neild/gate"
+)
+
+// Linearizability tests run randomized operations against a primitive from several goroutines,
+// and check that the results are consistent with some sequential ordering of the operations
+// in which each operation takes effect between its call and its return.
+
+// A linOp is a completed operation in a history.
+type linOp[S comparable] struct {
+	desc      string
+	call, ret int64
+	// step applies the operation to a model state.
+	// It reports whether the operation's observed result is possible in that state.
+	step func(S) (S, bool)
+}
+
+// A linHistory records the operations performed by concurrent goroutines.
+type linHistory[S comparable] struct {
+	clock atomic.Int64
+	mu    sync.Mutex
+	ops   []linOp[S]
+}
+
+// do runs an operation, which returns a description of its result and its model step.
+func (h *linHistory[S]) do(op func() (string, func(S) (S, bool))) {
+	call := h.clock.Add(1)
+	desc, step := op()
+	ret := h.clock.Add(1)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.ops = append(h.ops, linOp[S]{desc, call, ret, step})
+}
+
+// check fails the test if the history is not linearizable, starting from the initial model state.
+func (h *linHistory[S]) check(t *testing.T, init S) {
+	t.Helper()
+	ops := h.ops
+	if len(ops) > 64 {
+		t.Fatalf("history of %v operations is too long to check", len(ops))
+	}
+	all := uint64(1)<<len(ops) - 1
+	type key struct {
+		done uint64
+		s    S
+	}
+	seen := map[key]bool{}
+	var search func(done uint64, s S) bool
+	search = func(done uint64, s S) bool {
+		if done == all {
+			return true
+		}
+		if seen[key{done, s}] {
+			return false
+		}
+		seen[key{done, s}] = true
+		// The next operation in the order must have been called
+		// before every remaining operation returned.
+		minRet := int64(math.MaxInt64)
+		for i, op := range ops {
+			if done&(1<<i) == 0 {
+				minRet = min(minRet, op.ret)
+			}
+		}
+		for i, op := range ops {
+			if done&(1<<i) != 0 || op.call > minRet {
+				continue
+			}
+			if next, ok := op.step(s); ok && search(done|1<<i, next) {
+				return true
+			}
+		}
+		return false
+	}
+	if !search(0, init) {
+		var b strings.Builder
+		for _, op := range ops {
+			fmt.Fprintf(&b, "\t[%v, %v] %v\n", op.call, op.ret, op.desc)
+		}
+		t.Fatalf("history is not linearizable:\n%v", b.String())
+	}
+}
+
+// linPrograms splits fuzz input into a program of operations for each of several goroutines.
+func linPrograms(data []byte) [][]byte {
+	const goroutines, maxOps = 3, 8
+	progs := make([][]byte, goroutines)
+	for i, b := range data[:min(len(data), goroutines*maxOps)] {
+		progs[i%goroutines] = append(progs[i%goroutines], b)
+	}
+	return progs
+}
+
+func runLinearizability(f *testing.F, run func(t *testing.T, progs [][]byte)) {
+	f.Add([]byte{0, 1, 2, 3, 4, 5, 6, 7, 8})
+	f.Add([]byte{1, 1, 1, 0, 0, 0, 2, 2, 2, 1, 0, 2})
+	f.Add([]byte{2, 4, 6, 8, 1, 3, 5, 7, 9, 11, 13, 15})
+	f.Fuzz(func(t *testing.T, data []byte) {
+		for i := 0; i < 20; i++ {
+			run(t, linPrograms(data))
+		}
+	})
+}
+
+func FuzzLinearizableQueue(f *testing.F) {
+	// The model is the queue's contents, one byte per item.
+	runLinearizability(f, func(t *testing.T, progs [][]byte) {
+		q := NewQueue[byte]()
+		h := &linHistory[string]{}
+		var wg sync.WaitGroup
+		for _, prog := range progs {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				puts, gets := 0, 0
+				for _, b := range prog {
+					// Get only after putting more items than we have removed,
+					// so some item is always available to each Get eventually.
+					if b%2 == 0 || puts == gets {
+						puts++
+						h.do(func() (string, func(string) (string, bool)) {
+							q.Put(b)
+							return fmt.Sprintf("Put(%v)", b), func(s string) (string, bool) {
+								return s + string([]byte{b}), true
+							}
+						})
+					} else {
+						gets++
+						h.do(func() (string, func(string) (string, bool)) {
+							v, err := q.Get(context.Background())
+							return fmt.Sprintf("Get() = %v, %v", v, err), func(s string) (string, bool) {
+								if err != nil || len(s) == 0 || s[0] != v {
+									return s, false
+								}
+								return s[1:], true
+							}
+						})
+					}
+				}
+			}()
+		}
+		wg.Wait()
+		h.check(t, "")
+	})
+}
+
+func FuzzLinearizableSemaphore(f *testing.F) {
+	// The model is the number of available permits.
+	const permits = 2
+	runLinearizability(f, func(t *testing.T, progs [][]byte) {
+		s := gate.NewSemaphore(permits)
+		h := &linHistory[int]{}
+		var wg sync.WaitGroup
+		for _, prog := range progs {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				held := false
+				release := func() {
+					held = false
+					h.do(func() (string, func(int) (int, bool)) {
+						s.Release()
+						return "Release()", func(avail int) (int, bool) {
+							return avail + 1, avail < permits
+						}
+					})
+				}
+				for _, b := range prog {
+					switch {
+					case held:
+						release()
+					case b%2 == 0:
+						held = true
+						h.do(func() (string, func(int) (int, bool)) {
+							err := s.Acquire(context.Background())
+							return fmt.Sprintf("Acquire() = %v", err), func(avail int) (int, bool) {
+								return avail - 1, err == nil && avail > 0
+							}
+						})
+					default:
+						h.do(func() (string, func(int) (int, bool)) {
+							ok := s.TryAcquire()
+							held = ok
+							return fmt.Sprintf("TryAcquire() = %v", ok), func(avail int) (int, bool) {
+								if !ok {
+									// TryAcquire may fail while another goroutine
+									// holds the semaphore's gate.
+									return avail, true
+								}
+								return avail - 1, avail > 0
+							}
+						})
+					}
+				}
+				if held {
+					release()
+				}
+			}()
+		}
+		wg.Wait()
+		h.check(t, permits)
+	})
+}
+
+func FuzzLinearizableMailbox(f *testing.F) {
+	// The model is the mailbox's value, if full.
+	type state struct {
+		full bool
+		v    byte
+	}
+	runLinearizability(f, func(t *testing.T, progs [][]byte) {
+		m := gate.NewMailbox[byte]()
+		h := &linHistory[state]{}
+		var wg sync.WaitGroup
+		for _, prog := range progs {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for _, b := range prog {
+					if b%2 == 0 {
+						h.do(func() (string, func(state) (state, bool)) {
+							replaced := m.Put(b)
+							return fmt.Sprintf("Put(%v) = %v", b, replaced), func(s state) (state, bool) {
+								return state{true, b}, replaced == s.full
+							}
+						})
+					} else {
+						h.do(func() (string, func(state) (state, bool)) {
+							v, ok := m.TryGet()
+							return fmt.Sprintf("TryGet() = %v, %v", v, ok), func(s state) (state, bool) {
+								if !ok {
+									// TryGet may fail while another goroutine
+									// holds the mailbox's gate.
+									return s, true
+								}
+								return state{}, s.full && s.v == v
+							}
+						})
+					}
+				}
+			}()
+		}
+		wg.Wait()
+		h.check(t, state{})
+	})
+}
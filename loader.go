@@ -0,0 +1,116 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate
+
+import (
+	"context"
+	"maps"
+	"time"
+)
+
+// A Loader is a cache of values loaded on demand.
+//
+// Concurrent requests for a missing key share a single load,
+// as with SingleFlight: each caller waits with its own context,
+// and the load is canceled only when every caller has given up.
+type Loader[K comparable, V any] struct {
+	load    func(context.Context, K) (V, error)
+	ttl     time.Duration
+	errTTL  time.Duration
+	clock   Clock
+	flight  *SingleFlight[K, V]
+	mu      Gate // guards the fields below
+	entries map[K]loaderEntry[V]
+	sweepAt int // size of entries at which to remove expired entries
+}
+
+type loaderEntry[V any] struct {
+	v       V
+	err     error
+	expires time.Time
+}
+
+// NewLoader returns a new loader which loads values with load.
+// Loaded values are cached for ttl.
+// Errors are cached for errTTL, or not at all if errTTL is zero.
+func NewLoader[K comparable, V any](load func(context.Context, K) (V, error), ttl, errTTL time.Duration, opts ...Option) *Loader[K, V] {
+	o := newOptions(opts)
+	return &Loader[K, V]{
+		load:    load,
+		ttl:     ttl,
+		errTTL:  errTTL,
+		clock:   o.clock,
+		flight:  NewSingleFlight[K, V](),
+		mu:      o.newGate(false, ""),
+		entries: make(map[K]loaderEntry[V]),
+		sweepAt: loaderMinSweep,
+	}
+}
+
+// Get returns the cached value for key, loading it if it is missing or expired.
+// If ctx expires before the load completes, Get returns the context's error.
+func (l *Loader[K, V]) Get(ctx context.Context, key K) (V, error) {
+	l.mu.Lock()
+	e, ok := l.entries[key]
+	if ok {
+		if l.clock.Now().Before(e.expires) {
+			l.mu.Unlock(false)
+			return e.v, e.err
+		}
+		delete(l.entries, key)
+	}
+	l.mu.Unlock(false)
+	return l.flight.Do(ctx, key, func(ctx context.Context) (V, error) {
+		v, err := l.load(ctx, key)
+		l.store(ctx, key, v, err)
+		return v, err
+	})
+}
+
+// Forget removes the cached value for key, if any.
+// A load in progress is not affected.
+func (l *Loader[K, V]) Forget(key K) {
+	l.mu.Lock()
+	defer l.mu.Unlock(false)
+	delete(l.entries, key)
+}
+
+// store caches the result of a load.
+func (l *Loader[K, V]) store(ctx context.Context, key K, v V, err error) {
+	ttl := l.ttl
+	if err != nil {
+		ttl = l.errTTL
+	}
+	if ttl <= 0 || ctx.Err() != nil {
+		// Don't cache the result of a load abandoned by every caller.
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock(false)
+	now := l.clock.Now()
+	l.entries[key] = loaderEntry[V]{
+		v:       v,
+		err:     err,
+		expires: now.Add(ttl),
+	}
+	if len(l.entries) >= l.sweepAt {
+		// Remove entries which expired without being requested again.
+		// Sweeping each time the cache doubles in size keeps the cost amortized.
+		maps.DeleteFunc(l.entries, func(_ K, e loaderEntry[V]) bool {
+			return !now.Before(e.expires)
+		})
+		l.sweepAt = max(2*len(l.entries), loaderMinSweep)
+	}
+}
+
+// loaderMinSweep is the smallest cache size at which a Loader removes expired entries.
+const loaderMinSweep = 64
+
+// len returns the number of cached entries, including expired ones.
+func (l *Loader[K, V]) len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock(false)
+	return len(l.entries)
+}
@@ -0,0 +1,127 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/neild/gate"
+)
+
+func TestLoader(t *testing.T) {
+	var loads atomic.Int32
+	releasec := make(chan struct{})
+	l := gate.NewLoader(func(ctx context.Context, key string) (int, error) {
+		loads.Add(1)
+		<-releasec
+		return len(key), nil
+	}, 1*time.Hour, 0)
+
+	const n = 10
+	gotc := make(chan int)
+	for i := 0; i < n; i++ {
+		go func() {
+			v, _ := l.Get(context.Background(), "key")
+			gotc <- v
+		}()
+	}
+	time.Sleep(1 * time.Millisecond)
+	close(releasec)
+	for i := 0; i < n; i++ {
+		if v := <-gotc; v != 3 {
+			t.Fatalf("l.Get = %v, want 3", v)
+		}
+	}
+	// The value is cached.
+	if v, err := l.Get(context.Background(), "key"); err != nil || v != 3 {
+		t.Fatalf("cached l.Get = %v, %v; want 3, nil", v, err)
+	}
+	if got, want := loads.Load(), int32(1); got != want {
+		t.Fatalf("loads = %v, want %v", got, want)
+	}
+
+	l.Forget("key")
+	l.Get(context.Background(), "key")
+	if got, want := loads.Load(), int32(2); got != want {
+		t.Fatalf("loads after Forget = %v, want %v", got, want)
+	}
+}
+
+func TestLoaderTTL(t *testing.T) {
+	var loads atomic.Int32
+	l := gate.NewLoader(func(ctx context.Context, key int) (int32, error) {
+		return loads.Add(1), nil
+	}, 1*time.Millisecond, 0)
+	l.Get(context.Background(), 1)
+	time.Sleep(2 * time.Millisecond)
+	if v, _ := l.Get(context.Background(), 1); v != 2 {
+		t.Fatalf("l.Get after TTL = %v, want 2", v)
+	}
+}
+
+func TestLoaderErrorCaching(t *testing.T) {
+	wantErr := errors.New("load failed")
+	for _, test := range []struct {
+		name      string
+		errTTL    time.Duration
+		wantLoads int32
+	}{
+		{"not cached", 0, 2},
+		{"cached", 1 * time.Hour, 1},
+	} {
+		var loads atomic.Int32
+		l := gate.NewLoader(func(ctx context.Context, key int) (int, error) {
+			loads.Add(1)
+			return 0, wantErr
+		}, 1*time.Hour, test.errTTL)
+		for i := 0; i < 2; i++ {
+			if _, err := l.Get(context.Background(), 1); err != wantErr {
+				t.Errorf("%v: l.Get = %v, want %v", test.name, err, wantErr)
+			}
+		}
+		if got := loads.Load(); got != test.wantLoads {
+			t.Errorf("%v: loads = %v, want %v", test.name, got, test.wantLoads)
+		}
+	}
+}
+
+func TestLoaderCallerCanceled(t *testing.T) {
+	l := gate.NewLoader(func(ctx context.Context, key int) (int, error) {
+		<-ctx.Done()
+		return 0, ctx.Err()
+	}, 1*time.Hour, 1*time.Hour)
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Millisecond)
+	defer cancel()
+	if _, err := l.Get(ctx, 1); err != context.DeadlineExceeded {
+		t.Fatalf("l.Get with expired context = %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestLoaderRemovesExpired(t *testing.T) {
+	clock := gate.NewFakeClock(time.Now())
+	l := gate.NewLoader(func(ctx context.Context, key int) (int, error) {
+		return key, nil
+	}, 1*time.Minute, 0, gate.WithClock(clock))
+	ctx := context.Background()
+	l.Get(ctx, 0)
+	clock.Advance(2 * time.Minute)
+	l.Get(ctx, 0)
+	if got, want := gate.LoaderLen(l), 1; got != want {
+		t.Fatalf("after reloading an expired key, %v entries cached, want %v", got, want)
+	}
+
+	// Expired entries which are never requested again are eventually removed.
+	for i := 1; i < 1000; i++ {
+		l.Get(ctx, i)
+		clock.Advance(2 * time.Minute)
+	}
+	if got := gate.LoaderLen(l); got > 200 {
+		t.Fatalf("after loading 1000 keys which each expired, %v entries cached", got)
+	}
+}
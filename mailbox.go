@@ -0,0 +1,66 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate
+
+import "context"
+
+// A Mailbox holds at most one value.
+// Putting a value into a full mailbox replaces the value already there,
+// so a reader always receives the most recent value.
+type Mailbox[T any] struct {
+	gate Gate // set if the mailbox holds a value
+	v    T
+	full bool
+}
+
+// NewMailbox returns a new, empty mailbox.
+func NewMailbox[T any]() *Mailbox[T] {
+	return &Mailbox[T]{
+		gate: New(false),
+	}
+}
+
+// Put stores a value in the mailbox.
+// It reports whether it replaced a value which had not been received.
+func (m *Mailbox[T]) Put(v T) (replaced bool) {
+	m.gate.Lock()
+	defer m.unlock()
+	replaced = m.full
+	m.v = v
+	m.full = true
+	return replaced
+}
+
+// Get removes the value from the mailbox,
+// blocking until ctx is done or a value is available.
+func (m *Mailbox[T]) Get(ctx context.Context) (T, error) {
+	if err := m.gate.WaitAndLock(ctx); err != nil {
+		var zero T
+		return zero, err
+	}
+	defer m.unlock()
+	return m.take(), nil
+}
+
+// TryGet removes the value from the mailbox if one is present.
+func (m *Mailbox[T]) TryGet() (v T, ok bool) {
+	if !m.gate.LockIfSet() {
+		return v, false
+	}
+	defer m.unlock()
+	return m.take(), true
+}
+
+func (m *Mailbox[T]) take() T {
+	var zero T
+	v := m.v
+	m.v = zero
+	m.full = false
+	return v
+}
+
+func (m *Mailbox[T]) unlock() {
+	m.gate.Unlock(m.full)
+}
@@ -0,0 +1,49 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/neild/gate"
+)
+
+func TestMailbox(t *testing.T) {
+	m := gate.NewMailbox[int]()
+	if _, ok := m.TryGet(); ok {
+		t.Fatalf("m.TryGet of empty mailbox = _, %v; want false", ok)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Millisecond)
+	defer cancel()
+	if _, err := m.Get(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("m.Get of empty mailbox = %v, want context.DeadlineExceeded", err)
+	}
+
+	if replaced := m.Put(1); replaced {
+		t.Fatalf("m.Put(1) into empty mailbox = %v, want false", replaced)
+	}
+	if replaced := m.Put(2); !replaced {
+		t.Fatalf("m.Put(2) into full mailbox = %v, want true", replaced)
+	}
+	if v, err := m.Get(context.Background()); err != nil || v != 2 {
+		t.Fatalf("m.Get = %v, %v; want 2, nil", v, err)
+	}
+	if _, ok := m.TryGet(); ok {
+		t.Fatalf("m.TryGet after Get = _, %v; want false", ok)
+	}
+
+	donec := make(chan int)
+	go func() {
+		v, _ := m.Get(context.Background())
+		donec <- v
+	}()
+	time.Sleep(1 * time.Millisecond)
+	m.Put(3)
+	if v := <-donec; v != 3 {
+		t.Fatalf("blocked m.Get = %v, want 3", v)
+	}
+}
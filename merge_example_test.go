@@ -0,0 +1,102 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+)
+
+// Merge moves items from several sorted source queues to dst, in sorted order,
+// until ctx is done, dst is closed, or every source is closed.
+//
+// To put the least item first, Merge must know the next item from every open source,
+// so it blocks while any open source is empty.
+// A closed source is no longer considered.
+// When every source is closed, Merge closes dst with the error the last source was closed with.
+// When dst is closed, Merge closes every source with dst's error.
+// Merge returns the error which ended it.
+func Merge[T any](ctx context.Context, dst *Queue[T], less func(a, b T) bool, srcs ...*Queue[T]) error {
+	type source struct {
+		q    *Queue[T]
+		head T
+		ok   bool // head holds the next item from q
+	}
+	open := make([]*source, len(srcs))
+	for i, q := range srcs {
+		open[i] = &source{q: q}
+	}
+	var closeErr error
+	for {
+		// Wait for the next item from every open source.
+		for i := 0; i < len(open); {
+			s := open[i]
+			if s.ok {
+				i++
+				continue
+			}
+			v, err := s.q.Get(ctx)
+			if err != nil {
+				if ctx.Err() != nil {
+					return err
+				}
+				closeErr = err
+				open = slices.Delete(open, i, i+1)
+				continue
+			}
+			s.head, s.ok = v, true
+			i++
+		}
+		if len(open) == 0 {
+			dst.Close(closeErr)
+			return closeErr
+		}
+
+		least := open[0]
+		for _, s := range open[1:] {
+			if less(s.head, least.head) {
+				least = s
+			}
+		}
+		if !dst.Put(least.head) {
+			err := dst.Err()
+			for _, q := range srcs {
+				q.Close(err)
+			}
+			return err
+		}
+		var zero T
+		least.head, least.ok = zero, false
+	}
+}
+
+func Example_merge() {
+	a := NewQueue[int]()
+	b := NewQueue[int]()
+	dst := NewQueue[int]()
+	go Merge(context.Background(), dst, func(x, y int) bool { return x < y }, a, b)
+
+	a.Put(1)
+	a.Put(4)
+	b.Put(2)
+	b.Put(3)
+	// Merge must wait for b's next item before it can place 4.
+	for range 3 {
+		fmt.Println(dst.Get(context.Background()))
+	}
+	// Once b is closed, only a's items remain.
+	b.Close(io.EOF)
+	fmt.Println(dst.Get(context.Background()))
+	a.Close(io.EOF)
+	fmt.Println(dst.Get(context.Background()))
+	// Output:
+	// 1 <nil>
+	// 2 <nil>
+	// 3 <nil>
+	// 4 <nil>
+	// 0 EOF
+}
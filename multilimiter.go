@@ -0,0 +1,154 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate
+
+import (
+	"context"
+	"slices"
+)
+
+// A MultiLimiter limits usage of several resources at once,
+// such as concurrent requests and bytes in flight.
+//
+// Each Acquire takes a cost in every dimension, all at once or not at all,
+// so a caller never holds part of what it needs while waiting for the rest.
+// Waiters are admitted in FIFO order, as with WeightedSemaphore.
+type MultiLimiter struct {
+	gate    Gate // set if no dimension is exhausted and there are no waiters
+	size    []int64
+	avail   []int64
+	waiters []*multiWaiter
+}
+
+type multiWaiter struct {
+	costs []int64
+	ready chan struct{} // closed when the costs are granted
+}
+
+// NewMultiLimiter returns a new limiter with the given capacity in each dimension,
+// all available.
+func NewMultiLimiter(capacity ...int64) *MultiLimiter {
+	l := &MultiLimiter{
+		size:  slices.Clone(capacity),
+		avail: slices.Clone(capacity),
+	}
+	l.gate = New(l.hasRoom())
+	return l
+}
+
+// Acquire acquires the given cost in each dimension, blocking until all are available.
+// It panics if the number of costs does not match the number of dimensions.
+// If the context expires, Acquire returns an error and does not acquire anything.
+func (l *MultiLimiter) Acquire(ctx context.Context, costs ...int64) error {
+	l.checkDims(costs)
+	l.gate.Lock()
+	if len(l.waiters) == 0 && l.fits(costs) {
+		l.take(costs)
+		l.unlock()
+		return nil
+	}
+	w := &multiWaiter{
+		costs: slices.Clone(costs),
+		ready: make(chan struct{}),
+	}
+	l.waiters = append(l.waiters, w)
+	l.unlock()
+
+	if l.gate.WaitFor(ctx, w.ready) == nil {
+		return nil
+	}
+	l.gate.Lock()
+	defer l.unlock()
+	select {
+	case <-w.ready:
+		// The costs were granted before we reacquired the gate.
+		return nil
+	default:
+	}
+	i := slices.Index(l.waiters, w)
+	l.waiters = slices.Delete(l.waiters, i, i+1)
+	if i == 0 {
+		// We were blocking the waiters behind us.
+		l.grant()
+	}
+	return ctx.Err()
+}
+
+// TryAcquire acquires the given cost in each dimension if all are available without blocking.
+// It does not acquire anything while other callers are waiting in Acquire.
+// It reports whether the costs were acquired.
+func (l *MultiLimiter) TryAcquire(costs ...int64) (acquired bool) {
+	l.checkDims(costs)
+	if !l.gate.LockIfSet() {
+		return false
+	}
+	defer l.unlock()
+	if !l.fits(costs) {
+		return false
+	}
+	l.take(costs)
+	return true
+}
+
+// Release releases the given cost in each dimension.
+// It panics if more is released than is held.
+func (l *MultiLimiter) Release(costs ...int64) {
+	l.checkDims(costs)
+	l.gate.Lock()
+	defer l.unlock()
+	for i, c := range costs {
+		if l.avail[i]+c > l.size[i] {
+			panic("gate: MultiLimiter released more than held")
+		}
+	}
+	for i, c := range costs {
+		l.avail[i] += c
+	}
+	l.grant()
+}
+
+// grant admits waiters from the front of the queue for as long as their costs fit.
+func (l *MultiLimiter) grant() {
+	for len(l.waiters) > 0 && l.fits(l.waiters[0].costs) {
+		w := l.waiters[0]
+		l.waiters = slices.Delete(l.waiters, 0, 1)
+		l.take(w.costs)
+		close(w.ready)
+	}
+}
+
+func (l *MultiLimiter) fits(costs []int64) bool {
+	for i, c := range costs {
+		if l.avail[i] < c {
+			return false
+		}
+	}
+	return true
+}
+
+func (l *MultiLimiter) take(costs []int64) {
+	for i, c := range costs {
+		l.avail[i] -= c
+	}
+}
+
+func (l *MultiLimiter) hasRoom() bool {
+	for _, a := range l.avail {
+		if a <= 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func (l *MultiLimiter) checkDims(costs []int64) {
+	if len(costs) != len(l.size) {
+		panic("gate: MultiLimiter cost count does not match dimensions")
+	}
+}
+
+func (l *MultiLimiter) unlock() {
+	l.gate.Unlock(l.hasRoom() && len(l.waiters) == 0)
+}
@@ -0,0 +1,61 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/neild/gate"
+)
+
+func TestMultiLimiter(t *testing.T) {
+	// Two concurrent requests, 100 bytes in flight.
+	l := gate.NewMultiLimiter(2, 100)
+	if err := l.Acquire(context.Background(), 1, 80); err != nil {
+		t.Fatalf("l.Acquire(1, 80) = %v, want nil", err)
+	}
+	// There is room for another request, but not enough bytes.
+	if ok := l.TryAcquire(1, 30); ok {
+		t.Fatalf("l.TryAcquire(1, 30) = %v, want false", ok)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Millisecond)
+	defer cancel()
+	if err := l.Acquire(ctx, 1, 30); err != context.DeadlineExceeded {
+		t.Fatalf("l.Acquire(1, 30) = %v, want context.DeadlineExceeded", err)
+	}
+	// The failed Acquire took nothing.
+	if ok := l.TryAcquire(1, 20); !ok {
+		t.Fatalf("l.TryAcquire(1, 20) = %v, want true", ok)
+	}
+
+	donec := make(chan error)
+	go func() {
+		donec <- l.Acquire(context.Background(), 1, 50)
+	}()
+	time.Sleep(1 * time.Millisecond)
+	// Releasing the request slot alone is not enough.
+	l.Release(1, 0)
+	select {
+	case err := <-donec:
+		t.Fatalf("l.Acquire returned %v before bytes were released", err)
+	case <-time.After(1 * time.Millisecond):
+	}
+	l.Release(0, 80)
+	if err := <-donec; err != nil {
+		t.Fatalf("l.Acquire(1, 50) = %v, want nil", err)
+	}
+}
+
+func TestMultiLimiterOverRelease(t *testing.T) {
+	l := gate.NewMultiLimiter(1, 1)
+	defer func() {
+		if recover() == nil {
+			t.Errorf("over-release did not panic")
+		}
+	}()
+	l.Release(0, 1)
+}
@@ -0,0 +1,46 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate
+
+import "context"
+
+// A Mutex is a mutual exclusion lock whose Lock operation may be bounded by a context.
+//
+// A Mutex is a Gate whose condition is always set,
+// so it is visible to the same diagnostics as any other gate.
+type Mutex struct {
+	g Gate
+}
+
+// NewMutex returns a new, unlocked mutex.
+// The options a mutex accepts are WithName, WithObserver, and WithClock.
+func NewMutex(opts ...Option) Mutex {
+	return Mutex{
+		g: newOptions(opts).newGate(true, ""),
+	}
+}
+
+// Lock acquires the mutex.
+// If the context expires, Lock returns an error and does not acquire the mutex.
+// If the mutex is available and the context is expired, Lock acquires the mutex.
+func (m *Mutex) Lock(ctx context.Context) error {
+	return m.g.WaitAndLock(ctx)
+}
+
+// TryLock acquires the mutex if it is not held.
+// It reports whether the mutex was acquired.
+func (m *Mutex) TryLock() (acquired bool) {
+	return m.g.LockIfSet()
+}
+
+// Unlock releases the mutex.
+// It panics if the mutex is not locked.
+func (m *Mutex) Unlock() {
+	// An unlocked mutex's gate holds its set value.
+	if len(m.g.set) != 0 {
+		panic("gate: Unlock of unlocked Mutex")
+	}
+	m.g.Unlock(true)
+}
@@ -0,0 +1,97 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/neild/gate"
+)
+
+func TestMutex(t *testing.T) {
+	m := gate.NewMutex()
+	if err := m.Lock(context.Background()); err != nil {
+		t.Fatalf("m.Lock of unlocked mutex = %v, want nil", err)
+	}
+	if acquired := m.TryLock(); acquired {
+		t.Fatalf("m.TryLock of locked mutex = %v, want false", acquired)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Millisecond)
+	defer cancel()
+	if err := m.Lock(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("m.Lock of locked mutex = %v, want context.DeadlineExceeded", err)
+	}
+	go func() {
+		time.Sleep(1 * time.Millisecond)
+		m.Unlock()
+	}()
+	if err := m.Lock(context.Background()); err != nil {
+		t.Fatalf("m.Lock = %v, want nil", err)
+	}
+	m.Unlock()
+	// Lock succeeds when the mutex is available and the context is canceled.
+	if err := m.Lock(ctx); err != nil {
+		t.Fatalf("m.Lock with expired context = %v, want nil", err)
+	}
+	m.Unlock()
+	if acquired := m.TryLock(); !acquired {
+		t.Fatalf("m.TryLock of unlocked mutex = %v, want true", acquired)
+	}
+}
+
+func TestMutexUnlockOfUnlocked(t *testing.T) {
+	m := gate.NewMutex()
+	defer func() {
+		if recover() == nil {
+			t.Errorf("m.Unlock of unlocked mutex did not panic")
+		}
+	}()
+	m.Unlock()
+}
+
+func TestMutexDebug(t *testing.T) {
+	enableDebug(t)
+	m := gate.NewMutex(gate.WithName("m"))
+	m.Lock(context.Background())
+	var found bool
+	for _, d := range gate.Dump() {
+		if d.Name == "m" && d.Holder != 0 {
+			found = true
+		}
+	}
+	m.Unlock()
+	if !found {
+		t.Errorf("Dump() does not report the locked mutex")
+	}
+}
+
+func TestMutexExplore(t *testing.T) {
+	err := gate.Explore(1000, func(s *gate.Scenario) {
+		m := gate.NewMutex()
+		n := 0
+		for range 2 {
+			s.Go(func() {
+				m.Lock(context.Background())
+				v := n
+				m.Unlock()
+				m.Lock(context.Background())
+				n = v + 1
+				m.Unlock()
+			})
+		}
+		s.Check(func() error {
+			if n != 2 {
+				return errors.New("lost update")
+			}
+			return nil
+		})
+	})
+	if err == nil {
+		t.Errorf("gate.Explore did not find the lost update")
+	}
+}
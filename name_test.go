@@ -0,0 +1,146 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/neild/gate"
+)
+
+func TestNamedGate(t *testing.T) {
+	g := gate.NewNamed(true, "stream.42.sendWindow")
+	if got, want := g.Name(), "stream.42.sendWindow"; got != want {
+		t.Errorf("g.Name() = %q, want %q", got, want)
+	}
+	if got := gate.New(true); got.Name() != "" {
+		t.Errorf("unnamed gate Name() = %q, want \"\"", got.Name())
+	}
+	g.Lock()
+	g.Unlock(false)
+}
+
+func TestNamedGateDump(t *testing.T) {
+	enableDebug(t)
+	g := gate.NewNamed(true, "stream.42.sendWindow")
+	g.Lock()
+	defer g.Unlock(true)
+
+	var found bool
+	for _, d := range gate.Dump() {
+		if d.Name == "stream.42.sendWindow" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Dump() does not include a gate named stream.42.sendWindow")
+	}
+	var buf bytes.Buffer
+	gate.WriteDump(&buf)
+	if want := `gate "stream.42.sendWindow" held by goroutine`; !strings.Contains(buf.String(), want) {
+		t.Errorf("WriteDump output does not contain %q:\n%v", want, buf.String())
+	}
+}
+
+func TestNamedGateDeadlock(t *testing.T) {
+	enableDebug(t)
+	reports := make(chan *gate.DeadlockReport, 1)
+	gate.SetDeadlockHandler(func(r *gate.DeadlockReport) {
+		reports <- r
+	})
+	defer gate.SetDeadlockHandler(nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	a, b := gate.NewNamed(true, "a"), gate.NewNamed(true, "b")
+	lockedA := make(chan struct{})
+	lockedB := make(chan struct{})
+	done := make(chan error)
+	go func() {
+		a.Lock()
+		close(lockedA)
+		<-lockedB
+		err := b.WaitAndLock(ctx)
+		a.Unlock(true)
+		done <- err
+	}()
+	go func() {
+		b.Lock()
+		close(lockedB)
+		<-lockedA
+		err := a.WaitAndLock(ctx)
+		b.Unlock(true)
+		done <- err
+	}()
+	r := <-reports
+	cancel()
+	<-done
+	<-done
+	var names []string
+	for _, g := range r.Goroutines {
+		names = append(names, g.Gate)
+	}
+	if len(names) != 2 || names[0] == names[1] {
+		t.Errorf("deadlocked goroutines wait for gates %q, want a and b", names)
+	}
+	if s := r.String(); !strings.Contains(s, `on gate "a" held by`) || !strings.Contains(s, `on gate "b" held by`) {
+		t.Errorf("report does not name the gates:\n%v", s)
+	}
+}
+
+func TestNamedGateObserver(t *testing.T) {
+	o := gate.NewHistogramObserver(nil, nil)
+	gate.SetObserver(o)
+	defer gate.SetObserver(nil)
+
+	g := gate.NewNamed(false, "conn.7")
+	g.Lock()
+	go func() {
+		time.Sleep(1 * time.Millisecond)
+		g.Unlock(false)
+	}()
+	g.Lock()
+	g.Unlock(false)
+	if h := o.Histogram("conn.7.wait"); h == nil || h.Count() != 1 {
+		t.Errorf("conn.7.wait histogram = %v, want one duration", h)
+	}
+	if h := o.Histogram("gate.wait"); h == nil || h.Count() < 1 {
+		t.Errorf("gate.wait histogram = %v, want at least one duration", h)
+	}
+}
+
+func TestNamedPrimitive(t *testing.T) {
+	enableDebug(t)
+	s := gate.NewSemaphore(1, gate.WithName("uploads"))
+	s.Acquire(context.Background())
+	defer s.Release()
+
+	done := make(chan struct{})
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		defer close(done)
+		s.Acquire(ctx)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+	for {
+		var found bool
+		for _, d := range gate.Dump() {
+			if d.Name == "uploads" && len(d.Waiters) == 1 {
+				found = true
+			}
+		}
+		if found {
+			break
+		}
+		time.Sleep(1 * time.Millisecond)
+	}
+}
@@ -0,0 +1,63 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate
+
+import "context"
+
+// A Notifier broadcasts notifications to any number of waiters.
+//
+// Each notification increments the notifier's version.
+// Waiters pass the last version they observed to Wait,
+// so a notification sent between observing the version and calling Wait is never missed.
+type Notifier struct {
+	mu      Gate // guards the fields below
+	version uint64
+	changed chan struct{} // closed on the next notification
+}
+
+// NewNotifier returns a new notifier at version 0.
+func NewNotifier() *Notifier {
+	return &Notifier{
+		mu: New(false),
+	}
+}
+
+// Notify wakes all waiters and increments the version.
+// It returns the new version.
+func (n *Notifier) Notify() (version uint64) {
+	n.mu.Lock()
+	defer n.mu.Unlock(false)
+	n.version++
+	if n.changed != nil {
+		close(n.changed)
+		n.changed = nil
+	}
+	return n.version
+}
+
+// Version returns the current version.
+func (n *Notifier) Version() uint64 {
+	n.mu.Lock()
+	defer n.mu.Unlock(false)
+	return n.version
+}
+
+// Wait blocks until the version is greater than lastSeen or the context expires.
+// It returns the current version.
+func (n *Notifier) Wait(ctx context.Context, lastSeen uint64) (version uint64, err error) {
+	n.mu.Lock()
+	if n.version > lastSeen {
+		version = n.version
+		n.mu.Unlock(false)
+		return version, nil
+	}
+	if n.changed == nil {
+		n.changed = make(chan struct{})
+	}
+	changed := n.changed
+	n.mu.Unlock(false)
+	err = n.mu.WaitFor(ctx, changed)
+	return n.Version(), err
+}
@@ -0,0 +1,46 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/neild/gate"
+)
+
+func TestNotifier(t *testing.T) {
+	n := gate.NewNotifier()
+	v := n.Version()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Millisecond)
+	defer cancel()
+	if got, err := n.Wait(ctx, v); got != v || err != context.DeadlineExceeded {
+		t.Fatalf("n.Wait(%v) = %v, %v; want %v, context.DeadlineExceeded", v, got, err, v)
+	}
+
+	// A notification between reading the version and waiting is not missed.
+	n.Notify()
+	if got, err := n.Wait(ctx, v); got != v+1 || err != nil {
+		t.Fatalf("n.Wait(%v) after Notify = %v, %v; want %v, nil", v, got, err, v+1)
+	}
+
+	// All waiters are woken by a notification.
+	const waiters = 3
+	donec := make(chan uint64)
+	for i := 0; i < waiters; i++ {
+		go func() {
+			got, _ := n.Wait(context.Background(), v+1)
+			donec <- got
+		}()
+	}
+	time.Sleep(1 * time.Millisecond)
+	n.Notify()
+	for i := 0; i < waiters; i++ {
+		if got := <-donec; got != v+2 {
+			t.Errorf("n.Wait(%v) = %v, want %v", v+1, got, v+2)
+		}
+	}
+}
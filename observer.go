@@ -0,0 +1,155 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// An Observer receives measurements from gates and the primitives built on them.
+//
+// Measurements are identified by name, such as "gate.wait" or "semaphore.available".
+// Methods may be called concurrently, and from within a primitive's critical section,
+// so they should be fast and must not call back into the primitive.
+type Observer interface {
+	// Count adds delta to the named counter.
+	Count(name string, delta int64)
+
+	// Gauge sets the named gauge to value.
+	Gauge(name string, value int64)
+
+	// Timing records a duration.
+	Timing(name string, d time.Duration)
+}
+
+type observerHolder struct {
+	o Observer
+}
+
+var defaultObserver atomic.Pointer[observerHolder]
+
+// SetObserver sets the package-wide observer.
+// A nil o disables package-wide observation.
+//
+// Gates report to the package-wide observer:
+//   - "gate.contended" counts Lock and WaitAndLock calls which had to block.
+//   - "gate.wait" is the time each such call blocked.
+//   - "gate.wait.expired" counts WaitAndLock calls which returned because their context expired.
+//
+// Named gates also report each measurement under their own name,
+// such as "stream.42.sendWindow.wait" for a gate named "stream.42.sendWindow",
+// so that a HistogramObserver records their waits separately.
+//
+// A gate or primitive created with WithObserver reports to that observer instead.
+//
+// Primitives such as Semaphore, WeightedSemaphore, and Pool also report
+// measurements of their own, to an observer set on the instance or,
+// if none is set, to the package-wide observer.
+// Primitives that report the time callers spent waiting for them
+// can be given a HistogramObserver to see the distribution of waits of one instance.
+// Gauges are only meaningful when reported by a single instance,
+// so they are reported only to observers set on an instance.
+func SetObserver(o Observer) {
+	setDiagnostic(func() {
+		if o == nil {
+			defaultObserver.Store(nil)
+			return
+		}
+		defaultObserver.Store(&observerHolder{o})
+	})
+}
+
+// packageObserver returns the package-wide observer, or nil if none.
+func packageObserver() Observer {
+	if h := defaultObserver.Load(); h != nil {
+		return h.o
+	}
+	return nil
+}
+
+// An instanceObserver is the observer of one primitive.
+// It is guarded by the primitive's gate.
+type instanceObserver struct {
+	o Observer
+}
+
+// count reports to the instance observer, or the package-wide observer if none.
+func (ob *instanceObserver) count(name string, delta int64) {
+	if o := ob.observer(); o != nil {
+		o.Count(name, delta)
+	}
+}
+
+// timing reports to the instance observer, or the package-wide observer if none.
+func (ob *instanceObserver) timing(name string, d time.Duration) {
+	if o := ob.observer(); o != nil {
+		o.Timing(name, d)
+	}
+}
+
+// gauge reports to the instance observer, if any.
+func (ob *instanceObserver) gauge(name string, value int64) {
+	if ob.o != nil {
+		ob.o.Gauge(name, value)
+	}
+}
+
+func (ob *instanceObserver) observer() Observer {
+	if ob.o != nil {
+		return ob.o
+	}
+	return packageObserver()
+}
+
+// A waitObservation is a blocked Lock or WaitAndLock call being observed.
+type waitObservation struct {
+	o     Observer
+	name  string // gate name, or ""
+	clock Clock
+	start time.Time
+}
+
+// startObserve begins observing a blocked call on the gate,
+// if it has an observer or there is a package-wide observer.
+func (g *Gate) startObserve() waitObservation {
+	var o Observer
+	if g.h != nil && g.h.obs != nil {
+		o = g.h.obs
+	} else {
+		o = packageObserver()
+	}
+	if o == nil {
+		return waitObservation{}
+	}
+	w := waitObservation{o: o, name: g.Name(), clock: g.Clock()}
+	w.count("gate.contended", ".contended")
+	w.start = w.clock.Now()
+	return w
+}
+
+// end reports the end of a blocked call, which returned err.
+func (w waitObservation) end(err error) {
+	if w.o == nil {
+		return
+	}
+	d := w.clock.Now().Sub(w.start)
+	w.o.Timing("gate.wait", d)
+	if w.name != "" {
+		w.o.Timing(w.name+".wait", d)
+	}
+	if err != nil {
+		w.count("gate.wait.expired", ".wait.expired")
+	}
+}
+
+// count increments a gate counter,
+// and the counter of the same suffix under the gate's name if it is named.
+func (w waitObservation) count(name, suffix string) {
+	w.o.Count(name, 1)
+	if w.name != "" {
+		w.o.Count(w.name+suffix, 1)
+	}
+}
@@ -0,0 +1,190 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"testing/synctest"
+	"time"
+
+	"github.com/neild/gate"
+)
+
+type testObserver struct {
+	mu      sync.Mutex
+	counts  map[string]int64
+	gauges  map[string]int64
+	timings map[string][]time.Duration
+}
+
+func newTestObserver() *testObserver {
+	return &testObserver{
+		counts:  map[string]int64{},
+		gauges:  map[string]int64{},
+		timings: map[string][]time.Duration{},
+	}
+}
+
+func (o *testObserver) Count(name string, delta int64) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.counts[name] += delta
+}
+
+func (o *testObserver) Gauge(name string, value int64) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.gauges[name] = value
+}
+
+func (o *testObserver) Timing(name string, d time.Duration) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.timings[name] = append(o.timings[name], d)
+}
+
+func TestObserverGate(t *testing.T) {
+	o := newTestObserver()
+	gate.SetObserver(o)
+	defer gate.SetObserver(nil)
+
+	g := gate.New(false)
+	g.Lock()
+	g.Unlock(false)
+	if got := o.counts["gate.contended"]; got != 0 {
+		t.Fatalf("gate.contended = %v after uncontended Lock, want 0", got)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Millisecond)
+	defer cancel()
+	if err := g.WaitAndLock(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("g.WaitAndLock = %v, want context.DeadlineExceeded", err)
+	}
+	if got := o.counts["gate.contended"]; got != 1 {
+		t.Errorf("gate.contended = %v, want 1", got)
+	}
+	if got := o.counts["gate.wait.expired"]; got != 1 {
+		t.Errorf("gate.wait.expired = %v, want 1", got)
+	}
+	if got := o.timings["gate.wait"]; len(got) != 1 || got[0] < 1*time.Millisecond {
+		t.Errorf("gate.wait timings = %v, want one of at least 1ms", got)
+	}
+}
+
+func TestObserverSemaphore(t *testing.T) {
+	global := newTestObserver()
+	gate.SetObserver(global)
+	defer gate.SetObserver(nil)
+
+	s := gate.NewSemaphore(2)
+	s.Acquire(context.Background())
+	if got := global.counts["semaphore.acquired"]; got != 1 {
+		t.Errorf("package-wide semaphore.acquired = %v, want 1", got)
+	}
+	if _, ok := global.gauges["semaphore.available"]; ok {
+		t.Errorf("semaphore.available reported to package-wide observer")
+	}
+
+	o := newTestObserver()
+	s.SetObserver(o)
+	s.TryAcquire()
+	s.Release()
+	if got := o.counts["semaphore.acquired"]; got != 1 {
+		t.Errorf("semaphore.acquired = %v, want 1", got)
+	}
+	if got := o.counts["semaphore.released"]; got != 1 {
+		t.Errorf("semaphore.released = %v, want 1", got)
+	}
+	if got := o.gauges["semaphore.available"]; got != 1 {
+		t.Errorf("semaphore.available = %v, want 1", got)
+	}
+	if got := global.counts["semaphore.acquired"]; got != 1 {
+		t.Errorf("package-wide semaphore.acquired = %v after setting instance observer, want 1", got)
+	}
+}
+
+func TestObserverGateCreatedBeforeObserver(t *testing.T) {
+	g := gate.New(false)
+	o := newTestObserver()
+	gate.SetObserver(o)
+	defer gate.SetObserver(nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Millisecond)
+	defer cancel()
+	if err := g.WaitAndLock(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("g.WaitAndLock = %v, want context.DeadlineExceeded", err)
+	}
+	if got := o.counts["gate.contended"]; got != 1 {
+		t.Errorf("gate.contended = %v for gate created before SetObserver, want 1", got)
+	}
+}
+
+func TestObserverOption(t *testing.T) {
+	global := newTestObserver()
+	gate.SetObserver(global)
+	defer gate.SetObserver(nil)
+
+	o := newTestObserver()
+	g := gate.New(false, gate.WithObserver(o), gate.WithName("g"))
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Millisecond)
+	defer cancel()
+	if err := g.WaitAndLock(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("g.WaitAndLock = %v, want context.DeadlineExceeded", err)
+	}
+	if got := o.counts["gate.contended"]; got != 1 {
+		t.Errorf("gate.contended = %v, want 1", got)
+	}
+	if got := o.counts["g.wait.expired"]; got != 1 {
+		t.Errorf("g.wait.expired = %v, want 1", got)
+	}
+	if got := global.counts["gate.contended"]; got != 0 {
+		t.Errorf("package-wide gate.contended = %v for gate with observer, want 0", got)
+	}
+
+	s := gate.NewSemaphore(1, gate.WithObserver(o))
+	s.Acquire(context.Background())
+	if got := o.counts["semaphore.acquired"]; got != 1 {
+		t.Errorf("semaphore.acquired = %v, want 1", got)
+	}
+	if got := o.gauges["semaphore.available"]; got != 0 {
+		t.Errorf("semaphore.available = %v, want 0", got)
+	}
+	if got := global.counts["semaphore.acquired"]; got != 0 {
+		t.Errorf("package-wide semaphore.acquired = %v for semaphore with observer, want 0", got)
+	}
+}
+
+func TestObserverClock(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		o := newTestObserver()
+		c := gate.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+		s := gate.NewSemaphore(1, gate.WithObserver(o), gate.WithClock(c))
+		w := gate.NewWeightedSemaphore(1, gate.WithObserver(o), gate.WithClock(c))
+		s.Acquire(context.Background())
+		w.Acquire(context.Background(), 1)
+		done := make(chan struct{})
+		go func() {
+			s.Acquire(context.Background())
+			done <- struct{}{}
+		}()
+		go func() {
+			w.Acquire(context.Background(), 1)
+			done <- struct{}{}
+		}()
+		synctest.Wait()
+		c.Advance(5 * time.Second)
+		s.Release()
+		w.Release(1)
+		<-done
+		<-done
+		for _, name := range []string{"semaphore.wait", "weightedsemaphore.wait", "gate.wait"} {
+			if got := o.timings[name]; len(got) != 1 || got[0] != 5*time.Second {
+				t.Errorf("%v timings = %v, want [5s]", name, got)
+			}
+		}
+	})
+}
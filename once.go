@@ -0,0 +1,71 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate
+
+import (
+	"context"
+	"errors"
+)
+
+var errOncePanicked = errors.New("gate: Once function panicked")
+
+// A Once performs an initialization which may fail.
+//
+// Unlike sync.Once, a failed initialization may be retried,
+// and callers waiting for an initialization in progress may give up
+// when their context expires.
+type Once struct {
+	mu   Gate // guards the fields below
+	done bool
+	call *Event // set when the initialization in progress finishes
+}
+
+// NewOnce returns a new Once.
+func NewOnce() *Once {
+	return &Once{
+		mu: New(false),
+	}
+}
+
+// Do calls fn if no call to fn has yet succeeded and no call is in progress,
+// and returns its result.
+//
+// If another call to fn is in progress, Do waits for it to finish
+// and returns its result, or returns the context's error if the context expires first.
+// A caller which gives up waiting does not affect the call in progress.
+//
+// Once a call to fn succeeds, Do returns nil without calling fn.
+func (o *Once) Do(ctx context.Context, fn func() error) (err error) {
+	o.mu.Lock()
+	if o.done {
+		o.mu.Unlock(false)
+		return nil
+	}
+	if c := o.call; c != nil {
+		o.mu.Unlock(false)
+		return c.Wait(ctx)
+	}
+	c := NewEvent()
+	o.call = c
+	o.mu.Unlock(false)
+
+	err = errOncePanicked
+	defer func() {
+		o.mu.Lock()
+		o.call = nil
+		o.done = err == nil
+		o.mu.Unlock(false)
+		c.SetError(err)
+	}()
+	err = fn()
+	return err
+}
+
+// Done reports whether a call to fn has succeeded.
+func (o *Once) Done() bool {
+	o.mu.Lock()
+	defer o.mu.Unlock(false)
+	return o.done
+}
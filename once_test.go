@@ -0,0 +1,84 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/neild/gate"
+)
+
+func TestOnceRetryAfterFailure(t *testing.T) {
+	o := gate.NewOnce()
+	wantErr := errors.New("failed")
+	calls := 0
+	if err := o.Do(context.Background(), func() error {
+		calls++
+		return wantErr
+	}); err != wantErr {
+		t.Fatalf("o.Do = %v, want %v", err, wantErr)
+	}
+	if done := o.Done(); done {
+		t.Fatalf("o.Done after failure = %v, want false", done)
+	}
+	for i := 0; i < 2; i++ {
+		if err := o.Do(context.Background(), func() error {
+			calls++
+			return nil
+		}); err != nil {
+			t.Fatalf("o.Do = %v, want nil", err)
+		}
+	}
+	if calls != 2 {
+		t.Fatalf("fn called %v times, want 2", calls)
+	}
+	if done := o.Done(); !done {
+		t.Fatalf("o.Done after success = %v, want true", done)
+	}
+}
+
+func TestOnceWaiterCanceled(t *testing.T) {
+	o := gate.NewOnce()
+	startedc := make(chan struct{})
+	releasec := make(chan struct{})
+	donec := make(chan error)
+	go func() {
+		donec <- o.Do(context.Background(), func() error {
+			close(startedc)
+			<-releasec
+			return nil
+		})
+	}()
+	<-startedc
+
+	// A duplicate caller gives up without affecting the call in progress.
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Millisecond)
+	defer cancel()
+	if err := o.Do(ctx, func() error {
+		t.Errorf("fn called while another call is in progress")
+		return nil
+	}); err != context.DeadlineExceeded {
+		t.Fatalf("o.Do with expiring context = %v, want context.DeadlineExceeded", err)
+	}
+
+	waitc := make(chan error)
+	go func() {
+		waitc <- o.Do(context.Background(), func() error {
+			t.Errorf("fn called while another call is in progress")
+			return nil
+		})
+	}()
+	time.Sleep(1 * time.Millisecond)
+	close(releasec)
+	if err := <-donec; err != nil {
+		t.Fatalf("o.Do = %v, want nil", err)
+	}
+	if err := <-waitc; err != nil {
+		t.Fatalf("o.Do waiting for call in progress = %v, want nil", err)
+	}
+}
@@ -0,0 +1,61 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+)
+
+// ParallelForEach calls fn for each item, running at most limit calls at once.
+// It panics if limit is not positive.
+//
+// Calls continue when others fail, and ParallelForEach returns all their errors joined.
+// When ctx is done, no more calls are started and ctx's error is included in the result,
+// but ParallelForEach still waits for calls in progress to return.
+// It starts at most limit goroutines.
+func ParallelForEach[T any](ctx context.Context, items []T, limit int, fn func(context.Context, T) error) error {
+	_, err := ParallelMap(ctx, items, limit, func(ctx context.Context, v T) (struct{}, error) {
+		return struct{}{}, fn(ctx, v)
+	})
+	return err
+}
+
+// ParallelMap calls fn for each item, running at most limit calls at once,
+// and returns the results in the order of the items.
+// Results for items whose call failed or was never started are the zero value.
+//
+// Errors and cancellation are handled as by ParallelForEach.
+func ParallelMap[T, U any](ctx context.Context, items []T, limit int, fn func(context.Context, T) (U, error)) ([]U, error) {
+	if limit <= 0 {
+		panic("gate: parallel limit must be positive")
+	}
+	var (
+		results = make([]U, len(items))
+		errs    = make([]error, len(items))
+		next    atomic.Int64 // index of the next item to start
+		wg      = NewWaitGroup()
+	)
+	for range min(limit, len(items)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ctx.Err() == nil {
+				i := int(next.Add(1) - 1)
+				if i >= len(items) {
+					return
+				}
+				results[i], errs[i] = fn(ctx, items[i])
+			}
+		}()
+	}
+	wg.Wait(context.Background())
+	if int(next.Load()) < len(items) {
+		// Some items were never started.
+		errs = append(errs, ctx.Err())
+	}
+	return results, errors.Join(errs...)
+}
@@ -0,0 +1,86 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/neild/gate"
+)
+
+func TestParallelMap(t *testing.T) {
+	items := []int{1, 2, 3, 4, 5, 6, 7, 8}
+	var active, maxActive atomic.Int32
+	got, err := gate.ParallelMap(context.Background(), items, 3, func(ctx context.Context, v int) (int, error) {
+		n := active.Add(1)
+		defer active.Add(-1)
+		for {
+			m := maxActive.Load()
+			if n <= m || maxActive.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		time.Sleep(100 * time.Microsecond)
+		return v * 10, nil
+	})
+	if err != nil {
+		t.Fatalf("ParallelMap = %v, want nil", err)
+	}
+	if want := []int{10, 20, 30, 40, 50, 60, 70, 80}; !slices.Equal(got, want) {
+		t.Fatalf("ParallelMap = %v, want %v", got, want)
+	}
+	if got := maxActive.Load(); got > 3 {
+		t.Fatalf("%v calls ran at once, want at most 3", got)
+	}
+}
+
+func TestParallelForEachErrors(t *testing.T) {
+	errOdd := errors.New("odd")
+	var calls atomic.Int32
+	err := gate.ParallelForEach(context.Background(), []int{1, 2, 3, 4}, 2, func(ctx context.Context, v int) error {
+		calls.Add(1)
+		if v%2 == 1 {
+			return errOdd
+		}
+		return nil
+	})
+	if !errors.Is(err, errOdd) {
+		t.Fatalf("ParallelForEach = %v, want error wrapping %v", err, errOdd)
+	}
+	// Failures do not stop other calls.
+	if got, want := calls.Load(), int32(4); got != want {
+		t.Fatalf("ParallelForEach made %v calls, want %v", got, want)
+	}
+}
+
+func TestParallelForEachCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var calls, running atomic.Int32
+	items := make([]int, 100)
+	err := gate.ParallelForEach(ctx, items, 2, func(ctx context.Context, v int) error {
+		running.Add(1)
+		defer running.Add(-1)
+		if calls.Add(1) == 3 {
+			cancel()
+		}
+		time.Sleep(100 * time.Microsecond)
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("ParallelForEach = %v, want error wrapping context.Canceled", err)
+	}
+	if got := calls.Load(); got >= int32(len(items)) {
+		t.Fatalf("ParallelForEach made %v calls after cancellation, want fewer than %v", got, len(items))
+	}
+	// Calls in progress finished before ParallelForEach returned.
+	if got := running.Load(); got != 0 {
+		t.Fatalf("%v calls still running after ParallelForEach returned", got)
+	}
+}
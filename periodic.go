@@ -0,0 +1,113 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate
+
+import (
+	"context"
+	"time"
+)
+
+// A Periodic runs a function repeatedly, with a fixed interval between runs.
+// Callers may wait for a run to complete, and may request an immediate run.
+type Periodic struct {
+	mu       Gate   // guards the fields below
+	running  *Event // set when the run in progress completes, or nil if none
+	next     *Event // set when the next run to start completes
+	triggerc chan struct{}
+	cancel   context.CancelFunc
+	exited   *Event // set when the run loop exits
+}
+
+// NewPeriodic returns a Periodic which calls fn immediately,
+// and then again after each interval following the completion of the previous call.
+// The context passed to fn is canceled when Stop is called.
+func NewPeriodic(interval time.Duration, fn func(context.Context) error, opts ...Option) *Periodic {
+	o := newOptions(opts)
+	ctx, cancel := context.WithCancel(context.Background())
+	p := &Periodic{
+		mu:       o.newGate(false, ""),
+		next:     NewEvent(),
+		triggerc: make(chan struct{}, 1),
+		cancel:   cancel,
+		exited:   NewEvent(),
+	}
+	p.triggerc <- struct{}{}
+	go p.loop(ctx, o.clock.NewTimer(interval), interval, fn)
+	return p
+}
+
+// Trigger requests an immediate run.
+// If a run is in progress, another starts as soon as it completes.
+func (p *Periodic) Trigger() {
+	select {
+	case p.triggerc <- struct{}{}:
+	default:
+	}
+}
+
+// Wait blocks until the next run completes or the context expires.
+// If a run is in progress, Wait waits for it.
+// It returns the error returned by the function.
+func (p *Periodic) Wait(ctx context.Context) error {
+	p.mu.Lock()
+	ev := p.running
+	if ev == nil {
+		ev = p.next
+	}
+	p.mu.Unlock(false)
+	return ev.Wait(ctx)
+}
+
+// Run requests an immediate run and waits for it to complete.
+// Unlike Wait, it does not return the result of a run which was already in progress.
+func (p *Periodic) Run(ctx context.Context) error {
+	p.mu.Lock()
+	ev := p.next
+	p.mu.Unlock(false)
+	p.Trigger()
+	return ev.Wait(ctx)
+}
+
+// Stop stops the Periodic and waits for any run in progress to complete.
+// Callers waiting for a run which will never start receive ErrStopped.
+func (p *Periodic) Stop() {
+	p.cancel()
+	p.exited.Wait(context.Background())
+}
+
+func (p *Periodic) loop(ctx context.Context, t Timer, interval time.Duration, fn func(context.Context) error) {
+	defer p.exited.Set()
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C():
+		case <-p.triggerc:
+			if !t.Stop() {
+				select {
+				case <-t.C():
+				default:
+				}
+			}
+		case <-ctx.Done():
+			p.mu.Lock()
+			p.next.SetError(ErrStopped)
+			p.mu.Unlock(false)
+			return
+		}
+		p.mu.Lock()
+		ev := p.next
+		p.running = ev
+		p.next = NewEvent()
+		p.mu.Unlock(false)
+
+		err := fn(ctx)
+
+		p.mu.Lock()
+		p.running = nil
+		p.mu.Unlock(false)
+		ev.SetError(err)
+		t.Reset(interval)
+	}
+}
@@ -0,0 +1,71 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/neild/gate"
+)
+
+func TestPeriodic(t *testing.T) {
+	var runs atomic.Int32
+	p := gate.NewPeriodic(1*time.Millisecond, func(context.Context) error {
+		runs.Add(1)
+		return nil
+	})
+	defer p.Stop()
+	for i := 0; i < 3; i++ {
+		if err := p.Wait(context.Background()); err != nil {
+			t.Fatalf("p.Wait = %v, want nil", err)
+		}
+	}
+	if got := runs.Load(); got < 3 {
+		t.Fatalf("after three waits, %v runs completed, want at least 3", got)
+	}
+}
+
+func TestPeriodicRun(t *testing.T) {
+	var runs atomic.Int32
+	wantErr := errors.New("refresh failed")
+	p := gate.NewPeriodic(1*time.Hour, func(context.Context) error {
+		if runs.Add(1) > 1 {
+			return wantErr
+		}
+		return nil
+	})
+	defer p.Stop()
+	// The first run happens immediately.
+	if err := p.Wait(context.Background()); err != nil {
+		t.Fatalf("p.Wait for first run = %v, want nil", err)
+	}
+	// Later runs happen on demand.
+	if err := p.Run(context.Background()); err != wantErr {
+		t.Fatalf("p.Run = %v, want %v", err, wantErr)
+	}
+	if got, want := runs.Load(), int32(2); got != want {
+		t.Fatalf("after p.Run, %v runs completed, want %v", got, want)
+	}
+}
+
+func TestPeriodicStop(t *testing.T) {
+	p := gate.NewPeriodic(1*time.Hour, func(context.Context) error {
+		return nil
+	})
+	p.Wait(context.Background())
+	donec := make(chan error)
+	go func() {
+		donec <- p.Wait(context.Background())
+	}()
+	time.Sleep(1 * time.Millisecond)
+	p.Stop()
+	if err := <-donec; err != gate.ErrStopped {
+		t.Fatalf("p.Wait after Stop = %v, want %v", err, gate.ErrStopped)
+	}
+}
@@ -0,0 +1,99 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate
+
+import "context"
+
+// A Phaser is a reusable barrier with a dynamic number of parties.
+//
+// Parties register and deregister at any time.
+// The phaser advances to the next phase when every registered party
+// has arrived at the current one.
+type Phaser struct {
+	mu      Gate // guards the fields below
+	parties int
+	arrived int
+	phase   uint64
+	advance *Event // set when the current phase ends
+}
+
+// NewPhaser returns a new phaser with n registered parties, at phase 0.
+func NewPhaser(n int) *Phaser {
+	return &Phaser{
+		mu:      New(false),
+		parties: n,
+		advance: NewEvent(),
+	}
+}
+
+// Register adds a party to the phaser.
+// It returns the current phase.
+func (p *Phaser) Register() (phase uint64) {
+	p.mu.Lock()
+	defer p.mu.Unlock(false)
+	p.parties++
+	return p.phase
+}
+
+// Deregister removes a party which has not arrived at the current phase.
+// If all remaining parties have already arrived, the phaser advances.
+// It panics if there are no registered parties.
+func (p *Phaser) Deregister() {
+	p.mu.Lock()
+	defer p.mu.Unlock(false)
+	if p.parties == 0 {
+		panic("gate: Phaser.Deregister with no registered parties")
+	}
+	p.parties--
+	if p.arrived > 0 && p.arrived >= p.parties {
+		p.next()
+	}
+}
+
+// Wait records the arrival of a party at the current phase,
+// and blocks until all registered parties have arrived or the context expires.
+// It returns the new phase.
+//
+// If the context expires before the phase ends, the party's arrival is withdrawn
+// and Wait returns the current phase and the context's error.
+func (p *Phaser) Wait(ctx context.Context) (phase uint64, err error) {
+	p.mu.Lock()
+	p.arrived++
+	if p.arrived >= p.parties {
+		p.next()
+		phase = p.phase
+		p.mu.Unlock(false)
+		return phase, nil
+	}
+	advance, phase := p.advance, p.phase
+	p.mu.Unlock(false)
+
+	if err := advance.Wait(ctx); err == nil {
+		return phase + 1, nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock(false)
+	if advance.IsSet() {
+		// The phase ended before we could withdraw.
+		return phase + 1, nil
+	}
+	p.arrived--
+	return phase, ctx.Err()
+}
+
+// Phase returns the current phase.
+func (p *Phaser) Phase() uint64 {
+	p.mu.Lock()
+	defer p.mu.Unlock(false)
+	return p.phase
+}
+
+// next advances to the next phase.
+func (p *Phaser) next() {
+	p.phase++
+	p.arrived = 0
+	p.advance.Set()
+	p.advance = NewEvent()
+}
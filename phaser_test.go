@@ -0,0 +1,70 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/neild/gate"
+)
+
+func TestPhaserAdvance(t *testing.T) {
+	p := gate.NewPhaser(2)
+	waitc := make(chan uint64)
+	go func() {
+		phase, _ := p.Wait(context.Background())
+		waitc <- phase
+	}()
+	time.Sleep(1 * time.Millisecond)
+	if phase, err := p.Wait(context.Background()); phase != 1 || err != nil {
+		t.Fatalf("p.Wait = %v, %v; want 1, nil", phase, err)
+	}
+	if phase := <-waitc; phase != 1 {
+		t.Fatalf("p.Wait in other party = %v, want 1", phase)
+	}
+}
+
+func TestPhaserDynamicRegistration(t *testing.T) {
+	p := gate.NewPhaser(1)
+	if phase := p.Register(); phase != 0 {
+		t.Fatalf("p.Register = %v, want 0", phase)
+	}
+	waitc := make(chan uint64)
+	go func() {
+		phase, _ := p.Wait(context.Background())
+		waitc <- phase
+	}()
+	time.Sleep(1 * time.Millisecond)
+	select {
+	case <-waitc:
+		t.Fatalf("phaser advanced with one of two parties arrived")
+	default:
+	}
+	// Deregistering the party which has not arrived advances the phaser.
+	p.Deregister()
+	if phase := <-waitc; phase != 1 {
+		t.Fatalf("p.Wait = %v, want 1", phase)
+	}
+	if phase := p.Phase(); phase != 1 {
+		t.Fatalf("p.Phase = %v, want 1", phase)
+	}
+}
+
+func TestPhaserWaitCanceled(t *testing.T) {
+	p := gate.NewPhaser(2)
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Millisecond)
+	defer cancel()
+	if phase, err := p.Wait(ctx); phase != 0 || err != context.DeadlineExceeded {
+		t.Fatalf("p.Wait = %v, %v; want 0, context.DeadlineExceeded", phase, err)
+	}
+	// The canceled arrival was withdrawn, so one arrival does not advance the phaser.
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Millisecond)
+	defer cancel()
+	if phase, err := p.Wait(ctx); phase != 0 || err != context.DeadlineExceeded {
+		t.Fatalf("p.Wait = %v, %v; want 0, context.DeadlineExceeded", phase, err)
+	}
+}
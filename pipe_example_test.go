@@ -0,0 +1,107 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// Pipe moves items from src to dst until ctx is done or one of the queues is closed.
+// Each item is passed through fn, which returns the item to put in dst
+// and whether to keep it at all.
+//
+// When src is closed, Pipe closes dst with the same error.
+// When dst is closed, Pipe closes src with dst's error,
+// without waiting for another item to arrive in src.
+// When fn returns an error, Pipe closes both queues with it.
+// Pipe returns the error which ended it.
+func Pipe[T, U any](ctx context.Context, src *Queue[T], dst *Queue[U], fn func(T) (U, bool, error)) error {
+	// Stop waiting for items as soon as dst is closed.
+	getCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-dst.Done():
+			cancel()
+		case <-getCtx.Done():
+		}
+	}()
+	for {
+		v, err := src.Get(getCtx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return err
+			}
+			if derr := dst.Err(); derr != nil {
+				src.Close(derr)
+				return derr
+			}
+			dst.Close(err)
+			return err
+		}
+		u, keep, err := fn(v)
+		if err != nil {
+			src.Close(err)
+			dst.Close(err)
+			return err
+		}
+		if !keep {
+			continue
+		}
+		if !dst.Put(u) {
+			err := dst.Err()
+			src.Close(err)
+			return err
+		}
+	}
+}
+
+func Example_pipe() {
+	src := NewQueue[string]()
+	dst := NewQueue[int]()
+	go Pipe(context.Background(), src, dst, func(s string) (int, bool, error) {
+		// Parse numbers, dropping anything that isn't one.
+		n, err := strconv.Atoi(s)
+		return n, err == nil, nil
+	})
+
+	src.Put("1")
+	src.Put("two")
+	src.Put("3")
+	fmt.Println(dst.Get(context.Background()))
+	fmt.Println(dst.Get(context.Background()))
+	// Closing the source closes the destination.
+	src.Close(io.EOF)
+	fmt.Println(dst.Get(context.Background()))
+	// Output:
+	// 1 <nil>
+	// 3 <nil>
+	// 0 EOF
+}
+
+func Example_pipeCloseDst() {
+	src := NewQueue[int]()
+	dst := NewQueue[int]()
+	done := make(chan error)
+	go func() {
+		done <- Pipe(context.Background(), src, dst, func(v int) (int, bool, error) {
+			return v, true, nil
+		})
+	}()
+
+	// Closing the destination closes the source,
+	// even though no item is waiting to be moved.
+	dst.Close(errors.New("consumer gone"))
+	fmt.Println(<-done)
+	<-src.Done()
+	fmt.Println(src.Err())
+	// Output:
+	// consumer gone
+	// consumer gone
+}
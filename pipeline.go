@@ -0,0 +1,201 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate
+
+import (
+	"context"
+	"slices"
+)
+
+// A Pipeline is a set of stages connected by bounded queues.
+//
+// Each stage runs on one or more goroutines, reading items from the previous stage
+// and writing results to the next. When any stage returns an error,
+// the pipeline's context is canceled, tearing down every stage.
+type Pipeline struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     *WaitGroup // running stage goroutines
+	o      options    // for the gates of streams
+
+	mu  Gate // guards err
+	err error
+}
+
+// A Stream is the output of a pipeline stage.
+type Stream[T any] struct {
+	q *pipeQueue[T]
+}
+
+// NewPipeline returns a new, empty pipeline.
+// The pipeline's stages run with a context derived from ctx.
+// The options a pipeline accepts are WithName, WithObserver, and WithClock;
+// the gates of the streams between stages are named "<name>.stream".
+func NewPipeline(ctx context.Context, opts ...Option) *Pipeline {
+	ctx, cancel := context.WithCancel(ctx)
+	o := newOptions(opts)
+	return &Pipeline{
+		ctx:    ctx,
+		cancel: cancel,
+		wg:     NewWaitGroup(),
+		o:      o,
+		mu:     o.newGate(false, ""),
+	}
+}
+
+// Source adds a stage with no input to the pipeline.
+// The function emits items to the stage's output stream, which buffers up to buffer items.
+// Emit blocks while the buffer is full, and returns an error if the pipeline is torn down.
+func Source[T any](p *Pipeline, buffer int, fn func(ctx context.Context, emit func(T) error) error) *Stream[T] {
+	out := &Stream[T]{q: newPipeQueue[T](p, buffer, 1)}
+	p.goStage(func() error {
+		defer out.q.done()
+		return fn(p.ctx, func(v T) error {
+			return out.q.put(p.ctx, v)
+		})
+	})
+	return out
+}
+
+// Stage adds a stage to the pipeline which transforms items from in
+// using the given number of workers.
+// The stage's output stream buffers up to buffer items.
+// When workers is greater than one, items may be reordered.
+func Stage[T, U any](p *Pipeline, in *Stream[T], workers, buffer int, fn func(context.Context, T) (U, error)) *Stream[U] {
+	out := &Stream[U]{q: newPipeQueue[U](p, buffer, workers)}
+	for range workers {
+		p.goStage(func() error {
+			defer out.q.done()
+			for {
+				v, ok, err := in.q.get(p.ctx)
+				if err != nil || !ok {
+					return err
+				}
+				u, err := fn(p.ctx, v)
+				if err != nil {
+					return err
+				}
+				if err := out.q.put(p.ctx, u); err != nil {
+					return err
+				}
+			}
+		})
+	}
+	return out
+}
+
+// Sink adds a final stage to the pipeline which consumes items from in
+// using the given number of workers.
+func Sink[T any](p *Pipeline, in *Stream[T], workers int, fn func(context.Context, T) error) {
+	for range workers {
+		p.goStage(func() error {
+			for {
+				v, ok, err := in.q.get(p.ctx)
+				if err != nil || !ok {
+					return err
+				}
+				if err := fn(p.ctx, v); err != nil {
+					return err
+				}
+			}
+		})
+	}
+}
+
+// Wait blocks until every stage has finished or the context expires.
+// It returns the first error returned by any stage,
+// or the error of the context the pipeline was created with.
+func (p *Pipeline) Wait(ctx context.Context) error {
+	if err := p.wg.Wait(ctx); err != nil {
+		return err
+	}
+	p.cancel()
+	p.mu.Lock()
+	defer p.mu.Unlock(false)
+	return p.err
+}
+
+func (p *Pipeline) goStage(f func() error) {
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		if err := f(); err != nil {
+			p.mu.Lock()
+			if p.err == nil {
+				p.err = err
+			}
+			p.mu.Unlock(false)
+			p.cancel()
+		}
+	}()
+}
+
+// A pipeQueue is a bounded queue connecting pipeline stages.
+// Unlike an error-closed queue, consumers drain remaining items
+// after all producers are done.
+type pipeQueue[T any] struct {
+	gate      Gate // set if the queue is non-empty or all producers are done
+	max       int
+	q         []T
+	producers int
+	roomc     chan struct{} // closed when an item is removed
+}
+
+func newPipeQueue[T any](p *Pipeline, size, producers int) *pipeQueue[T] {
+	return &pipeQueue[T]{
+		gate:      p.o.newGate(producers == 0, "stream"),
+		max:       max(size, 1),
+		producers: producers,
+	}
+}
+
+func (q *pipeQueue[T]) put(ctx context.Context, v T) error {
+	for {
+		q.gate.Lock()
+		if len(q.q) < q.max {
+			q.q = append(q.q, v)
+			q.unlock()
+			return nil
+		}
+		if q.roomc == nil {
+			q.roomc = make(chan struct{})
+		}
+		roomc := q.roomc
+		q.unlock()
+		if err := q.gate.WaitFor(ctx, roomc); err != nil {
+			return err
+		}
+	}
+}
+
+// get returns the next item from the queue.
+// It returns ok == false when the queue is empty and all producers are done.
+func (q *pipeQueue[T]) get(ctx context.Context) (v T, ok bool, err error) {
+	if err := q.gate.WaitAndLock(ctx); err != nil {
+		return v, false, err
+	}
+	defer q.unlock()
+	if len(q.q) == 0 {
+		return v, false, nil
+	}
+	v = q.q[0]
+	q.q = slices.Delete(q.q, 0, 1)
+	if q.roomc != nil {
+		close(q.roomc)
+		q.roomc = nil
+	}
+	return v, true, nil
+}
+
+// done records that a producer has finished.
+func (q *pipeQueue[T]) done() {
+	q.gate.Lock()
+	defer q.unlock()
+	q.producers--
+}
+
+func (q *pipeQueue[T]) unlock() {
+	q.gate.Unlock(len(q.q) > 0 || q.producers == 0)
+}
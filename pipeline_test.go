@@ -0,0 +1,71 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/neild/gate"
+)
+
+func TestPipeline(t *testing.T) {
+	p := gate.NewPipeline(context.Background())
+	nums := gate.Source(p, 1, func(ctx context.Context, emit func(int) error) error {
+		for i := 0; i < 10; i++ {
+			if err := emit(i); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	strs := gate.Stage(p, nums, 3, 1, func(ctx context.Context, v int) (string, error) {
+		return strconv.Itoa(v * v), nil
+	})
+	var (
+		mu  sync.Mutex
+		got []string
+	)
+	gate.Sink(p, strs, 2, func(ctx context.Context, s string) error {
+		mu.Lock()
+		defer mu.Unlock()
+		got = append(got, s)
+		return nil
+	})
+	if err := p.Wait(context.Background()); err != nil {
+		t.Fatalf("p.Wait = %v, want nil", err)
+	}
+	slices.Sort(got)
+	want := []string{"0", "1", "16", "25", "36", "4", "49", "64", "81", "9"}
+	if !slices.Equal(got, want) {
+		t.Fatalf("sink received %v, want %v", got, want)
+	}
+}
+
+func TestPipelineErrorTearsDown(t *testing.T) {
+	p := gate.NewPipeline(context.Background())
+	wantErr := errors.New("bad item")
+	// The source produces items forever, until the pipeline is torn down.
+	nums := gate.Source(p, 1, func(ctx context.Context, emit func(int) error) error {
+		for i := 0; ; i++ {
+			if err := emit(i); err != nil {
+				return err
+			}
+		}
+	})
+	gate.Sink(p, nums, 1, func(ctx context.Context, v int) error {
+		if v == 5 {
+			return wantErr
+		}
+		return nil
+	})
+	if err := p.Wait(context.Background()); err != wantErr {
+		t.Fatalf("p.Wait = %v, want %v", err, wantErr)
+	}
+}
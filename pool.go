@@ -0,0 +1,140 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate
+
+import (
+	"context"
+	"errors"
+	"slices"
+)
+
+// ErrPoolClosed is returned by Pool.Get after the pool is closed.
+var ErrPoolClosed = errors.New("gate: pool closed")
+
+// A Pool is a bounded pool of reusable resources.
+//
+// Unlike sync.Pool, a Pool limits the number of resources in existence:
+// Get blocks when every resource is checked out.
+type Pool[T any] struct {
+	gate    Gate // set if a resource is idle, a new one may be created, or the pool is closed
+	max     int
+	new     func(context.Context) (T, error)
+	destroy func(T)
+	idle    []T
+	total   int // idle and checked out resources
+	closed  bool
+	drained *Event // set when the pool is closed and all resources are destroyed
+	obs     instanceObserver
+}
+
+// NewPool returns a new pool of at most max resources.
+// The new function creates a resource, and the destroy function,
+// which may be nil, disposes of one.
+func NewPool[T any](max int, new func(context.Context) (T, error), destroy func(T)) *Pool[T] {
+	if destroy == nil {
+		destroy = func(T) {}
+	}
+	return &Pool[T]{
+		gate:    New(max > 0),
+		max:     max,
+		new:     new,
+		destroy: destroy,
+		drained: NewEvent(),
+	}
+}
+
+// Get checks a resource out of the pool, creating one if none are idle
+// and the pool is not at capacity.
+// It blocks until a resource is available or the context expires.
+// The caller must return the resource with Put or Discard.
+func (p *Pool[T]) Get(ctx context.Context) (T, error) {
+	var zero T
+	if err := p.gate.WaitAndLock(ctx); err != nil {
+		return zero, err
+	}
+	if p.closed {
+		p.unlock()
+		return zero, ErrPoolClosed
+	}
+	if n := len(p.idle); n > 0 {
+		v := p.idle[n-1]
+		p.idle = slices.Delete(p.idle, n-1, n)
+		p.unlock()
+		return v, nil
+	}
+	p.total++
+	p.obs.count("pool.created", 1)
+	p.unlock()
+	v, err := p.new(ctx)
+	if err != nil {
+		p.gate.Lock()
+		p.remove()
+		return zero, err
+	}
+	return v, nil
+}
+
+// Put returns a resource to the pool.
+// If the pool is closed, the resource is destroyed.
+func (p *Pool[T]) Put(v T) {
+	p.gate.Lock()
+	if p.closed {
+		p.unlock()
+		p.Discard(v)
+		return
+	}
+	p.idle = append(p.idle, v)
+	p.unlock()
+}
+
+// Discard destroys a checked out resource instead of returning it to the pool,
+// making room for a new one.
+func (p *Pool[T]) Discard(v T) {
+	p.destroy(v)
+	p.gate.Lock()
+	p.remove()
+}
+
+// Close closes the pool and destroys all idle resources.
+// It then waits for all checked out resources to be returned and destroyed,
+// or for the context to expire.
+func (p *Pool[T]) Close(ctx context.Context) error {
+	p.gate.Lock()
+	p.closed = true
+	p.gate.recordClose()
+	idle := p.idle
+	p.idle = nil
+	p.unlock()
+	for _, v := range idle {
+		p.Discard(v)
+	}
+	return p.drained.Wait(ctx)
+}
+
+// remove records the removal of a resource from the pool and unlocks the pool's gate.
+func (p *Pool[T]) remove() {
+	p.total--
+	p.obs.count("pool.removed", 1)
+	p.unlock()
+}
+
+// SetObserver sets the pool's observer.
+// The pool reports the "pool.created" and "pool.removed" counters of resources,
+// and the "pool.idle" and "pool.total" gauges.
+// If no observer is set, counters are reported to the package-wide observer.
+func (p *Pool[T]) SetObserver(o Observer) {
+	p.gate.Lock()
+	defer p.unlock()
+	p.obs.o = o
+}
+
+func (p *Pool[T]) unlock() {
+	p.obs.gauge("pool.idle", int64(len(p.idle)))
+	p.obs.gauge("pool.total", int64(p.total))
+	if p.closed && p.total == 0 {
+		p.drained.Set()
+	}
+	p.gate.Unlock(p.closed || len(p.idle) > 0 || p.total < p.max)
+}
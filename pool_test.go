@@ -0,0 +1,86 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/neild/gate"
+)
+
+type testResource struct {
+	id        int
+	destroyed bool
+}
+
+func newTestPool(max int) *gate.Pool[*testResource] {
+	id := 0
+	return gate.NewPool(max, func(context.Context) (*testResource, error) {
+		id++
+		return &testResource{id: id}, nil
+	}, func(r *testResource) {
+		r.destroyed = true
+	})
+}
+
+func TestPoolReuse(t *testing.T) {
+	p := newTestPool(1)
+	r1, err := p.Get(context.Background())
+	if err != nil {
+		t.Fatalf("p.Get = %v, want nil error", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Millisecond)
+	defer cancel()
+	if _, err := p.Get(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("p.Get of exhausted pool = %v, want context.DeadlineExceeded", err)
+	}
+	p.Put(r1)
+	r2, err := p.Get(context.Background())
+	if err != nil || r2 != r1 {
+		t.Fatalf("p.Get after Put = %v, %v; want reused resource", r2, err)
+	}
+
+	// Discarding a resource makes room for a new one.
+	p.Discard(r2)
+	if !r2.destroyed {
+		t.Fatalf("discarded resource not destroyed")
+	}
+	r3, err := p.Get(context.Background())
+	if err != nil || r3.id != 2 {
+		t.Fatalf("p.Get after Discard = %v, %v; want new resource", r3, err)
+	}
+}
+
+func TestPoolCloseDrains(t *testing.T) {
+	p := newTestPool(2)
+	r1, _ := p.Get(context.Background())
+	r2, _ := p.Get(context.Background())
+	p.Put(r1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Millisecond)
+	defer cancel()
+	if err := p.Close(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("p.Close with resource checked out = %v, want context.DeadlineExceeded", err)
+	}
+	if !r1.destroyed {
+		t.Fatalf("idle resource not destroyed by Close")
+	}
+	if _, err := p.Get(context.Background()); err != gate.ErrPoolClosed {
+		t.Fatalf("p.Get of closed pool = %v, want ErrPoolClosed", err)
+	}
+
+	go func() {
+		time.Sleep(1 * time.Millisecond)
+		p.Put(r2)
+	}()
+	if err := p.Close(context.Background()); err != nil {
+		t.Fatalf("p.Close = %v, want nil", err)
+	}
+	if !r2.destroyed {
+		t.Fatalf("resource returned after Close not destroyed")
+	}
+}
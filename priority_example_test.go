@@ -0,0 +1,142 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate_test
+
+import (
+	"container/heap"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/neild/gate"
+)
+
+// A PriorityQueue is an unbounded queue of items with priorities.
+// Get returns the item with the highest priority.
+//
+// To prevent low-priority items from being starved by a stream of high-priority ones,
+// items age: an item's priority increases by one for every aging interval it spends in the queue.
+type PriorityQueue[T any] struct {
+	gate  gate.Gate // set if queue is non-empty or closed
+	aging time.Duration
+	start time.Time
+	seq   uint64
+	err   error
+	q     priorityHeap[T]
+}
+
+// NewPriorityQueue returns a new queue.
+// If aging is zero, items do not age.
+// The options apply to the queue's gate, as for gate.New;
+// the gate's clock measures the time items spend in the queue.
+func NewPriorityQueue[T any](aging time.Duration, opts ...gate.Option) *PriorityQueue[T] {
+	g := gate.New(false, opts...)
+	return &PriorityQueue[T]{
+		gate:  g,
+		aging: aging,
+		start: g.Clock().Now(),
+	}
+}
+
+// Close closes the queue, causing pending and future pop operations
+// to return immediately with err.
+func (q *PriorityQueue[T]) Close(err error) {
+	q.gate.Lock()
+	defer q.unlock()
+	if q.err == nil {
+		q.err = err
+	}
+}
+
+// Put adds an item to the queue with the given priority.
+// It returns true if the item was added, false if the queue is closed.
+func (q *PriorityQueue[T]) Put(v T, priority int) bool {
+	q.gate.Lock()
+	defer q.unlock()
+	if q.err != nil {
+		return false
+	}
+	// An item's effective priority at time now is
+	//
+	//	priority + (now - enqueued) / aging
+	//
+	// The now term is the same for every item, so ordering by
+	// priority - enqueued/aging gives the same order at any time
+	// and the heap never needs to be rebalanced as items age.
+	key := float64(priority)
+	if q.aging > 0 {
+		key -= float64(q.gate.Clock().Now().Sub(q.start)) / float64(q.aging)
+	}
+	q.seq++
+	heap.Push(&q.q, priorityItem[T]{v: v, key: key, seq: q.seq})
+	return true
+}
+
+// Get removes the item with the highest effective priority from the queue,
+// blocking until ctx is done, an item is available, or the queue is closed.
+// Items with equal priority are returned in the order they were added.
+func (q *PriorityQueue[T]) Get(ctx context.Context) (T, error) {
+	var zero T
+	if err := q.gate.WaitAndLock(ctx); err != nil {
+		return zero, err
+	}
+	defer q.unlock()
+	if q.err != nil {
+		return zero, q.err
+	}
+	return heap.Pop(&q.q).(priorityItem[T]).v, nil
+}
+
+// unlock unlocks the queue's gate,
+// setting the condition to true if the queue is non-empty or closed.
+func (q *PriorityQueue[T]) unlock() {
+	q.gate.Unlock(q.err != nil || len(q.q) > 0)
+}
+
+type priorityItem[T any] struct {
+	v   T
+	key float64
+	seq uint64
+}
+
+// priorityHeap implements heap.Interface.
+type priorityHeap[T any] []priorityItem[T]
+
+func (h priorityHeap[T]) Len() int { return len(h) }
+func (h priorityHeap[T]) Less(i, j int) bool {
+	if h[i].key != h[j].key {
+		return h[i].key > h[j].key
+	}
+	return h[i].seq < h[j].seq
+}
+func (h priorityHeap[T]) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+func (h *priorityHeap[T]) Push(x any)   { *h = append(*h, x.(priorityItem[T])) }
+func (h *priorityHeap[T]) Pop() any {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
+
+func Example_priorityQueue() {
+	// Items gain one priority level per 10ms spent in the queue.
+	clock := gate.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	q := NewPriorityQueue[string](10*time.Millisecond, gate.WithClock(clock))
+
+	q.Put("background", 0)
+	clock.Advance(50 * time.Millisecond)
+	q.Put("urgent", 10)
+	q.Put("normal", 1)
+
+	// The background task has aged to priority 5,
+	// which is higher than the normal task but lower than the urgent one.
+	for range 3 {
+		fmt.Println(q.Get(context.Background()))
+	}
+	// Output:
+	// urgent <nil>
+	// background <nil>
+	// normal <nil>
+}
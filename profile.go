@@ -0,0 +1,77 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate
+
+import (
+	"runtime"
+	"runtime/pprof"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// BlockedProfileName is the name of the pprof profile of goroutines blocked on gates.
+//
+// Each sample in the profile is a goroutine currently blocked in Lock or WaitAndLock,
+// whether directly or within a primitive built on gates.
+// Unlike the runtime's block profile, where blocking on a gate is attributed
+// to a channel operation inside this package, each stack begins
+// at the innermost caller outside this package.
+//
+// The profile is served by net/http/pprof along with the standard profiles,
+// once SetBlockedProfile has enabled it.
+const BlockedProfileName = "github.com/neild/gate.blocked"
+
+var (
+	blockedEnabled     atomic.Bool
+	blockedProfileOnce sync.Once
+	blockedProfile     *pprof.Profile
+)
+
+// SetBlockedProfile enables or disables recording of blocked goroutines
+// in the profile named by BlockedProfileName.
+// Disabling the profile does not remove goroutines which are already recorded
+// until they stop blocking.
+func SetBlockedProfile(on bool) {
+	blockedProfileOnce.Do(func() {
+		blockedProfile = pprof.NewProfile(BlockedProfileName)
+	})
+	setDiagnostic(func() {
+		blockedEnabled.Store(on)
+	})
+}
+
+// A blockedSample is a goroutine recorded in the blocked profile.
+type blockedSample struct {
+	_ byte // distinct samples must have distinct addresses
+}
+
+// startBlocked records the calling goroutine in the blocked profile, if enabled.
+func startBlocked() *blockedSample {
+	if !blockedEnabled.Load() {
+		return nil
+	}
+	var pcs [32]uintptr
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(1, pcs[:])])
+	skip := 0
+	for {
+		f, more := frames.Next()
+		if !strings.HasPrefix(f.Function, "github.com/neild/gate.") || !more {
+			break
+		}
+		skip++
+	}
+	b := &blockedSample{}
+	// Profile.Add's skip of 0 begins the stack at Add itself.
+	blockedProfile.Add(b, skip+1)
+	return b
+}
+
+// end removes the goroutine from the blocked profile.
+func (b *blockedSample) end() {
+	if b != nil {
+		blockedProfile.Remove(b)
+	}
+}
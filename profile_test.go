@@ -0,0 +1,51 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate_test
+
+import (
+	"runtime/pprof"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/neild/gate"
+)
+
+func TestBlockedProfile(t *testing.T) {
+	gate.SetBlockedProfile(true)
+	defer gate.SetBlockedProfile(false)
+	p := pprof.Lookup(gate.BlockedProfileName)
+
+	g := gate.New(false)
+	g.Lock()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		g.Lock()
+		g.Unlock(false)
+	}()
+	for p.Count() == 0 {
+		time.Sleep(1 * time.Millisecond)
+	}
+	var b strings.Builder
+	p.WriteTo(&b, 1)
+	// Each stack is written as lines of "#\t0xPC\tfunction+offset\tfile:line",
+	// beginning with the innermost frame.
+	var first string
+	for _, line := range strings.Split(b.String(), "\n") {
+		if strings.HasPrefix(line, "#\t0x") {
+			first = line
+			break
+		}
+	}
+	if !strings.Contains(first, "TestBlockedProfile") {
+		t.Errorf("innermost frame of blocked profile is %q, want test function\n%v", first, b.String())
+	}
+	g.Unlock(false)
+	<-done
+	if got := p.Count(); got != 0 {
+		t.Errorf("p.Count() = %v after goroutine unblocked, want 0", got)
+	}
+}
@@ -0,0 +1,78 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate
+
+import "context"
+
+// A Progress records the progress of a job, measured in completed units,
+// and allows waiting for the job to reach a given point.
+type Progress struct {
+	mu        Gate // guards the fields below
+	completed int64
+	total     int64
+	changed   chan struct{} // closed when progress is added
+}
+
+// A ProgressSnapshot is the state of a Progress at a point in time.
+type ProgressSnapshot struct {
+	Completed int64 // units completed
+	Total     int64 // total units in the job, or 0 if unknown
+}
+
+// NewProgress returns a new Progress for a job of total units.
+// If total is zero, the size of the job is unknown.
+func NewProgress(total int64) *Progress {
+	return &Progress{
+		mu:    New(false),
+		total: total,
+	}
+}
+
+// Add records n completed units.
+func (p *Progress) Add(n int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock(false)
+	p.completed += n
+	if p.changed != nil {
+		close(p.changed)
+		p.changed = nil
+	}
+}
+
+// SetTotal changes the total number of units in the job.
+func (p *Progress) SetTotal(total int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock(false)
+	p.total = total
+}
+
+// Snapshot returns the current progress.
+func (p *Progress) Snapshot() ProgressSnapshot {
+	p.mu.Lock()
+	defer p.mu.Unlock(false)
+	return ProgressSnapshot{
+		Completed: p.completed,
+		Total:     p.total,
+	}
+}
+
+// Wait blocks until at least target units have been completed or the context expires.
+func (p *Progress) Wait(ctx context.Context, target int64) error {
+	for {
+		p.mu.Lock()
+		if p.completed >= target {
+			p.mu.Unlock(false)
+			return nil
+		}
+		if p.changed == nil {
+			p.changed = make(chan struct{})
+		}
+		changed := p.changed
+		p.mu.Unlock(false)
+		if err := p.mu.WaitFor(ctx, changed); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,37 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/neild/gate"
+)
+
+func TestProgress(t *testing.T) {
+	p := gate.NewProgress(100)
+	donec := make(chan error)
+	go func() {
+		donec <- p.Wait(context.Background(), 50)
+	}()
+	for i := 0; i < 5; i++ {
+		time.Sleep(1 * time.Millisecond)
+		p.Add(10)
+	}
+	if err := <-donec; err != nil {
+		t.Fatalf("p.Wait(50) = %v, want nil", err)
+	}
+	if got, want := p.Snapshot(), (gate.ProgressSnapshot{Completed: 50, Total: 100}); got != want {
+		t.Fatalf("p.Snapshot() = %+v, want %+v", got, want)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Millisecond)
+	defer cancel()
+	if err := p.Wait(ctx, 100); err != context.DeadlineExceeded {
+		t.Fatalf("p.Wait(100) = %v, want context.DeadlineExceeded", err)
+	}
+}
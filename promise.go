@@ -0,0 +1,78 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate
+
+import "context"
+
+// A Promise is the producer side of a value which will become available in the future.
+type Promise[T any] struct {
+	f *Future[T]
+}
+
+// A Future is the consumer side of a Promise.
+type Future[T any] struct {
+	gate Gate // set if the promise is resolved or rejected
+	v    T
+	err  error
+}
+
+// NewPromise returns a new, unresolved promise.
+func NewPromise[T any]() *Promise[T] {
+	return &Promise[T]{
+		f: &Future[T]{
+			gate: New(false),
+		},
+	}
+}
+
+// Future returns the promise's future.
+func (p *Promise[T]) Future() *Future[T] {
+	return p.f
+}
+
+// Resolve completes the promise with a value.
+// It reports whether this call completed the promise.
+func (p *Promise[T]) Resolve(v T) bool {
+	return p.complete(v, nil)
+}
+
+// Reject completes the promise with an error.
+// It reports whether this call completed the promise.
+func (p *Promise[T]) Reject(err error) bool {
+	var zero T
+	return p.complete(zero, err)
+}
+
+func (p *Promise[T]) complete(v T, err error) bool {
+	f := p.f
+	if set := f.gate.Lock(); set {
+		f.gate.Unlock(true)
+		return false
+	}
+	f.v, f.err = v, err
+	f.gate.Unlock(true)
+	return true
+}
+
+// Get blocks until the promise is completed or the context expires.
+// It returns the value or error the promise was completed with,
+// or the context's error if the context expired.
+func (f *Future[T]) Get(ctx context.Context) (T, error) {
+	if err := f.gate.WaitAndLock(ctx); err != nil {
+		var zero T
+		return zero, err
+	}
+	defer f.gate.Unlock(true)
+	return f.v, f.err
+}
+
+// Done reports whether the promise has been completed.
+func (f *Future[T]) Done() bool {
+	if !f.gate.LockIfSet() {
+		return false
+	}
+	f.gate.Unlock(true)
+	return true
+}
@@ -0,0 +1,55 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/neild/gate"
+)
+
+func TestPromiseResolve(t *testing.T) {
+	p := gate.NewPromise[int]()
+	f := p.Future()
+	if done := f.Done(); done {
+		t.Fatalf("f.Done of unresolved promise = %v, want false", done)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Millisecond)
+	defer cancel()
+	if _, err := f.Get(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("f.Get of unresolved promise = %v, want context.DeadlineExceeded", err)
+	}
+	go func() {
+		time.Sleep(1 * time.Millisecond)
+		p.Resolve(1)
+	}()
+	if v, err := f.Get(context.Background()); v != 1 || err != nil {
+		t.Fatalf("f.Get = %v, %v; want 1, nil", v, err)
+	}
+	if ok := p.Resolve(2); ok {
+		t.Fatalf("second p.Resolve = %v, want false", ok)
+	}
+	if ok := p.Reject(errors.New("too late")); ok {
+		t.Fatalf("p.Reject of resolved promise = %v, want false", ok)
+	}
+	if v, err := f.Get(ctx); v != 1 || err != nil {
+		t.Fatalf("f.Get with expired context = %v, %v; want 1, nil", v, err)
+	}
+	if done := f.Done(); !done {
+		t.Fatalf("f.Done of resolved promise = %v, want true", done)
+	}
+}
+
+func TestPromiseReject(t *testing.T) {
+	p := gate.NewPromise[int]()
+	wantErr := errors.New("failed")
+	p.Reject(wantErr)
+	if _, err := p.Future().Get(context.Background()); err != wantErr {
+		t.Fatalf("f.Get of rejected promise = %v, want %v", err, wantErr)
+	}
+}
@@ -14,39 +14,139 @@ import (
 	"github.com/neild/gate"
 )
 
-// A Queue is an unbounded queue of some item.
+// A Queue is an unbounded queue (or stack) of some item.
 type Queue[T any] struct {
-	gate gate.Gate // set if queue is non-empty or closed
-	err  error
-	q    []T
+	gate   gate.Gate // set if queue is closed, or non-empty and not paused
+	err    error
+	q      []T  // items, in the order they will be removed
+	lifo   bool // Put adds items to the front of q
+	paused bool
+	reject func(v T, reason error)
+	wakec  chan struct{} // closed when an item is added, the queue is resumed or closed, or a batch timer expires
+	donec  chan struct{} // closed when the queue is closed, created by Done
 }
 
 // NewQueue returns a new queue.
-func NewQueue[T any]() *Queue[T] {
+// The options apply to the queue's gate, as for gate.New;
+// the gate's clock also times GetBatch and Claim.
+func NewQueue[T any](opts ...gate.Option) *Queue[T] {
 	return &Queue[T]{
-		gate: gate.New(false),
+		gate: gate.New(false, opts...),
+	}
+}
+
+// NewStack returns a new queue with stack semantics:
+// items are removed in the reverse of the order they were added.
+func NewStack[T any](opts ...gate.Option) *Queue[T] {
+	return &Queue[T]{
+		gate: gate.New(false, opts...),
+		lifo: true,
 	}
 }
 
 // Close closes the queue, causing pending and future pop operations
-// to return immediately with err.
-func (q *Queue[T]) Close(err error) {
+// to return immediately with err, or io.EOF if err is nil.
+//
+// It returns the items which were still in the queue, and reports whether
+// this call closed the queue. If the queue was already closed,
+// Close returns no items and the original close error is retained.
+func (q *Queue[T]) Close(err error) (pending []T, closed bool) {
+	q.gate.Lock()
+	defer q.unlock()
+	if q.err != nil {
+		return nil, false
+	}
+	if err == nil {
+		err = io.EOF
+	}
+	q.err = err
+	pending, q.q = q.q, nil
+	q.wake()
+	if q.donec != nil {
+		close(q.donec)
+	}
+	return pending, true
+}
+
+// Done returns a channel which is closed when the queue is closed.
+func (q *Queue[T]) Done() <-chan struct{} {
 	q.gate.Lock()
 	defer q.unlock()
-	if q.err == nil {
-		q.err = err
+	if q.donec == nil {
+		q.donec = make(chan struct{})
+		if q.err != nil {
+			close(q.donec)
+		}
 	}
+	return q.donec
+}
+
+// Err returns the error the queue was closed with, or nil if it is open.
+func (q *Queue[T]) Err() error {
+	q.gate.Lock()
+	defer q.unlock()
+	return q.err
 }
 
-// Put appends an item to the queue.
+// Put adds an item to the back of the queue, or the top of a stack.
 // It returns true if the item was added, false if the queue is closed.
 func (q *Queue[T]) Put(v T) bool {
 	q.gate.Lock()
+	if q.err != nil {
+		q.unlockAndReject(v)
+		return false
+	}
 	defer q.unlock()
+	if q.lifo {
+		q.q = slices.Insert(q.q, 0, v)
+	} else {
+		q.q = append(q.q, v)
+	}
+	q.wake()
+	return true
+}
+
+// OnReject registers a function to be called with items the queue refuses to accept,
+// such as items put after the queue is closed or expired claims which cannot be requeued.
+// The reason is the error the queue was closed with.
+// The function is called without the queue's gate held.
+func (q *Queue[T]) OnReject(f func(v T, reason error)) {
+	q.gate.Lock()
+	defer q.unlock()
+	q.reject = f
+}
+
+// Pause stops the queue from delivering items.
+// Calls to Get block until the queue is resumed or closed,
+// while calls to Put continue to add items to the queue.
+func (q *Queue[T]) Pause() {
+	q.gate.Lock()
+	defer q.unlock()
+	q.paused = true
+}
+
+// Resume resumes delivery of items after a call to Pause.
+func (q *Queue[T]) Resume() {
+	q.gate.Lock()
+	defer q.unlock()
+	q.paused = false
+	q.wake()
+}
+
+// Requeue returns an item to the front of the queue,
+// so that it is the next item returned by Get.
+// It is intended for consumers which fail to process an item and want to retry it
+// without reordering it relative to items put after it.
+// It returns true if the item was added, false if the queue is closed.
+func (q *Queue[T]) Requeue(v T) bool {
+	q.gate.Lock()
 	if q.err != nil {
+		q.unlockAndReject(v)
 		return false
 	}
-	q.q = append(q.q, v)
+	defer q.unlock()
+	q.q = slices.Insert(q.q, 0, v)
+	q.wake()
 	return true
 }
 
@@ -61,7 +161,7 @@ func (q *Queue[T]) Get(ctx context.Context) (T, error) {
 
 	// WaitAndLock blocks until the gate condition is set,
 	// so either the queue is closed (q.err != nil) or
-	// there is at least one item in the queue.
+	// there is at least one item in the queue and the queue is not paused.
 	if q.err != nil {
 		return zero, q.err
 	}
@@ -70,10 +170,212 @@ func (q *Queue[T]) Get(ctx context.Context) (T, error) {
 	return v, nil
 }
 
+// GetInto removes up to len(buf) items from the queue and stores them in buf,
+// blocking until ctx is done, at least one item is available, or the queue is closed.
+// It returns the number of items stored.
+func (q *Queue[T]) GetInto(ctx context.Context, buf []T) (n int, err error) {
+	if err := q.gate.WaitAndLock(ctx); err != nil {
+		return 0, err
+	}
+	defer q.unlock()
+	if q.err != nil {
+		return 0, q.err
+	}
+	n = copy(buf, q.q)
+	q.q = slices.Delete(q.q, 0, n)
+	return n, nil
+}
+
+// GetMatch removes the first item in the queue for which match returns true,
+// blocking until ctx is done, a matching item is available, or the queue is closed.
+// Items which do not match are left in the queue.
+func (q *Queue[T]) GetMatch(ctx context.Context, match func(T) bool) (T, error) {
+	var zero T
+	for {
+		// The gate condition doesn't tell us whether a matching item is present,
+		// so lock unconditionally and wait for the queue to change if there is none.
+		q.gate.Lock()
+		if err := q.err; err != nil {
+			q.unlock()
+			return zero, err
+		}
+		if !q.paused {
+			if i := slices.IndexFunc(q.q, match); i >= 0 {
+				v := q.q[i]
+				q.q = slices.Delete(q.q, i, i+1)
+				q.unlock()
+				return v, nil
+			}
+		}
+		if q.wakec == nil {
+			q.wakec = make(chan struct{})
+		}
+		wakec := q.wakec
+		q.unlock()
+		if err := q.gate.WaitFor(ctx, wakec); err != nil {
+			return zero, err
+		}
+	}
+}
+
+// GetBatch removes up to maxItems items from the queue.
+// It blocks until ctx is done, at least one item is available, or the queue is closed.
+// Once an item is available, it waits for up to maxWait for the batch to fill
+// before returning the items it has.
+//
+// If ctx is done before a batch is returned, GetBatch returns an error
+// and no items are removed from the queue.
+// The maxItems must be positive.
+func (q *Queue[T]) GetBatch(ctx context.Context, maxItems int, maxWait time.Duration) ([]T, error) {
+	if maxItems <= 0 {
+		panic("gate: GetBatch maxItems must be positive")
+	}
+	if err := q.gate.WaitAndLock(ctx); err != nil {
+		return nil, err
+	}
+	var (
+		timer   gate.Timer
+		expired bool // guarded by q.gate
+	)
+	for {
+		if err := q.err; err != nil {
+			q.unlock()
+			return nil, err
+		}
+		if !q.paused && (len(q.q) >= maxItems || (expired && len(q.q) > 0)) {
+			n := min(maxItems, len(q.q))
+			batch := slices.Clone(q.q[:n])
+			q.q = slices.Delete(q.q, 0, n)
+			q.unlock()
+			return batch, nil
+		}
+		if len(q.q) > 0 && timer == nil {
+			// The first item of the batch has arrived: start the clock.
+			timer = q.gate.Clock().AfterFunc(maxWait, func() {
+				q.gate.Lock()
+				expired = true
+				q.wake()
+				q.unlock()
+			})
+			defer timer.Stop()
+		}
+		if q.wakec == nil {
+			q.wakec = make(chan struct{})
+		}
+		wakec := q.wakec
+		q.unlock()
+		if err := q.gate.WaitFor(ctx, wakec); err != nil {
+			return nil, err
+		}
+		q.gate.Lock()
+	}
+}
+
+// WaitLen blocks until ctx is done, the queue contains at least n items,
+// or the queue is closed. It does not remove any items from the queue.
+// It returns nil if the queue contained at least n items.
+func (q *Queue[T]) WaitLen(ctx context.Context, n int) error {
+	for {
+		q.gate.Lock()
+		if err := q.err; err != nil {
+			q.unlock()
+			return err
+		}
+		if len(q.q) >= n {
+			q.unlock()
+			return nil
+		}
+		if q.wakec == nil {
+			q.wakec = make(chan struct{})
+		}
+		wakec := q.wakec
+		q.unlock()
+		if err := q.gate.WaitFor(ctx, wakec); err != nil {
+			return err
+		}
+	}
+}
+
+// A Claim is an item leased from a queue by Queue.Claim.
+type Claim[T any] struct {
+	Item T
+
+	q     *Queue[T]
+	timer gate.Timer
+	done  bool // acked or expired; guarded by q.gate
+}
+
+// Claim removes the first item from the queue, blocking until ctx is done, an item is available,
+// or the queue is closed.
+// The item is leased to the caller, who must call Ack within the given timeout.
+// If the claim is not acknowledged in time, the item is returned to the head of the queue.
+func (q *Queue[T]) Claim(ctx context.Context, timeout time.Duration) (*Claim[T], error) {
+	v, err := q.Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c := &Claim[T]{Item: v, q: q}
+	q.gate.Lock()
+	c.timer = q.gate.Clock().AfterFunc(timeout, c.expire)
+	q.unlock()
+	return c, nil
+}
+
+// Ack acknowledges that the claimed item has been processed.
+// It reports whether the claim was acknowledged before it expired.
+// If Ack returns false, the item has been returned to the queue.
+func (c *Claim[T]) Ack() bool {
+	c.q.gate.Lock()
+	defer c.q.unlock()
+	if c.done {
+		return false
+	}
+	c.done = true
+	c.timer.Stop()
+	return true
+}
+
+func (c *Claim[T]) expire() {
+	q := c.q
+	q.gate.Lock()
+	if c.done {
+		q.unlock()
+		return
+	}
+	c.done = true
+	if q.err != nil {
+		q.unlockAndReject(c.Item)
+		return
+	}
+	q.q = slices.Insert(q.q, 0, c.Item)
+	q.wake()
+	q.unlock()
+}
+
+// wake wakes any GetMatch, GetBatch, or WaitLen calls waiting for the queue to change,
+// or a GetBatch call whose batch timer has expired.
+// The queue's gate must be held.
+func (q *Queue[T]) wake() {
+	if q.wakec != nil {
+		close(q.wakec)
+		q.wakec = nil
+	}
+}
+
+// unlockAndReject unlocks the queue's gate and passes v to the reject handler.
+// The queue must be closed.
+func (q *Queue[T]) unlockAndReject(v T) {
+	err, reject := q.err, q.reject
+	q.unlock()
+	if reject != nil {
+		reject(v, err)
+	}
+}
+
 // unlock unlocks the queue's gate,
-// setting the condition to true if the queue is non-empty or closed.
+// setting the condition to true if the queue is closed, or non-empty and not paused.
 func (q *Queue[T]) unlock() {
-	q.gate.Unlock(q.err != nil || len(q.q) > 0)
+	q.gate.Unlock(q.err != nil || (!q.paused && len(q.q) > 0))
 }
 
 func Example_queue() {
@@ -95,3 +397,202 @@ func Example_queue() {
 	// 2 <nil>
 	// 0 EOF
 }
+
+func Example_queueGetMatch() {
+	type response struct {
+		id   int
+		body string
+	}
+	q := NewQueue[response]()
+
+	go func() {
+		q.Put(response{1, "one"})
+		time.Sleep(1 * time.Millisecond)
+		q.Put(response{2, "two"})
+	}()
+
+	// Wait for the response with ID 2, leaving the other in the queue.
+	r, err := q.GetMatch(context.Background(), func(r response) bool {
+		return r.id == 2
+	})
+	fmt.Println(r.body, err)
+	r, err = q.Get(context.Background())
+	fmt.Println(r.body, err)
+	// Output:
+	// two <nil>
+	// one <nil>
+}
+
+func Example_queueRequeue() {
+	// Items carry a retry count, so consumers can give up on items
+	// that fail repeatedly.
+	type work struct {
+		name    string
+		retries int
+	}
+	q := NewQueue[work]()
+	q.Put(work{name: "a"})
+	q.Put(work{name: "b"})
+
+	for done := 0; done < 2; {
+		w, _ := q.Get(context.Background())
+		if w.name == "a" && w.retries < 2 {
+			// Transient failure: put the item back at the head of the queue.
+			fmt.Println("retry", w.name)
+			w.retries++
+			q.Requeue(w)
+			continue
+		}
+		fmt.Println("done", w.name, w.retries)
+		done++
+	}
+	// Output:
+	// retry a
+	// retry a
+	// done a 2
+	// done b 0
+}
+
+func Example_queueGetBatch() {
+	q := NewQueue[int]()
+
+	// The batch is full.
+	q.Put(1)
+	q.Put(2)
+	q.Put(3)
+	fmt.Println(q.GetBatch(context.Background(), 2, 1*time.Hour))
+
+	// The batch is not full, and is returned after maxWait.
+	fmt.Println(q.GetBatch(context.Background(), 2, 1*time.Millisecond))
+	// Output:
+	// [1 2] <nil>
+	// [3] <nil>
+}
+
+func Example_queuePause() {
+	q := NewQueue[int]()
+	q.Pause()
+	q.Put(1)
+
+	// Get blocks while the queue is paused.
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Millisecond)
+	defer cancel()
+	fmt.Println(q.Get(ctx))
+
+	q.Resume()
+	fmt.Println(q.Get(context.Background()))
+	// Output:
+	// 0 context deadline exceeded
+	// 1 <nil>
+}
+
+func Example_queueClaim() {
+	clock := gate.NewFakeClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	q := NewQueue[string](gate.WithClock(clock))
+	q.Put("job")
+
+	// The consumer claims the item but fails to acknowledge it in time,
+	// so the item is returned to the queue.
+	c, _ := q.Claim(context.Background(), 1*time.Minute)
+	fmt.Println("claimed", c.Item)
+	clock.Advance(2 * time.Minute)
+	fmt.Println("ack", c.Ack())
+
+	// The next consumer processes the item successfully.
+	c, _ = q.Claim(context.Background(), 1*time.Hour)
+	fmt.Println("claimed", c.Item)
+	fmt.Println("ack", c.Ack())
+	// Output:
+	// claimed job
+	// ack false
+	// claimed job
+	// ack true
+}
+
+func Example_queueClose() {
+	q := NewQueue[int]()
+	q.Put(1)
+	q.Put(2)
+
+	fmt.Println(q.Close(io.EOF))
+	fmt.Println(q.Close(io.ErrUnexpectedEOF))
+	fmt.Println(q.Err())
+	// Output:
+	// [1 2] true
+	// [] false
+	// EOF
+}
+
+func Example_queueCloseNil() {
+	q := NewQueue[int]()
+	q.Put(1)
+
+	fmt.Println(q.Close(nil))
+	fmt.Println(q.Put(2))
+	fmt.Println(q.Get(context.Background()))
+	// Output:
+	// [1] true
+	// false
+	// 0 EOF
+}
+
+func Example_queueWaitLen() {
+	q := NewQueue[int]()
+
+	go func() {
+		for i := range 3 {
+			time.Sleep(1 * time.Millisecond)
+			q.Put(i)
+		}
+	}()
+
+	// Wait until a batch of three items is available.
+	fmt.Println(q.WaitLen(context.Background(), 3))
+	fmt.Println(q.GetBatch(context.Background(), 3, 0))
+	// Output:
+	// <nil>
+	// [0 1 2] <nil>
+}
+
+func Example_queueGetInto() {
+	q := NewQueue[int]()
+	for i := range 5 {
+		q.Put(i)
+	}
+
+	// Reuse the same buffer for every receive.
+	buf := make([]int, 2)
+	for range 3 {
+		n, err := q.GetInto(context.Background(), buf)
+		fmt.Println(buf[:n], err)
+	}
+	// Output:
+	// [0 1] <nil>
+	// [2 3] <nil>
+	// [4] <nil>
+}
+
+func Example_stack() {
+	s := NewStack[int]()
+	for i := range 3 {
+		s.Put(i)
+	}
+	for range 3 {
+		fmt.Println(s.Get(context.Background()))
+	}
+	// Output:
+	// 2 <nil>
+	// 1 <nil>
+	// 0 <nil>
+}
+
+func Example_queueOnReject() {
+	q := NewQueue[string]()
+	q.OnReject(func(v string, reason error) {
+		fmt.Printf("rejected %q: %v\n", v, reason)
+	})
+	q.Close(io.EOF)
+	q.Put("late")
+	// Output:
+	// rejected "late": EOF
+}
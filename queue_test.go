@@ -0,0 +1,22 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate_test
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestQueueGetBatchNoItems(t *testing.T) {
+	q := NewQueue[int]()
+	q.Put(1)
+	defer func() {
+		if recover() == nil {
+			t.Errorf("GetBatch with maxItems 0 did not panic")
+		}
+	}()
+	q.GetBatch(context.Background(), 0, time.Second)
+}
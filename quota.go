@@ -0,0 +1,134 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate
+
+import "context"
+
+// A Quota is a node in a tree of limits, such as a per-tenant limit under a global cap.
+// Acquiring units from a quota takes them from the quota and all its ancestors at once,
+// so usage never exceeds the limit at any level.
+type Quota struct {
+	tree   *quotaTree
+	parent *Quota
+	limit  int64 // guarded by tree.mu
+	used   int64 // guarded by tree.mu
+}
+
+// quotaTree holds the state shared by all quotas in a tree.
+type quotaTree struct {
+	mu      Gate          // guards all quotas in the tree
+	changed chan struct{} // closed when units are released or a limit changes
+}
+
+// NewQuota returns a new root quota with the given limit.
+func NewQuota(limit int64) *Quota {
+	return &Quota{
+		tree:  &quotaTree{mu: New(false)},
+		limit: limit,
+	}
+}
+
+// Child returns a new quota with the given limit, which draws units from q.
+func (q *Quota) Child(limit int64) *Quota {
+	return &Quota{
+		tree:   q.tree,
+		parent: q,
+		limit:  limit,
+	}
+}
+
+// Acquire acquires n units, blocking until they are available
+// from q and all its ancestors, or the context expires.
+// If the context expires, Acquire returns an error and does not acquire any units.
+func (q *Quota) Acquire(ctx context.Context, n int64) error {
+	t := q.tree
+	for {
+		t.mu.Lock()
+		if q.fits(n) {
+			q.add(n)
+			t.mu.Unlock(false)
+			return nil
+		}
+		if t.changed == nil {
+			t.changed = make(chan struct{})
+		}
+		changed := t.changed
+		t.mu.Unlock(false)
+		if err := t.mu.WaitFor(ctx, changed); err != nil {
+			return err
+		}
+	}
+}
+
+// TryAcquire acquires n units if they are available without blocking.
+// It reports whether the units were acquired.
+func (q *Quota) TryAcquire(n int64) (acquired bool) {
+	t := q.tree
+	t.mu.Lock()
+	defer t.mu.Unlock(false)
+	if !q.fits(n) {
+		return false
+	}
+	q.add(n)
+	return true
+}
+
+// Release releases n units to q and all its ancestors.
+// It panics if more units are released than are held.
+func (q *Quota) Release(n int64) {
+	t := q.tree
+	t.mu.Lock()
+	defer t.mu.Unlock(false)
+	if n > q.used {
+		panic("gate: Quota released more units than held")
+	}
+	q.add(-n)
+	t.notify()
+}
+
+// SetLimit changes q's limit.
+// If the limit is lowered below the units in use, no units are reclaimed,
+// but no more are granted until usage falls below the new limit.
+func (q *Quota) SetLimit(limit int64) {
+	t := q.tree
+	t.mu.Lock()
+	defer t.mu.Unlock(false)
+	q.limit = limit
+	t.notify()
+}
+
+// Used returns the number of units in use from q, including units used by its descendants.
+func (q *Quota) Used() int64 {
+	t := q.tree
+	t.mu.Lock()
+	defer t.mu.Unlock(false)
+	return q.used
+}
+
+// fits reports whether n units are available from q and all its ancestors.
+func (q *Quota) fits(n int64) bool {
+	for p := q; p != nil; p = p.parent {
+		if p.used+n > p.limit {
+			return false
+		}
+	}
+	return true
+}
+
+// add adds n to the units used by q and all its ancestors.
+func (q *Quota) add(n int64) {
+	for p := q; p != nil; p = p.parent {
+		p.used += n
+	}
+}
+
+// notify wakes callers of Acquire.
+// The tree's mu must be held.
+func (t *quotaTree) notify() {
+	if t.changed != nil {
+		close(t.changed)
+		t.changed = nil
+	}
+}
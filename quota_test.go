@@ -0,0 +1,77 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/neild/gate"
+)
+
+func TestQuota(t *testing.T) {
+	global := gate.NewQuota(10)
+	a := global.Child(6)
+	b := global.Child(6)
+
+	if ok := a.TryAcquire(7); ok {
+		t.Fatalf("a.TryAcquire(7) over tenant limit = %v, want false", ok)
+	}
+	if err := a.Acquire(context.Background(), 6); err != nil {
+		t.Fatalf("a.Acquire(6) = %v, want nil", err)
+	}
+	// b is within its own limit, but the global cap is exhausted.
+	if ok := b.TryAcquire(5); ok {
+		t.Fatalf("b.TryAcquire(5) over global limit = %v, want false", ok)
+	}
+	if ok := b.TryAcquire(4); !ok {
+		t.Fatalf("b.TryAcquire(4) = %v, want true", ok)
+	}
+	if got, want := global.Used(), int64(10); got != want {
+		t.Fatalf("global.Used() = %v, want %v", got, want)
+	}
+
+	donec := make(chan error)
+	go func() {
+		donec <- b.Acquire(context.Background(), 2)
+	}()
+	time.Sleep(1 * time.Millisecond)
+	a.Release(2)
+	if err := <-donec; err != nil {
+		t.Fatalf("b.Acquire(2) = %v, want nil", err)
+	}
+}
+
+func TestQuotaSetLimit(t *testing.T) {
+	global := gate.NewQuota(10)
+	a := global.Child(1)
+	a.Acquire(context.Background(), 1)
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Millisecond)
+	defer cancel()
+	if err := a.Acquire(ctx, 1); err != context.DeadlineExceeded {
+		t.Fatalf("a.Acquire over limit = %v, want context.DeadlineExceeded", err)
+	}
+
+	donec := make(chan error)
+	go func() {
+		donec <- a.Acquire(context.Background(), 1)
+	}()
+	time.Sleep(1 * time.Millisecond)
+	a.SetLimit(2)
+	if err := <-donec; err != nil {
+		t.Fatalf("a.Acquire after raising limit = %v, want nil", err)
+	}
+}
+
+func TestQuotaOverRelease(t *testing.T) {
+	q := gate.NewQuota(1)
+	defer func() {
+		if recover() == nil {
+			t.Errorf("over-release did not panic")
+		}
+	}()
+	q.Release(1)
+}
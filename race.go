@@ -0,0 +1,103 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// Race calls each of fns and returns the first successful result.
+//
+// If stagger is zero, all functions start at once.
+// Otherwise the functions are hedged: each starts after stagger has passed
+// since the previous one started, or as soon as the previous one fails.
+// The stagger is measured by the clock carried by ctx (see ContextWithClock),
+// or by the system clock.
+//
+// Once a function succeeds, the others are canceled, and Race waits for them to return
+// before returning the winning result.
+// If every function fails, Race returns all their errors joined.
+// If ctx is done first, Race cancels all functions, waits for them to return,
+// and returns ctx's error.
+func Race[T any](ctx context.Context, stagger time.Duration, fns ...func(context.Context) (T, error)) (T, error) {
+	type result struct {
+		v   T
+		err error
+	}
+	if len(fns) == 0 {
+		panic("gate: Race with no functions")
+	}
+	var zero T
+	raceCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	results := make(chan result, len(fns))
+	started, done := 0, 0
+	start := func() {
+		fn := fns[started]
+		started++
+		go func() {
+			v, err := fn(raceCtx)
+			results <- result{v, err}
+		}()
+	}
+	// reap cancels all running functions and waits for them to return.
+	reap := func() {
+		cancel()
+		for ; done < started; done++ {
+			<-results
+		}
+	}
+
+	var (
+		timer  Timer
+		timerc <-chan time.Time
+	)
+	if stagger > 0 {
+		start()
+		timer = contextClock(ctx).NewTimer(stagger)
+		defer timer.Stop()
+		timerc = timer.C()
+	} else {
+		for started < len(fns) {
+			start()
+		}
+	}
+	var errs []error
+	for {
+		select {
+		case r := <-results:
+			done++
+			if r.err == nil {
+				reap()
+				return r.v, nil
+			}
+			errs = append(errs, r.err)
+			if done == len(fns) {
+				return zero, errors.Join(errs...)
+			}
+			if started < len(fns) && done == started {
+				// Nothing is running; start the next function now.
+				start()
+				if !timer.Stop() {
+					select {
+					case <-timer.C():
+					default:
+					}
+				}
+				timer.Reset(stagger)
+			}
+		case <-timerc:
+			if started < len(fns) {
+				start()
+				timer.Reset(stagger)
+			}
+		case <-ctx.Done():
+			reap()
+			return zero, ctx.Err()
+		}
+	}
+}
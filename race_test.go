@@ -0,0 +1,99 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/neild/gate"
+)
+
+func TestRace(t *testing.T) {
+	var running atomic.Int32
+	slow := func(ctx context.Context) (string, error) {
+		running.Add(1)
+		defer running.Add(-1)
+		<-ctx.Done()
+		return "", ctx.Err()
+	}
+	fast := func(ctx context.Context) (string, error) {
+		return "fast", nil
+	}
+	v, err := gate.Race(context.Background(), 0, slow, fast, slow)
+	if err != nil || v != "fast" {
+		t.Fatalf("Race = %q, %v; want %q, nil", v, err, "fast")
+	}
+	// The losers were canceled and reaped.
+	if got := running.Load(); got != 0 {
+		t.Fatalf("%v losing functions still running after Race returned", got)
+	}
+}
+
+func TestRaceAllFail(t *testing.T) {
+	err1 := errors.New("one")
+	err2 := errors.New("two")
+	_, err := gate.Race(context.Background(), 0,
+		func(context.Context) (int, error) { return 0, err1 },
+		func(context.Context) (int, error) { return 0, err2 },
+	)
+	if !errors.Is(err, err1) || !errors.Is(err, err2) {
+		t.Fatalf("Race = %v, want errors wrapping %v and %v", err, err1, err2)
+	}
+}
+
+func TestRaceHedged(t *testing.T) {
+	var started atomic.Int32
+	fn := func(v int, d time.Duration) func(context.Context) (int, error) {
+		return func(ctx context.Context) (int, error) {
+			started.Add(1)
+			select {
+			case <-time.After(d):
+				return v, nil
+			case <-ctx.Done():
+				return 0, ctx.Err()
+			}
+		}
+	}
+	// The first function answers before the hedge delay, so the second never starts.
+	v, err := gate.Race(context.Background(), 1*time.Hour, fn(1, 1*time.Millisecond), fn(2, 0))
+	if err != nil || v != 1 {
+		t.Fatalf("Race = %v, %v; want 1, nil", v, err)
+	}
+	if got := started.Load(); got != 1 {
+		t.Fatalf("Race started %v functions, want 1", got)
+	}
+
+	// The second function starts after the hedge delay, and wins.
+	v, err = gate.Race(context.Background(), 1*time.Millisecond, fn(1, 1*time.Hour), fn(2, 0))
+	if err != nil || v != 2 {
+		t.Fatalf("hedged Race = %v, %v; want 2, nil", v, err)
+	}
+}
+
+func TestRaceHedgedFailureStartsNext(t *testing.T) {
+	v, err := gate.Race(context.Background(), 1*time.Hour,
+		func(context.Context) (int, error) { return 0, errors.New("failed") },
+		func(context.Context) (int, error) { return 2, nil },
+	)
+	if err != nil || v != 2 {
+		t.Fatalf("Race = %v, %v; want 2, nil", v, err)
+	}
+}
+
+func TestRaceContextExpired(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Millisecond)
+	defer cancel()
+	_, err := gate.Race(ctx, 0, func(ctx context.Context) (int, error) {
+		<-ctx.Done()
+		return 0, errors.New("canceled")
+	})
+	if err != context.DeadlineExceeded {
+		t.Fatalf("Race with expired context = %v, want context.DeadlineExceeded", err)
+	}
+}
@@ -0,0 +1,170 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var (
+	// ErrExceedsBurst is returned when waiting for more tokens than a limiter's burst size.
+	ErrExceedsBurst = errors.New("gate: token count exceeds limiter burst")
+
+	// ErrWouldExceedDeadline is returned by RateLimiter.WaitN and SlidingWindowLimiter.Wait
+	// when the wait would not end before the context's deadline.
+	ErrWouldExceedDeadline = errors.New("gate: rate limit wait would exceed context deadline")
+)
+
+// A RateLimiter is a token bucket rate limiter.
+//
+// The bucket holds up to burst tokens, and is refilled at a rate of rate tokens per second.
+// Waiters reserve tokens in the order they arrive and sleep until their tokens have accrued,
+// so each waiter wakes exactly when it may proceed.
+type RateLimiter struct {
+	mu     Gate // guards the fields below
+	clock  Clock
+	rate   float64
+	burst  int
+	tokens float64 // may be negative when tokens are reserved
+	last   time.Time
+}
+
+// A Reservation holds tokens reserved from a RateLimiter.
+type Reservation struct {
+	l        *RateLimiter
+	ok       bool
+	n        int
+	at       time.Time
+	canceled bool // guarded by l.mu
+}
+
+// NewRateLimiter returns a new limiter with a full bucket.
+func NewRateLimiter(rate float64, burst int, opts ...Option) *RateLimiter {
+	o := newOptions(opts)
+	clock := o.clock
+	return &RateLimiter{
+		mu:     o.newGate(false, ""),
+		clock:  clock,
+		rate:   rate,
+		burst:  burst,
+		tokens: float64(burst),
+		last:   clock.Now(),
+	}
+}
+
+// Allow reports whether a token is available now, consuming it if so.
+func (l *RateLimiter) Allow() bool {
+	return l.AllowN(1)
+}
+
+// AllowN reports whether n tokens are available now, consuming them if so.
+func (l *RateLimiter) AllowN(n int) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock(false)
+	l.advance(l.clock.Now())
+	if l.tokens < float64(n) {
+		return false
+	}
+	l.tokens -= float64(n)
+	return true
+}
+
+// Wait blocks until a token is available or the context expires.
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	return l.WaitN(ctx, 1)
+}
+
+// WaitN blocks until n tokens are available or the context expires.
+// If the tokens will not be available before the context's deadline,
+// WaitN returns ErrWouldExceedDeadline immediately.
+func (l *RateLimiter) WaitN(ctx context.Context, n int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r := l.ReserveN(n)
+	if !r.OK() {
+		return ErrExceedsBurst
+	}
+	if deadline, ok := ctx.Deadline(); ok && r.at.After(deadline) {
+		r.Cancel()
+		return ErrWouldExceedDeadline
+	}
+	delay := r.Delay()
+	if delay <= 0 {
+		return nil
+	}
+	t := l.clock.NewTimer(delay)
+	defer t.Stop()
+	select {
+	case <-t.C():
+		return nil
+	case <-ctx.Done():
+		r.Cancel()
+		return ctx.Err()
+	}
+}
+
+// ReserveN reserves n tokens, which become available after the reservation's delay.
+// The reservation is not OK if n exceeds the limiter's burst size.
+func (l *RateLimiter) ReserveN(n int) *Reservation {
+	l.mu.Lock()
+	defer l.mu.Unlock(false)
+	now := l.clock.Now()
+	l.advance(now)
+	r := &Reservation{l: l, n: n}
+	if n > l.burst {
+		return r
+	}
+	need := float64(n) - l.tokens
+	if need > 0 && l.rate <= 0 {
+		return r
+	}
+	r.ok = true
+	r.at = now
+	if need > 0 {
+		r.at = now.Add(time.Duration(need / l.rate * float64(time.Second)))
+	}
+	l.tokens -= float64(n)
+	return r
+}
+
+// OK reports whether the reservation holds tokens.
+func (r *Reservation) OK() bool {
+	return r.ok
+}
+
+// Delay returns the time remaining until the reserved tokens are available.
+func (r *Reservation) Delay() time.Duration {
+	if !r.ok {
+		return 0
+	}
+	return max(0, r.at.Sub(r.l.clock.Now()))
+}
+
+// Cancel returns the reserved tokens to the limiter, if they have not yet become available.
+func (r *Reservation) Cancel() {
+	if !r.ok {
+		return
+	}
+	l := r.l
+	l.mu.Lock()
+	defer l.mu.Unlock(false)
+	if r.canceled || !l.clock.Now().Before(r.at) {
+		return
+	}
+	r.canceled = true
+	l.advance(l.clock.Now())
+	l.tokens = min(l.tokens+float64(r.n), float64(l.burst))
+}
+
+// advance adds the tokens accrued since the last update.
+func (l *RateLimiter) advance(now time.Time) {
+	if elapsed := now.Sub(l.last); elapsed > 0 {
+		l.tokens = min(l.tokens+elapsed.Seconds()*l.rate, float64(l.burst))
+		l.last = now
+	}
+}
@@ -0,0 +1,65 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/neild/gate"
+)
+
+func TestRateLimiterAllow(t *testing.T) {
+	l := gate.NewRateLimiter(1, 2)
+	for i := 0; i < 2; i++ {
+		if ok := l.Allow(); !ok {
+			t.Fatalf("l.Allow #%v within burst = %v, want true", i, ok)
+		}
+	}
+	if ok := l.Allow(); ok {
+		t.Fatalf("l.Allow after burst exhausted = %v, want false", ok)
+	}
+}
+
+func TestRateLimiterWait(t *testing.T) {
+	l := gate.NewRateLimiter(1000, 1)
+	l.Allow()
+	start := time.Now()
+	if err := l.Wait(context.Background()); err != nil {
+		t.Fatalf("l.Wait = %v, want nil", err)
+	}
+	if elapsed := time.Since(start); elapsed < 500*time.Microsecond {
+		t.Fatalf("l.Wait returned after %v, want about 1ms", elapsed)
+	}
+	if err := l.WaitN(context.Background(), 2); err != gate.ErrExceedsBurst {
+		t.Fatalf("l.WaitN(2) with burst 1 = %v, want ErrExceedsBurst", err)
+	}
+}
+
+func TestRateLimiterWaitDeadline(t *testing.T) {
+	l := gate.NewRateLimiter(1, 1)
+	l.Allow()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Millisecond)
+	defer cancel()
+	if err := l.Wait(ctx); err != gate.ErrWouldExceedDeadline {
+		t.Fatalf("l.Wait with short deadline = %v, want ErrWouldExceedDeadline", err)
+	}
+}
+
+func TestRateLimiterReservationCancel(t *testing.T) {
+	l := gate.NewRateLimiter(1, 1)
+	l.Allow()
+	r := l.ReserveN(1)
+	if !r.OK() || r.Delay() <= 0 {
+		t.Fatalf("l.ReserveN(1) = ok %v, delay %v; want positive delay", r.OK(), r.Delay())
+	}
+	r.Cancel()
+	// The canceled reservation's tokens are returned,
+	// so the next reservation has the same delay rather than twice as long.
+	if d := l.ReserveN(1).Delay(); d > 1*time.Second {
+		t.Fatalf("delay after canceled reservation = %v, want at most 1s", d)
+	}
+}
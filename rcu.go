@@ -0,0 +1,84 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate
+
+import "sync/atomic"
+
+// An RCU holds a value which is read without locking and replaced by writers,
+// in the manner of read-copy-update.
+//
+// Readers acquire a snapshot of the current value and release it when done.
+// Writers publish a new value and may wait for a grace period to end,
+// after which no reader holds a snapshot of the value it replaced.
+//
+// Values must not be modified after they are stored.
+type RCU[T any] struct {
+	cur atomic.Pointer[Snapshot[T]]
+}
+
+// A Snapshot is a version of the value held by an RCU.
+type Snapshot[T any] struct {
+	v       T
+	readers atomic.Int64
+	retired atomic.Bool
+	grace   *Event // set when retired and no readers remain
+}
+
+// NewRCU returns a new RCU holding v.
+func NewRCU[T any](v T) *RCU[T] {
+	r := &RCU[T]{}
+	r.cur.Store(newSnapshot(v))
+	return r
+}
+
+func newSnapshot[T any](v T) *Snapshot[T] {
+	return &Snapshot[T]{
+		v:     v,
+		grace: NewEvent(),
+	}
+}
+
+// Acquire returns a snapshot of the current value.
+// The caller must call Release on the snapshot exactly once when done with it.
+func (r *RCU[T]) Acquire() *Snapshot[T] {
+	for {
+		s := r.cur.Load()
+		s.readers.Add(1)
+		if r.cur.Load() == s {
+			return s
+		}
+		// A writer replaced the value before we registered as a reader,
+		// and may already have found the old value unused.
+		s.Release()
+	}
+}
+
+// Store publishes a new value.
+// It returns a signal which is set when every reader of the previous value
+// has released it.
+func (r *RCU[T]) Store(v T) Signal {
+	old := r.cur.Swap(newSnapshot(v))
+	old.retired.Store(true)
+	if old.readers.Load() == 0 {
+		old.grace.Set()
+	}
+	return old.grace.Signal()
+}
+
+// Value returns the snapshot's value.
+func (s *Snapshot[T]) Value() T {
+	return s.v
+}
+
+// Release releases the snapshot.
+func (s *Snapshot[T]) Release() {
+	n := s.readers.Add(-1)
+	if n < 0 {
+		panic("gate: Snapshot released more times than acquired")
+	}
+	if n == 0 && s.retired.Load() {
+		s.grace.Set()
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate_test
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/neild/gate"
+)
+
+func TestRCU(t *testing.T) {
+	r := gate.NewRCU("v1")
+	s := r.Acquire()
+	if got, want := s.Value(), "v1"; got != want {
+		t.Fatalf("s.Value() = %q, want %q", got, want)
+	}
+
+	grace := r.Store("v2")
+	s2 := r.Acquire()
+	if got, want := s2.Value(), "v2"; got != want {
+		t.Fatalf("after Store, Value() = %q, want %q", got, want)
+	}
+	s2.Release()
+	// The old snapshot is unchanged.
+	if got, want := s.Value(), "v1"; got != want {
+		t.Fatalf("old s.Value() = %q, want %q", got, want)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Millisecond)
+	defer cancel()
+	if err := grace.Wait(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("grace.Wait with active reader = %v, want context.DeadlineExceeded", err)
+	}
+	s.Release()
+	if err := grace.Wait(context.Background()); err != nil {
+		t.Fatalf("grace.Wait after Release = %v, want nil", err)
+	}
+
+	// Without readers, the grace period ends immediately.
+	if set := r.Store("v3").IsSet(); !set {
+		t.Fatalf("r.Store with no readers of old value: grace.IsSet = %v, want true", set)
+	}
+}
+
+func TestRCUConcurrent(t *testing.T) {
+	type value struct {
+		n     int
+		freed atomic.Bool
+	}
+	r := gate.NewRCU(&value{})
+	stopc := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-stopc:
+					return
+				default:
+				}
+				s := r.Acquire()
+				if s.Value().freed.Load() {
+					t.Errorf("reader observed freed value")
+				}
+				s.Release()
+			}
+		}()
+	}
+	for i := 1; i < 100; i++ {
+		old := r.Acquire()
+		v := old.Value()
+		old.Release()
+		r.Store(&value{n: i}).Wait(context.Background())
+		// No reader holds the old value.
+		v.freed.Store(true)
+	}
+	close(stopc)
+	wg.Wait()
+}
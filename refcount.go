@@ -0,0 +1,76 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate
+
+import "context"
+
+// A RefCount is a reference count which may be waited on to reach zero.
+//
+// Once closed, a RefCount refuses new references,
+// so that a caller may wait for existing references to be released
+// without new ones appearing, as in a graceful shutdown.
+type RefCount struct {
+	gate   Gate // set if count is zero
+	count  int
+	closed bool
+}
+
+// NewRefCount returns a new RefCount with a count of zero.
+func NewRefCount() *RefCount {
+	return &RefCount{
+		gate: New(true),
+	}
+}
+
+// Inc adds a reference.
+// It reports whether the reference was added, which it is not if the RefCount is closed.
+func (r *RefCount) Inc() bool {
+	r.gate.Lock()
+	defer r.unlock()
+	if r.closed {
+		return false
+	}
+	r.count++
+	return true
+}
+
+// Dec releases a reference.
+// It panics if the count is zero.
+func (r *RefCount) Dec() {
+	r.gate.Lock()
+	defer r.unlock()
+	if r.count == 0 {
+		panic("gate: RefCount.Dec with zero count")
+	}
+	r.count--
+}
+
+// Close prevents new references from being added.
+func (r *RefCount) Close() {
+	r.gate.Lock()
+	defer r.unlock()
+	r.closed = true
+	r.gate.recordClose()
+}
+
+// Count returns the current count.
+func (r *RefCount) Count() int {
+	r.gate.Lock()
+	defer r.unlock()
+	return r.count
+}
+
+// Wait blocks until the count is zero or the context expires.
+func (r *RefCount) Wait(ctx context.Context) error {
+	if err := r.gate.WaitAndLock(ctx); err != nil {
+		return err
+	}
+	r.unlock()
+	return nil
+}
+
+func (r *RefCount) unlock() {
+	r.gate.Unlock(r.count == 0)
+}
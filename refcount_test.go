@@ -0,0 +1,53 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/neild/gate"
+)
+
+func TestRefCount(t *testing.T) {
+	r := gate.NewRefCount()
+	if err := r.Wait(context.Background()); err != nil {
+		t.Fatalf("r.Wait with zero count = %v, want nil", err)
+	}
+	r.Inc()
+	r.Inc()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Millisecond)
+	defer cancel()
+	if err := r.Wait(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("r.Wait with references = %v, want context.DeadlineExceeded", err)
+	}
+
+	r.Close()
+	if ok := r.Inc(); ok {
+		t.Fatalf("r.Inc after Close = %v, want false", ok)
+	}
+	if got, want := r.Count(), 2; got != want {
+		t.Fatalf("r.Count() = %v, want %v", got, want)
+	}
+	go func() {
+		time.Sleep(1 * time.Millisecond)
+		r.Dec()
+		r.Dec()
+	}()
+	if err := r.Wait(context.Background()); err != nil {
+		t.Fatalf("r.Wait = %v, want nil", err)
+	}
+}
+
+func TestRefCountDecOfZero(t *testing.T) {
+	r := gate.NewRefCount()
+	defer func() {
+		if recover() == nil {
+			t.Errorf("r.Dec with zero count did not panic")
+		}
+	}()
+	r.Dec()
+}
@@ -0,0 +1,108 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate
+
+import "context"
+
+// A Registry is a collection of named conditions.
+// It allows loosely coupled components to coordinate on conditions
+// such as "storage.ready" without sharing any other state.
+//
+// Conditions which are neither set nor waited for use no memory.
+type Registry struct {
+	mu    Gate // guards conds
+	conds map[string]*registryCond
+}
+
+type registryCond struct {
+	gate Gate // set if the condition is set
+	set  bool // guarded by Registry.mu
+	refs int  // waiters; guarded by Registry.mu
+}
+
+// NewRegistry returns a new registry with all conditions unset.
+func NewRegistry() *Registry {
+	return &Registry{
+		mu:    New(false),
+		conds: make(map[string]*registryCond),
+	}
+}
+
+// Set sets the named condition, waking all waiters.
+func (r *Registry) Set(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock(false)
+	c := r.cond(name)
+	c.set = true
+	c.gate.Lock()
+	c.gate.Unlock(true)
+}
+
+// Unset unsets the named condition.
+func (r *Registry) Unset(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock(false)
+	c := r.conds[name]
+	if c == nil {
+		return
+	}
+	c.set = false
+	c.gate.Lock()
+	c.gate.Unlock(false)
+	r.release(name, c)
+}
+
+// IsSet reports whether the named condition is set.
+func (r *Registry) IsSet(name string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock(false)
+	c := r.conds[name]
+	return c != nil && c.set
+}
+
+// Wait blocks until the named condition is set or the context expires.
+func (r *Registry) Wait(ctx context.Context, name string) error {
+	r.mu.Lock()
+	c := r.cond(name)
+	c.refs++
+	r.mu.Unlock(false)
+
+	err := c.gate.WaitAndLock(ctx)
+	if err == nil {
+		c.gate.Unlock(true)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock(false)
+	c.refs--
+	r.release(name, c)
+	return err
+}
+
+// cond returns the named condition, creating it if necessary.
+// The registry's mu must be held.
+func (r *Registry) cond(name string) *registryCond {
+	c := r.conds[name]
+	if c == nil {
+		c = &registryCond{gate: New(false)}
+		r.conds[name] = c
+	}
+	return c
+}
+
+// release forgets the named condition if it is unused.
+// The registry's mu must be held.
+func (r *Registry) release(name string, c *registryCond) {
+	if !c.set && c.refs == 0 {
+		delete(r.conds, name)
+	}
+}
+
+// len returns the number of conditions with state, for testing.
+func (r *Registry) len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock(false)
+	return len(r.conds)
+}
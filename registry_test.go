@@ -0,0 +1,61 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/neild/gate"
+)
+
+func TestRegistry(t *testing.T) {
+	r := gate.NewRegistry()
+	if set := r.IsSet("storage.ready"); set {
+		t.Fatalf("r.IsSet of new condition = %v, want false", set)
+	}
+	donec := make(chan error)
+	for i := 0; i < 2; i++ {
+		go func() {
+			donec <- r.Wait(context.Background(), "storage.ready")
+		}()
+	}
+	time.Sleep(1 * time.Millisecond)
+	r.Set("storage.ready")
+	for i := 0; i < 2; i++ {
+		if err := <-donec; err != nil {
+			t.Fatalf("r.Wait = %v, want nil", err)
+		}
+	}
+	if set := r.IsSet("storage.ready"); !set {
+		t.Fatalf("r.IsSet after Set = %v, want true", set)
+	}
+	if err := r.Wait(context.Background(), "storage.ready"); err != nil {
+		t.Fatalf("r.Wait of set condition = %v, want nil", err)
+	}
+
+	r.Unset("storage.ready")
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Millisecond)
+	defer cancel()
+	if err := r.Wait(ctx, "storage.ready"); err != context.DeadlineExceeded {
+		t.Fatalf("r.Wait after Unset = %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestRegistryCleanup(t *testing.T) {
+	r := gate.NewRegistry()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Millisecond)
+	defer cancel()
+	r.Wait(ctx, "a")
+	r.Set("b")
+	if n := gate.RegistryLen(r); n != 1 {
+		t.Fatalf("registry holds %v conditions, want 1", n)
+	}
+	r.Unset("b")
+	if n := gate.RegistryLen(r); n != 0 {
+		t.Fatalf("registry holds %v conditions after Unset, want 0", n)
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+)
+
+// A Retrier paces retries of an operation with exponential backoff.
+//
+// After each failure, the next try is delayed for twice as long as the last,
+// up to a maximum, with random jitter to spread out retries from many callers.
+// A success resets the delay.
+type Retrier struct {
+	gate     Gate // set if a try is allowed now
+	clock    Clock
+	min      time.Duration
+	max      time.Duration
+	jitter   float64
+	failures int
+	next     time.Time // time at which the next try is allowed
+	timer    Timer
+}
+
+// NewRetrier returns a new retrier which delays for minDelay after the first failure,
+// doubling on each further failure up to maxDelay.
+// Each delay is reduced by a random fraction of up to jitter, which is between 0 and 1.
+// A try is allowed immediately.
+func NewRetrier(minDelay, maxDelay time.Duration, jitter float64, opts ...Option) *Retrier {
+	o := newOptions(opts)
+	return &Retrier{
+		gate:   o.newGate(true, ""),
+		clock:  o.clock,
+		min:    minDelay,
+		max:    maxDelay,
+		jitter: jitter,
+	}
+}
+
+// Wait blocks until a try is allowed or the context expires.
+func (r *Retrier) Wait(ctx context.Context) error {
+	if err := r.gate.WaitAndLock(ctx); err != nil {
+		return err
+	}
+	r.unlock()
+	return nil
+}
+
+// Allowed reports whether a try is allowed now.
+func (r *Retrier) Allowed() bool {
+	r.gate.Lock()
+	defer r.unlock()
+	return r.allowed()
+}
+
+// Success records a successful try, resetting the backoff.
+func (r *Retrier) Success() {
+	r.gate.Lock()
+	defer r.unlock()
+	r.failures = 0
+	r.next = time.Time{}
+	if r.timer != nil {
+		r.timer.Stop()
+	}
+}
+
+// Failure records a failed try, delaying the next one.
+// It returns the delay.
+func (r *Retrier) Failure() time.Duration {
+	r.gate.Lock()
+	defer r.unlock()
+	d := r.min
+	for i := 0; i < r.failures && d < r.max; i++ {
+		d *= 2
+	}
+	d = min(d, r.max)
+	d -= time.Duration(r.jitter * rand.Float64() * float64(d))
+	r.failures++
+	r.next = r.clock.Now().Add(d)
+	if r.timer == nil {
+		r.timer = r.clock.AfterFunc(d, r.check)
+	} else {
+		r.timer.Reset(d)
+	}
+	return d
+}
+
+// Failures returns the number of failures since the last success.
+func (r *Retrier) Failures() int {
+	r.gate.Lock()
+	defer r.unlock()
+	return r.failures
+}
+
+func (r *Retrier) check() {
+	r.gate.Lock()
+	defer r.unlock()
+	if d := r.next.Sub(r.clock.Now()); d > 0 {
+		r.timer.Reset(d)
+	}
+}
+
+func (r *Retrier) allowed() bool {
+	return !r.clock.Now().Before(r.next)
+}
+
+func (r *Retrier) unlock() {
+	r.gate.Unlock(r.allowed())
+}
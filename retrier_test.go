@@ -0,0 +1,68 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/neild/gate"
+)
+
+func TestRetrier(t *testing.T) {
+	r := gate.NewRetrier(1*time.Millisecond, 4*time.Millisecond, 0)
+	if err := r.Wait(context.Background()); err != nil {
+		t.Fatalf("r.Wait before any failure = %v, want nil", err)
+	}
+	for _, want := range []time.Duration{
+		1 * time.Millisecond,
+		2 * time.Millisecond,
+		4 * time.Millisecond,
+		4 * time.Millisecond,
+	} {
+		if got := r.Failure(); got != want {
+			t.Fatalf("r.Failure() = %v, want %v", got, want)
+		}
+	}
+	if allowed := r.Allowed(); allowed {
+		t.Fatalf("r.Allowed after failure = %v, want false", allowed)
+	}
+	start := time.Now()
+	if err := r.Wait(context.Background()); err != nil {
+		t.Fatalf("r.Wait = %v, want nil", err)
+	}
+	if elapsed := time.Since(start); elapsed < 2*time.Millisecond {
+		t.Fatalf("r.Wait returned after %v, want at least backoff delay", elapsed)
+	}
+
+	r.Failure()
+	r.Success()
+	if allowed := r.Allowed(); !allowed {
+		t.Fatalf("r.Allowed after success = %v, want true", allowed)
+	}
+	if got, want := r.Failure(), 1*time.Millisecond; got != want {
+		t.Fatalf("r.Failure() after success = %v, want %v", got, want)
+	}
+}
+
+func TestRetrierJitter(t *testing.T) {
+	r := gate.NewRetrier(10*time.Millisecond, 10*time.Millisecond, 0.5)
+	for i := 0; i < 10; i++ {
+		if d := r.Failure(); d < 5*time.Millisecond || d > 10*time.Millisecond {
+			t.Fatalf("r.Failure() = %v, want between 5ms and 10ms", d)
+		}
+	}
+}
+
+func TestRetrierWaitContextExpired(t *testing.T) {
+	r := gate.NewRetrier(1*time.Hour, 1*time.Hour, 0)
+	r.Failure()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Millisecond)
+	defer cancel()
+	if err := r.Wait(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("r.Wait during backoff = %v, want context.DeadlineExceeded", err)
+	}
+}
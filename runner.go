@@ -0,0 +1,127 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate
+
+import (
+	"context"
+	"slices"
+)
+
+// A Runner runs functions in goroutines, with a limit on how many run at once.
+// Functions submitted beyond the limit wait in a bounded queue.
+//
+// A Runner is like errgroup.Group with SetLimit,
+// but submitters block only while the queue is full,
+// and both submitting and waiting may be bounded by a context.
+type Runner struct {
+	gate     Gate // set if no functions are running or queued
+	limit    int
+	maxQueue int
+	running  int
+	queue    []func() error
+	err      error         // first error returned by a function
+	roomc    chan struct{} // closed when a function starts or the limit changes
+}
+
+// NewRunner returns a runner which runs at most limit functions at once,
+// and queues at most queueSize more.
+func NewRunner(limit, queueSize int) *Runner {
+	return &Runner{
+		gate:     New(true),
+		limit:    limit,
+		maxQueue: queueSize,
+	}
+}
+
+// SetLimit changes the number of functions which may run at once.
+// If the limit is lowered, running functions are not affected.
+func (r *Runner) SetLimit(n int) {
+	r.gate.Lock()
+	defer r.unlock()
+	r.limit = n
+	for r.running < r.limit && len(r.queue) > 0 {
+		fn := r.queue[0]
+		r.queue = slices.Delete(r.queue, 0, 1)
+		r.start(fn)
+	}
+	r.room()
+}
+
+// Go runs fn in a new goroutine, or queues it if the runner is at its limit.
+// It blocks until fn is started or queued, or the context expires.
+func (r *Runner) Go(ctx context.Context, fn func() error) error {
+	for {
+		r.gate.Lock()
+		if r.running < r.limit {
+			r.start(fn)
+			r.unlock()
+			return nil
+		}
+		if len(r.queue) < r.maxQueue {
+			r.queue = append(r.queue, fn)
+			r.unlock()
+			return nil
+		}
+		if r.roomc == nil {
+			r.roomc = make(chan struct{})
+		}
+		roomc := r.roomc
+		r.unlock()
+		if err := r.gate.WaitFor(ctx, roomc); err != nil {
+			return err
+		}
+	}
+}
+
+// Wait blocks until all submitted functions have returned or the context expires.
+// It returns the first error returned by a function, if any.
+func (r *Runner) Wait(ctx context.Context) error {
+	if err := r.gate.WaitAndLock(ctx); err != nil {
+		return err
+	}
+	defer r.unlock()
+	return r.err
+}
+
+// start starts fn in a new goroutine.
+// The runner's gate must be held.
+func (r *Runner) start(fn func() error) {
+	r.running++
+	go r.run(fn)
+}
+
+// run runs fn, and then any queued functions while the runner is within its limit.
+func (r *Runner) run(fn func() error) {
+	for {
+		err := fn()
+		r.gate.Lock()
+		if err != nil && r.err == nil {
+			r.err = err
+		}
+		if len(r.queue) == 0 || r.running > r.limit {
+			r.running--
+			r.room()
+			r.unlock()
+			return
+		}
+		fn = r.queue[0]
+		r.queue = slices.Delete(r.queue, 0, 1)
+		r.room()
+		r.unlock()
+	}
+}
+
+// room wakes callers of Go waiting for room.
+// The runner's gate must be held.
+func (r *Runner) room() {
+	if r.roomc != nil {
+		close(r.roomc)
+		r.roomc = nil
+	}
+}
+
+func (r *Runner) unlock() {
+	r.gate.Unlock(r.running == 0 && len(r.queue) == 0)
+}
@@ -0,0 +1,102 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/neild/gate"
+)
+
+func TestRunner(t *testing.T) {
+	r := gate.NewRunner(2, 100)
+	var running, maxRunning, done atomic.Int32
+	for i := 0; i < 10; i++ {
+		err := r.Go(context.Background(), func() error {
+			n := running.Add(1)
+			for {
+				m := maxRunning.Load()
+				if n <= m || maxRunning.CompareAndSwap(m, n) {
+					break
+				}
+			}
+			time.Sleep(1 * time.Millisecond)
+			running.Add(-1)
+			done.Add(1)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("r.Go = %v, want nil", err)
+		}
+	}
+	if err := r.Wait(context.Background()); err != nil {
+		t.Fatalf("r.Wait = %v, want nil", err)
+	}
+	if got, want := done.Load(), int32(10); got != want {
+		t.Fatalf("%v functions ran, want %v", got, want)
+	}
+	if got, want := maxRunning.Load(), int32(2); got > want {
+		t.Fatalf("%v functions ran at once, want at most %v", got, want)
+	}
+}
+
+func TestRunnerQueueFull(t *testing.T) {
+	r := gate.NewRunner(1, 1)
+	releasec := make(chan struct{})
+	block := func() error {
+		<-releasec
+		return nil
+	}
+	r.Go(context.Background(), block) // running
+	r.Go(context.Background(), block) // queued
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Millisecond)
+	defer cancel()
+	if err := r.Go(ctx, block); err != context.DeadlineExceeded {
+		t.Fatalf("r.Go with full queue = %v, want context.DeadlineExceeded", err)
+	}
+	if err := r.Wait(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("r.Wait with running functions = %v, want context.DeadlineExceeded", err)
+	}
+	close(releasec)
+	if err := r.Wait(context.Background()); err != nil {
+		t.Fatalf("r.Wait = %v, want nil", err)
+	}
+}
+
+func TestRunnerSetLimit(t *testing.T) {
+	r := gate.NewRunner(0, 10)
+	var done atomic.Int32
+	for i := 0; i < 3; i++ {
+		r.Go(context.Background(), func() error {
+			done.Add(1)
+			return nil
+		})
+	}
+	time.Sleep(1 * time.Millisecond)
+	if got := done.Load(); got != 0 {
+		t.Fatalf("with limit 0, %v functions ran, want 0", got)
+	}
+	r.SetLimit(1)
+	if err := r.Wait(context.Background()); err != nil {
+		t.Fatalf("r.Wait = %v, want nil", err)
+	}
+	if got, want := done.Load(), int32(3); got != want {
+		t.Fatalf("after SetLimit, %v functions ran, want %v", got, want)
+	}
+}
+
+func TestRunnerError(t *testing.T) {
+	r := gate.NewRunner(1, 10)
+	wantErr := errors.New("failed")
+	r.Go(context.Background(), func() error { return wantErr })
+	r.Go(context.Background(), func() error { return errors.New("later") })
+	if err := r.Wait(context.Background()); err != wantErr {
+		t.Fatalf("r.Wait = %v, want %v", err, wantErr)
+	}
+}
@@ -0,0 +1,177 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate
+
+import "context"
+
+// An RWGate is a reader/writer gate.
+// It may be held by any number of readers or a single writer.
+//
+// Like a Gate, an RWGate has one bit of state, the condition.
+// Only the writer may change the condition, when it unlocks the gate.
+//
+// Waiting writers take precedence over new readers,
+// so a steady stream of readers does not starve a writer.
+type RWGate struct {
+	mu      Gate // guards the fields below
+	set     bool
+	writer  bool // held by a writer
+	readers int  // number of readers holding the gate
+	wwait   int  // writers waiting for the gate
+	wwaitc  int  // writers waiting for the gate and the condition
+	changed chan struct{}
+}
+
+// NewRWGate returns a new, unlocked gate with the given condition state.
+func NewRWGate(set bool) *RWGate {
+	return &RWGate{
+		mu:  New(false),
+		set: set,
+	}
+}
+
+// Lock acquires the gate for writing unconditionally.
+// It reports whether the condition was set.
+func (g *RWGate) Lock() (set bool) {
+	g.acquire(context.Background(), true, false)
+	return g.set
+}
+
+// WaitAndLock waits until the condition is set before acquiring the gate for writing.
+// If the context expires, WaitAndLock returns an error and does not acquire the gate.
+func (g *RWGate) WaitAndLock(ctx context.Context) error {
+	return g.acquire(ctx, true, true)
+}
+
+// LockIfSet acquires the gate for writing if and only if it is available and the condition is set.
+func (g *RWGate) LockIfSet() (acquired bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock(false)
+	if !g.canLock(true) {
+		return false
+	}
+	g.writer = true
+	return true
+}
+
+// Unlock sets the condition and releases the gate from writing.
+func (g *RWGate) Unlock(set bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock(false)
+	if !g.writer {
+		panic("gate: Unlock of RWGate not locked for writing")
+	}
+	g.writer = false
+	g.set = set
+	g.wake()
+}
+
+// RLock acquires the gate for reading unconditionally.
+// It reports whether the condition was set.
+func (g *RWGate) RLock() (set bool) {
+	g.acquire(context.Background(), false, false)
+	// The condition cannot change while the gate is held for reading.
+	return g.set
+}
+
+// WaitAndRLock waits until the condition is set before acquiring the gate for reading.
+// If the context expires, WaitAndRLock returns an error and does not acquire the gate.
+func (g *RWGate) WaitAndRLock(ctx context.Context) error {
+	return g.acquire(ctx, false, true)
+}
+
+// RLockIfSet acquires the gate for reading if and only if it is available and the condition is set.
+func (g *RWGate) RLockIfSet() (acquired bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock(false)
+	if !g.canRLock(true) {
+		return false
+	}
+	g.readers++
+	return true
+}
+
+// RUnlock releases the gate from reading.
+// Readers cannot change the condition.
+func (g *RWGate) RUnlock() {
+	g.mu.Lock()
+	defer g.mu.Unlock(false)
+	if g.readers == 0 {
+		panic("gate: RUnlock of RWGate not locked for reading")
+	}
+	g.readers--
+	if g.readers == 0 {
+		g.wake()
+	}
+}
+
+func (g *RWGate) canLock(needSet bool) bool {
+	return !g.writer && g.readers == 0 && (g.set || !needSet)
+}
+
+func (g *RWGate) canRLock(needSet bool) bool {
+	// Yield to waiting writers, unless they are waiting for a condition which isn't set.
+	writerWaiting := g.wwait > 0 || (g.wwaitc > 0 && g.set)
+	return !g.writer && !writerWaiting && (g.set || !needSet)
+}
+
+func (g *RWGate) acquire(ctx context.Context, write, needSet bool) error {
+	waiting := false
+	for {
+		g.mu.Lock()
+		if write && g.canLock(needSet) {
+			g.writer = true
+			if waiting {
+				g.unwait(needSet)
+			}
+			g.mu.Unlock(false)
+			return nil
+		}
+		if !write && g.canRLock(needSet) {
+			g.readers++
+			g.mu.Unlock(false)
+			return nil
+		}
+		if write && !waiting {
+			waiting = true
+			if needSet {
+				g.wwaitc++
+			} else {
+				g.wwait++
+			}
+		}
+		if g.changed == nil {
+			g.changed = make(chan struct{})
+		}
+		changed := g.changed
+		g.mu.Unlock(false)
+		if err := g.mu.WaitFor(ctx, changed); err != nil {
+			if waiting {
+				g.mu.Lock()
+				g.unwait(needSet)
+				// Readers may have been waiting on us.
+				g.wake()
+				g.mu.Unlock(false)
+			}
+			return err
+		}
+	}
+}
+
+func (g *RWGate) unwait(needSet bool) {
+	if needSet {
+		g.wwaitc--
+	} else {
+		g.wwait--
+	}
+}
+
+// wake wakes all goroutines waiting for the gate's state to change.
+func (g *RWGate) wake() {
+	if g.changed != nil {
+		close(g.changed)
+		g.changed = nil
+	}
+}
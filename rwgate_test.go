@@ -0,0 +1,99 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/neild/gate"
+)
+
+func TestRWGateMultipleReaders(t *testing.T) {
+	g := gate.NewRWGate(true)
+	if set := g.RLock(); !set {
+		t.Fatalf("g.RLock of set gate: false, want true")
+	}
+	if acquired := g.RLockIfSet(); !acquired {
+		t.Fatalf("g.RLockIfSet with reader holding set gate = %v, want true", acquired)
+	}
+	if acquired := g.LockIfSet(); acquired {
+		t.Fatalf("g.LockIfSet with readers holding gate = %v, want false", acquired)
+	}
+	g.RUnlock()
+	g.RUnlock()
+	if acquired := g.LockIfSet(); !acquired {
+		t.Fatalf("g.LockIfSet of unlocked set gate = %v, want true", acquired)
+	}
+	if acquired := g.RLockIfSet(); acquired {
+		t.Fatalf("g.RLockIfSet with writer holding gate = %v, want false", acquired)
+	}
+	g.Unlock(false)
+	if acquired := g.RLockIfSet(); acquired {
+		t.Fatalf("g.RLockIfSet of unset gate = %v, want false", acquired)
+	}
+	if set := g.RLock(); set {
+		t.Fatalf("g.RLock of unset gate: true, want false")
+	}
+	g.RUnlock()
+}
+
+func TestRWGateWaitAndLock(t *testing.T) {
+	g := gate.NewRWGate(false)
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Millisecond)
+	defer cancel()
+	if err := g.WaitAndLock(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("g.WaitAndLock = %v, want context.DeadlineExceeded", err)
+	}
+	if err := g.WaitAndRLock(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("g.WaitAndRLock = %v, want context.DeadlineExceeded", err)
+	}
+
+	// A writer waiting for the condition doesn't block readers.
+	writerc := make(chan error)
+	go func() {
+		writerc <- g.WaitAndLock(context.Background())
+	}()
+	time.Sleep(1 * time.Millisecond)
+	g.RLock()
+	g.RUnlock()
+
+	readerc := make(chan error)
+	go func() {
+		readerc <- g.WaitAndRLock(context.Background())
+	}()
+	time.Sleep(1 * time.Millisecond)
+	g.Lock()
+	g.Unlock(true)
+	if err := <-writerc; err != nil {
+		t.Fatalf("g.WaitAndLock = %v, want nil", err)
+	}
+	g.Unlock(true)
+	if err := <-readerc; err != nil {
+		t.Fatalf("g.WaitAndRLock = %v, want nil", err)
+	}
+	g.RUnlock()
+}
+
+func TestRWGateWriterPreference(t *testing.T) {
+	g := gate.NewRWGate(true)
+	g.RLock()
+	lockedc := make(chan struct{})
+	go func() {
+		g.Lock()
+		close(lockedc)
+		g.Unlock(true)
+	}()
+	time.Sleep(1 * time.Millisecond)
+	// A new reader does not jump ahead of the waiting writer.
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Millisecond)
+	defer cancel()
+	if err := g.WaitAndRLock(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("g.WaitAndRLock with waiting writer = %v, want context.DeadlineExceeded", err)
+	}
+	g.RUnlock()
+	<-lockedc
+}
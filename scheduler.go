@@ -0,0 +1,177 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate
+
+import (
+	"container/heap"
+	"context"
+	"time"
+)
+
+// A Scheduler holds tasks until the time they are scheduled for.
+// Workers receive due tasks by calling Get.
+type Scheduler[T any] struct {
+	mu    Gate // guards the fields below
+	clock Clock
+	tasks scheduleHeap[T]
+	seq   uint64
+	wakec chan struct{} // closed when the earliest task changes
+}
+
+// A ScheduledTask is a task held by a Scheduler.
+type ScheduledTask[T any] struct {
+	s     *Scheduler[T]
+	v     T
+	at    time.Time
+	seq   uint64 // tasks scheduled for the same time are delivered in order
+	index int    // index in s.tasks, or -1 if not scheduled; guarded by s.mu
+}
+
+// NewScheduler returns a new, empty scheduler.
+func NewScheduler[T any](opts ...Option) *Scheduler[T] {
+	o := newOptions(opts)
+	return &Scheduler[T]{
+		mu:    o.newGate(false, ""),
+		clock: o.clock,
+	}
+}
+
+// Schedule adds a task which becomes due at the given time.
+func (s *Scheduler[T]) Schedule(at time.Time, v T) *ScheduledTask[T] {
+	s.mu.Lock()
+	defer s.mu.Unlock(false)
+	t := &ScheduledTask[T]{
+		s:   s,
+		v:   v,
+		at:  at,
+		seq: s.seq,
+	}
+	s.seq++
+	heap.Push(&s.tasks, t)
+	if t.index == 0 {
+		s.wake()
+	}
+	return t
+}
+
+// Get removes the earliest due task,
+// blocking until ctx is done or a task is due.
+func (s *Scheduler[T]) Get(ctx context.Context) (T, error) {
+	var timer Timer
+	defer func() {
+		if timer != nil {
+			timer.Stop()
+		}
+	}()
+	for {
+		s.mu.Lock()
+		if len(s.tasks) > 0 {
+			delay := s.tasks[0].at.Sub(s.clock.Now())
+			if delay <= 0 {
+				t := heap.Pop(&s.tasks).(*ScheduledTask[T])
+				s.mu.Unlock(false)
+				return t.v, nil
+			}
+			// Wake when the earliest task becomes due.
+			if timer == nil {
+				timer = s.clock.AfterFunc(delay, s.wakeAll)
+			} else {
+				timer.Reset(delay)
+			}
+		}
+		if s.wakec == nil {
+			s.wakec = make(chan struct{})
+		}
+		wakec := s.wakec
+		s.mu.Unlock(false)
+		if err := s.mu.WaitFor(ctx, wakec); err != nil {
+			var zero T
+			return zero, err
+		}
+	}
+}
+
+// Len returns the number of scheduled tasks, including tasks which are due.
+func (s *Scheduler[T]) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock(false)
+	return len(s.tasks)
+}
+
+// Cancel removes the task from its scheduler.
+// It reports whether the task was removed,
+// which it is not if it has already been delivered or canceled.
+func (t *ScheduledTask[T]) Cancel() bool {
+	s := t.s
+	s.mu.Lock()
+	defer s.mu.Unlock(false)
+	if t.index < 0 {
+		return false
+	}
+	heap.Remove(&s.tasks, t.index)
+	return true
+}
+
+// Reschedule changes the time at which the task becomes due.
+// It reports whether the task was rescheduled,
+// which it is not if it has already been delivered or canceled.
+func (t *ScheduledTask[T]) Reschedule(at time.Time) bool {
+	s := t.s
+	s.mu.Lock()
+	defer s.mu.Unlock(false)
+	if t.index < 0 {
+		return false
+	}
+	t.at = at
+	heap.Fix(&s.tasks, t.index)
+	// Waiters may need to wake earlier or later than they planned.
+	s.wake()
+	return true
+}
+
+// wakeAll is wake, for calling without the scheduler's mu held.
+func (s *Scheduler[T]) wakeAll() {
+	s.mu.Lock()
+	defer s.mu.Unlock(false)
+	s.wake()
+}
+
+// wake wakes all callers of Get to recompute the earliest task.
+// The scheduler's mu must be held.
+func (s *Scheduler[T]) wake() {
+	if s.wakec != nil {
+		close(s.wakec)
+		s.wakec = nil
+	}
+}
+
+// scheduleHeap implements heap.Interface.
+type scheduleHeap[T any] []*ScheduledTask[T]
+
+func (h scheduleHeap[T]) Len() int { return len(h) }
+func (h scheduleHeap[T]) Less(i, j int) bool {
+	if !h[i].at.Equal(h[j].at) {
+		return h[i].at.Before(h[j].at)
+	}
+	return h[i].seq < h[j].seq
+}
+func (h scheduleHeap[T]) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+func (h *scheduleHeap[T]) Push(x any) {
+	t := x.(*ScheduledTask[T])
+	t.index = len(*h)
+	*h = append(*h, t)
+}
+func (h *scheduleHeap[T]) Pop() any {
+	old := *h
+	t := old[len(old)-1]
+	old[len(old)-1] = nil
+	*h = old[:len(old)-1]
+	t.index = -1
+	return t
+}
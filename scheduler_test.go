@@ -0,0 +1,82 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/neild/gate"
+)
+
+func TestScheduler(t *testing.T) {
+	s := gate.NewScheduler[string]()
+	now := time.Now()
+	s.Schedule(now.Add(2*time.Millisecond), "second")
+	s.Schedule(now.Add(1*time.Millisecond), "first")
+	s.Schedule(now.Add(3*time.Millisecond), "third")
+	for _, want := range []string{"first", "second", "third"} {
+		v, err := s.Get(context.Background())
+		if err != nil || v != want {
+			t.Fatalf("s.Get = %q, %v; want %q, nil", v, err, want)
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Millisecond)
+	defer cancel()
+	if _, err := s.Get(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("s.Get of empty scheduler = %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestSchedulerEarlierInsertion(t *testing.T) {
+	s := gate.NewScheduler[string]()
+	s.Schedule(time.Now().Add(1*time.Hour), "later")
+	gotc := make(chan string)
+	go func() {
+		v, _ := s.Get(context.Background())
+		gotc <- v
+	}()
+	time.Sleep(1 * time.Millisecond)
+	// A waiting Get wakes for a task scheduled earlier than the one it is waiting for.
+	s.Schedule(time.Now(), "now")
+	if v := <-gotc; v != "now" {
+		t.Fatalf("s.Get = %q, want %q", v, "now")
+	}
+}
+
+func TestSchedulerCancel(t *testing.T) {
+	s := gate.NewScheduler[int]()
+	task := s.Schedule(time.Now(), 1)
+	if ok := task.Cancel(); !ok {
+		t.Fatalf("task.Cancel = %v, want true", ok)
+	}
+	if ok := task.Cancel(); ok {
+		t.Fatalf("second task.Cancel = %v, want false", ok)
+	}
+	if got := s.Len(); got != 0 {
+		t.Fatalf("s.Len after Cancel = %v, want 0", got)
+	}
+}
+
+func TestSchedulerReschedule(t *testing.T) {
+	s := gate.NewScheduler[int]()
+	task := s.Schedule(time.Now().Add(1*time.Hour), 1)
+	gotc := make(chan int)
+	go func() {
+		v, _ := s.Get(context.Background())
+		gotc <- v
+	}()
+	time.Sleep(1 * time.Millisecond)
+	if ok := task.Reschedule(time.Now()); !ok {
+		t.Fatalf("task.Reschedule = %v, want true", ok)
+	}
+	if v := <-gotc; v != 1 {
+		t.Fatalf("s.Get = %v, want 1", v)
+	}
+	if ok := task.Reschedule(time.Now()); ok {
+		t.Fatalf("task.Reschedule after delivery = %v, want false", ok)
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate
+
+import "context"
+
+// A Scope runs a group of goroutines and waits for them to finish.
+//
+// The first error returned by a goroutine in the scope sets the Failed signal,
+// which other goroutines may watch to stop early.
+// Unlike errgroup, goroutines are not handed a context:
+// they observe failure through the signal, and may combine it with
+// whatever context they were given.
+type Scope struct {
+	mu      Gate // guards the fields below
+	running int
+	waiting bool  // Wait has been called
+	err     error // first error returned by a goroutine
+	failed  *Event
+	done    *Event // set when Wait has been called and no goroutines are running
+}
+
+// NewScope returns a new, empty Scope.
+func NewScope() *Scope {
+	return &Scope{
+		mu:     New(false),
+		failed: NewEvent(),
+		done:   NewEvent(),
+	}
+}
+
+// Go runs fn in a new goroutine in the scope.
+//
+// Go may be called by a goroutine in the scope while Wait is waiting.
+// It panics if called after the scope is done.
+func (s *Scope) Go(fn func() error) {
+	s.mu.Lock()
+	if s.done.IsSet() {
+		s.mu.Unlock(false)
+		panic("gate: Scope.Go after scope is done")
+	}
+	s.running++
+	s.mu.Unlock(false)
+	go func() {
+		err := fn()
+		s.mu.Lock()
+		defer s.mu.Unlock(false)
+		if err != nil && s.err == nil {
+			s.err = err
+			s.failed.SetError(err)
+		}
+		s.running--
+		s.checkDone()
+	}()
+}
+
+// Wait blocks until all goroutines in the scope have returned or the context expires.
+// It returns the first error returned by a goroutine, if any.
+func (s *Scope) Wait(ctx context.Context) error {
+	s.mu.Lock()
+	s.waiting = true
+	s.checkDone()
+	s.mu.Unlock(false)
+	if err := s.done.Wait(ctx); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock(false)
+	return s.err
+}
+
+// Failed returns a signal which is set with the first error returned by a goroutine in the scope.
+func (s *Scope) Failed() Signal {
+	return s.failed.Signal()
+}
+
+// Done returns a signal which is set when Wait has been called
+// and all goroutines in the scope have returned.
+func (s *Scope) Done() Signal {
+	return s.done.Signal()
+}
+
+// checkDone sets the done event if the scope is done.
+// The scope's mu must be held.
+func (s *Scope) checkDone() {
+	if s.waiting && s.running == 0 {
+		s.done.Set()
+	}
+}
@@ -0,0 +1,93 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/neild/gate"
+)
+
+func TestScope(t *testing.T) {
+	s := gate.NewScope()
+	var count atomic.Int32
+	for i := 0; i < 10; i++ {
+		s.Go(func() error {
+			time.Sleep(1 * time.Millisecond)
+			count.Add(1)
+			return nil
+		})
+	}
+	if err := s.Wait(context.Background()); err != nil {
+		t.Fatalf("s.Wait = %v, want nil", err)
+	}
+	if got, want := count.Load(), int32(10); got != want {
+		t.Fatalf("after s.Wait, %v goroutines finished, want %v", got, want)
+	}
+	if set := s.Done().IsSet(); !set {
+		t.Fatalf("s.Done().IsSet after Wait = %v, want true", set)
+	}
+	if set := s.Failed().IsSet(); set {
+		t.Fatalf("s.Failed().IsSet with no errors = %v, want false", set)
+	}
+}
+
+func TestScopeFailed(t *testing.T) {
+	s := gate.NewScope()
+	wantErr := errors.New("failed")
+	s.Go(func() error {
+		return wantErr
+	})
+	s.Go(func() error {
+		// Stop when another goroutine fails.
+		s.Failed().Wait(context.Background())
+		return errors.New("stopped")
+	})
+	if err := s.Wait(context.Background()); err != wantErr {
+		t.Fatalf("s.Wait = %v, want %v", err, wantErr)
+	}
+	if err := s.Failed().Wait(context.Background()); err != wantErr {
+		t.Fatalf("s.Failed().Wait = %v, want %v", err, wantErr)
+	}
+}
+
+func TestScopeNestedGo(t *testing.T) {
+	s := gate.NewScope()
+	var ran atomic.Bool
+	s.Go(func() error {
+		time.Sleep(1 * time.Millisecond)
+		s.Go(func() error {
+			time.Sleep(1 * time.Millisecond)
+			ran.Store(true)
+			return nil
+		})
+		return nil
+	})
+	if err := s.Wait(context.Background()); err != nil {
+		t.Fatalf("s.Wait = %v, want nil", err)
+	}
+	if !ran.Load() {
+		t.Fatalf("s.Wait returned before nested goroutine finished")
+	}
+}
+
+func TestScopeWaitContextExpired(t *testing.T) {
+	s := gate.NewScope()
+	stopc := make(chan struct{})
+	defer close(stopc)
+	s.Go(func() error {
+		<-stopc
+		return nil
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Millisecond)
+	defer cancel()
+	if err := s.Wait(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("s.Wait with running goroutine = %v, want context.DeadlineExceeded", err)
+	}
+}
@@ -0,0 +1,97 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate
+
+import "context"
+
+// A Semaphore is a counting semaphore with a fixed number of permits.
+//
+// Callers blocked in Acquire are admitted approximately in the order they began waiting.
+type Semaphore struct {
+	gate  Gate // set if permits are available
+	max   int
+	avail int // negative if the limit was lowered below the permits held
+	obs   instanceObserver
+	clock Clock // times waits for the observer
+}
+
+// NewSemaphore returns a new semaphore with n permits, all available.
+// The options a semaphore accepts are WithName, WithObserver, and WithClock.
+func NewSemaphore(n int, opts ...Option) *Semaphore {
+	o := newOptions(opts)
+	return &Semaphore{
+		gate:  o.newGate(n > 0, ""),
+		max:   n,
+		avail: n,
+		obs:   instanceObserver{o.observer},
+		clock: o.clock,
+	}
+}
+
+// Acquire acquires a permit, blocking until one is available.
+// If the context expires, Acquire returns an error and does not acquire a permit.
+func (s *Semaphore) Acquire(ctx context.Context) error {
+	if !s.gate.LockIfSet() {
+		start := s.clock.Now()
+		if err := s.gate.WaitAndLock(ctx); err != nil {
+			return err
+		}
+		s.obs.timing("semaphore.wait", s.clock.Now().Sub(start))
+	}
+	s.avail--
+	s.obs.count("semaphore.acquired", 1)
+	s.unlock()
+	return nil
+}
+
+// TryAcquire acquires a permit if one is available without blocking.
+// It reports whether a permit was acquired.
+func (s *Semaphore) TryAcquire() (acquired bool) {
+	if !s.gate.LockIfSet() {
+		return false
+	}
+	s.avail--
+	s.obs.count("semaphore.acquired", 1)
+	s.unlock()
+	return true
+}
+
+// Release releases a permit.
+// It panics if more permits are released than were acquired.
+func (s *Semaphore) Release() {
+	s.gate.Lock()
+	defer s.unlock()
+	if s.avail == s.max {
+		panic("gate: Semaphore released more permits than acquired")
+	}
+	s.avail++
+	s.obs.count("semaphore.released", 1)
+}
+
+// SetLimit changes the number of permits.
+// If the limit is lowered below the number of permits held,
+// no permits are revoked, but no more are granted until enough have been released.
+func (s *Semaphore) SetLimit(n int) {
+	s.gate.Lock()
+	defer s.unlock()
+	s.avail += n - s.max
+	s.max = n
+}
+
+// SetObserver sets the semaphore's observer.
+// The semaphore reports the "semaphore.acquired" and "semaphore.released" counters,
+// the "semaphore.wait" time of each Acquire which had to wait,
+// and the "semaphore.available" gauge.
+// If no observer is set, counters and timings are reported to the package-wide observer.
+func (s *Semaphore) SetObserver(o Observer) {
+	s.gate.Lock()
+	defer s.unlock()
+	s.obs.o = o
+}
+
+func (s *Semaphore) unlock() {
+	s.obs.gauge("semaphore.available", int64(s.avail))
+	s.gate.Unlock(s.avail > 0)
+}
@@ -0,0 +1,127 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/neild/gate"
+)
+
+func TestSemaphoreAcquireAndRelease(t *testing.T) {
+	s := gate.NewSemaphore(2)
+	for i := 0; i < 2; i++ {
+		if err := s.Acquire(context.Background()); err != nil {
+			t.Fatalf("s.Acquire #%v = %v, want nil", i, err)
+		}
+	}
+	if acquired := s.TryAcquire(); acquired {
+		t.Fatalf("s.TryAcquire with no permits = %v, want false", acquired)
+	}
+	s.Release()
+	if acquired := s.TryAcquire(); !acquired {
+		t.Fatalf("s.TryAcquire after Release = %v, want true", acquired)
+	}
+}
+
+func TestSemaphoreAcquireCanceled(t *testing.T) {
+	s := gate.NewSemaphore(1)
+	s.Acquire(context.Background())
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Millisecond)
+	defer cancel()
+	if err := s.Acquire(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("s.Acquire = %v, want context.DeadlineExceeded", err)
+	}
+	// The canceled Acquire did not consume a permit.
+	s.Release()
+	if acquired := s.TryAcquire(); !acquired {
+		t.Fatalf("s.TryAcquire after Release = %v, want true", acquired)
+	}
+	// Acquire succeeds when a permit is available and the context is canceled.
+	s.Release()
+	if err := s.Acquire(ctx); err != nil {
+		t.Fatalf("s.Acquire with expired context = %v, want nil", err)
+	}
+}
+
+func TestSemaphoreFairness(t *testing.T) {
+	s := gate.NewSemaphore(1)
+	s.Acquire(context.Background())
+	var (
+		mu    sync.Mutex
+		order []int
+		wg    sync.WaitGroup
+	)
+	const waiters = 5
+	for i := 0; i < waiters; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.Acquire(context.Background())
+			mu.Lock()
+			order = append(order, i)
+			mu.Unlock()
+			s.Release()
+		}()
+		// Give each waiter time to block before starting the next.
+		time.Sleep(1 * time.Millisecond)
+	}
+	s.Release()
+	wg.Wait()
+	for i, got := range order {
+		if got != i {
+			t.Fatalf("waiters admitted in order %v, want FIFO", order)
+		}
+	}
+}
+
+func TestSemaphoreOverRelease(t *testing.T) {
+	s := gate.NewSemaphore(1)
+	defer func() {
+		if recover() == nil {
+			t.Errorf("s.Release with no permits acquired did not panic")
+		}
+	}()
+	s.Release()
+}
+
+func TestSemaphoreSetLimit(t *testing.T) {
+	s := gate.NewSemaphore(2)
+	s.Acquire(context.Background())
+	s.Acquire(context.Background())
+
+	// Growing the limit admits a waiter.
+	donec := make(chan error)
+	go func() {
+		donec <- s.Acquire(context.Background())
+	}()
+	time.Sleep(1 * time.Millisecond)
+	s.SetLimit(3)
+	if err := <-donec; err != nil {
+		t.Fatalf("s.Acquire after growing limit = %v, want nil", err)
+	}
+
+	// Shrinking the limit below the permits held waits for them to be released.
+	s.SetLimit(1)
+	s.Release()
+	s.Release()
+	if acquired := s.TryAcquire(); acquired {
+		t.Fatalf("s.TryAcquire with 1 of 1 permits held = %v, want false", acquired)
+	}
+	s.Release()
+	if acquired := s.TryAcquire(); !acquired {
+		t.Fatalf("s.TryAcquire with 0 of 1 permits held = %v, want true", acquired)
+	}
+	s.Release()
+	defer func() {
+		if recover() == nil {
+			t.Errorf("over-release after shrinking limit did not panic")
+		}
+	}()
+	s.Release()
+}
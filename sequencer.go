@@ -0,0 +1,80 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate
+
+import "context"
+
+// A Sequencer orders work by sequence number.
+// Work items may be processed in parallel, with each item waiting for its turn
+// to commit its results until every earlier item has been released.
+type Sequencer struct {
+	mu       Gate                // guards the fields below
+	next     uint64              // lowest unreleased sequence number
+	released map[uint64]struct{} // released sequence numbers above next
+	changed  chan struct{}       // closed when next advances
+}
+
+// NewSequencer returns a new sequencer whose first sequence number is start.
+func NewSequencer(start uint64) *Sequencer {
+	return &Sequencer{
+		mu:       New(false),
+		next:     start,
+		released: make(map[uint64]struct{}),
+	}
+}
+
+// WaitForTurn blocks until every sequence number before seq has been released,
+// or the context expires.
+func (s *Sequencer) WaitForTurn(ctx context.Context, seq uint64) error {
+	for {
+		s.mu.Lock()
+		if s.next >= seq {
+			s.mu.Unlock(false)
+			return nil
+		}
+		if s.changed == nil {
+			s.changed = make(chan struct{})
+		}
+		changed := s.changed
+		s.mu.Unlock(false)
+		if err := s.mu.WaitFor(ctx, changed); err != nil {
+			return err
+		}
+	}
+}
+
+// Release releases a sequence number.
+// Sequence numbers may be released in any order.
+// It panics if seq has already been released.
+func (s *Sequencer) Release(seq uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock(false)
+	if _, ok := s.released[seq]; ok || seq < s.next {
+		panic("gate: Sequencer sequence number released twice")
+	}
+	if seq != s.next {
+		s.released[seq] = struct{}{}
+		return
+	}
+	s.next++
+	for {
+		if _, ok := s.released[s.next]; !ok {
+			break
+		}
+		delete(s.released, s.next)
+		s.next++
+	}
+	if s.changed != nil {
+		close(s.changed)
+		s.changed = nil
+	}
+}
+
+// Next returns the lowest sequence number which has not been released.
+func (s *Sequencer) Next() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock(false)
+	return s.next
+}
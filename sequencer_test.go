@@ -0,0 +1,84 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate_test
+
+import (
+	"context"
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/neild/gate"
+)
+
+func TestSequencer(t *testing.T) {
+	s := gate.NewSequencer(0)
+	if err := s.WaitForTurn(context.Background(), 0); err != nil {
+		t.Fatalf("s.WaitForTurn(0) = %v, want nil", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Millisecond)
+	defer cancel()
+	if err := s.WaitForTurn(ctx, 2); err != context.DeadlineExceeded {
+		t.Fatalf("s.WaitForTurn(2) before release = %v, want context.DeadlineExceeded", err)
+	}
+
+	// Releasing out of order advances only when the gap is filled.
+	s.Release(1)
+	if got, want := s.Next(), uint64(0); got != want {
+		t.Fatalf("after s.Release(1), s.Next() = %v, want %v", got, want)
+	}
+	s.Release(0)
+	if got, want := s.Next(), uint64(2); got != want {
+		t.Fatalf("after s.Release(0), s.Next() = %v, want %v", got, want)
+	}
+	if err := s.WaitForTurn(context.Background(), 2); err != nil {
+		t.Fatalf("s.WaitForTurn(2) = %v, want nil", err)
+	}
+}
+
+func TestSequencerInOrderCommit(t *testing.T) {
+	s := gate.NewSequencer(0)
+	const n = 20
+	var (
+		mu  sync.Mutex
+		got []int
+		wg  sync.WaitGroup
+	)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// Process in parallel.
+			time.Sleep(time.Duration(rand.IntN(1000)) * time.Microsecond)
+			// Commit in order.
+			s.WaitForTurn(context.Background(), uint64(i))
+			mu.Lock()
+			got = append(got, i)
+			mu.Unlock()
+			s.Release(uint64(i))
+		}()
+	}
+	wg.Wait()
+	want := make([]int, n)
+	for i := range want {
+		want[i] = i
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("commit order = %v, want %v", got, want)
+	}
+}
+
+func TestSequencerReleaseTwice(t *testing.T) {
+	s := gate.NewSequencer(0)
+	s.Release(0)
+	defer func() {
+		if recover() == nil {
+			t.Errorf("second s.Release(0) did not panic")
+		}
+	}()
+	s.Release(0)
+}
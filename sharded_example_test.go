@@ -0,0 +1,197 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate_test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"sync/atomic"
+
+	"github.com/neild/gate"
+)
+
+// A ShardedQueue is an unbounded multi-producer, multi-consumer queue
+// which spreads its items across several independently locked shards.
+// Items put into different shards may be returned in any order.
+type ShardedQueue[T any] struct {
+	shards []queueShard[T]
+	next   atomic.Uint64 // shard for the next Put
+
+	// Consumers which find every shard empty sleep on the idle gate.
+	// Producers only touch the idle gate when there are sleepers,
+	// so it is not contended when consumers are busy.
+	sleepers atomic.Int64
+	idle     gate.Gate // set if wakeups > 0 or closed
+	wakeups  int
+	closed   bool
+}
+
+type queueShard[T any] struct {
+	gate gate.Gate // set if shard is non-empty or closed
+	err  error
+	q    []T
+}
+
+// NewShardedQueue returns a new queue with n shards.
+// The n must be positive.
+func NewShardedQueue[T any](n int) *ShardedQueue[T] {
+	if n <= 0 {
+		panic("gate: ShardedQueue must have at least one shard")
+	}
+	q := &ShardedQueue[T]{
+		shards: make([]queueShard[T], n),
+		idle:   gate.New(false),
+	}
+	for i := range q.shards {
+		q.shards[i].gate = gate.New(false)
+	}
+	return q
+}
+
+// Close closes the queue, causing pending and future pop operations
+// to return immediately with err, or io.EOF if err is nil.
+func (q *ShardedQueue[T]) Close(err error) {
+	if err == nil {
+		err = io.EOF
+	}
+	for i := range q.shards {
+		s := &q.shards[i]
+		s.gate.Lock()
+		if s.err == nil {
+			s.err = err
+		}
+		s.unlock()
+	}
+	q.idle.Lock()
+	q.closed = true
+	q.idle.Unlock(true)
+}
+
+// Put adds an item to one of the queue's shards.
+// It returns true if the item was added, false if the queue is closed.
+func (q *ShardedQueue[T]) Put(v T) bool {
+	s := &q.shards[q.next.Add(1)%uint64(len(q.shards))]
+	s.gate.Lock()
+	if s.err != nil {
+		s.unlock()
+		return false
+	}
+	s.q = append(s.q, v)
+	s.unlock()
+	// If a consumer checked this shard before we added the item,
+	// it has already registered as a sleeper.
+	if q.sleepers.Load() > 0 {
+		q.wake()
+	}
+	return true
+}
+
+// Get removes an item from the queue, blocking until ctx is done, an item is available,
+// or the queue is closed.
+func (q *ShardedQueue[T]) Get(ctx context.Context) (T, error) {
+	for {
+		if v, ok, err := q.tryGet(false); ok {
+			return v, err
+		}
+		// Register as a sleeper before checking the shards again.
+		// Either a concurrent Put sees the registration and wakes us,
+		// or we see its item.
+		// This check waits for shards locked by other goroutines,
+		// so that we do not sleep while a busy shard holds items.
+		q.sleepers.Add(1)
+		if v, ok, err := q.tryGet(true); ok {
+			q.sleepers.Add(-1)
+			return v, err
+		}
+		err := q.idle.WaitAndLock(ctx)
+		q.sleepers.Add(-1)
+		if err != nil {
+			var zero T
+			return zero, err
+		}
+		if q.wakeups > 0 {
+			q.wakeups--
+		}
+		q.idle.Unlock(q.closed || q.wakeups > 0)
+	}
+}
+
+// tryGet removes an item from the first non-empty shard, starting at a random shard.
+// If wait is false, it skips shards which are locked by another goroutine.
+// If wait is true, it waits to lock each shard in turn.
+func (q *ShardedQueue[T]) tryGet(wait bool) (v T, ok bool, err error) {
+	start := rand.IntN(len(q.shards))
+	for i := range q.shards {
+		s := &q.shards[(start+i)%len(q.shards)]
+		if wait {
+			if !s.gate.Lock() {
+				s.unlock()
+				continue
+			}
+		} else if !s.gate.LockIfSet() {
+			continue
+		}
+		if s.err != nil {
+			err = s.err
+		} else {
+			v = s.q[0]
+			s.q = slices.Delete(s.q, 0, 1)
+		}
+		s.unlock()
+		return v, true, err
+	}
+	return v, false, nil
+}
+
+// wake wakes a sleeping consumer.
+func (q *ShardedQueue[T]) wake() {
+	q.idle.Lock()
+	q.wakeups++
+	q.idle.Unlock(true)
+}
+
+// unlock unlocks the shard's gate,
+// setting the condition to true if the shard is non-empty or closed.
+func (s *queueShard[T]) unlock() {
+	s.gate.Unlock(s.err != nil || len(s.q) > 0)
+}
+
+func Example_shardedQueue() {
+	q := NewShardedQueue[int](4)
+
+	var wg sync.WaitGroup
+	gotc := make(chan int)
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				v, err := q.Get(context.Background())
+				if err != nil {
+					return
+				}
+				gotc <- v
+			}
+		}()
+	}
+	for i := range 10 {
+		q.Put(i)
+	}
+	var got []int
+	for range 10 {
+		got = append(got, <-gotc)
+	}
+	q.Close(io.EOF)
+	wg.Wait()
+
+	slices.Sort(got)
+	fmt.Println(got)
+	// Output:
+	// [0 1 2 3 4 5 6 7 8 9]
+}
@@ -0,0 +1,28 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate_test
+
+import (
+	"context"
+	"io"
+	"testing"
+)
+
+func TestShardedQueueNoShards(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("NewShardedQueue(0) did not panic")
+		}
+	}()
+	NewShardedQueue[int](0)
+}
+
+func TestShardedQueueCloseNil(t *testing.T) {
+	q := NewShardedQueue[int](2)
+	q.Close(nil)
+	if _, err := q.Get(context.Background()); err != io.EOF {
+		t.Errorf("Get after Close(nil) = %v, want io.EOF", err)
+	}
+}
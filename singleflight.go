@@ -0,0 +1,85 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate
+
+import "context"
+
+// A SingleFlight suppresses duplicate concurrent calls for the same key.
+//
+// Each caller waits for the shared call with its own context,
+// and may give up without affecting other callers.
+// The shared call's context is canceled only when every caller has given up.
+type SingleFlight[K comparable, V any] struct {
+	mu    Gate // guards calls
+	calls map[K]*flightCall[V]
+}
+
+type flightCall[V any] struct {
+	done    *Event // set when the call completes
+	v       V
+	err     error
+	waiters int // guarded by SingleFlight.mu
+	cancel  context.CancelFunc
+}
+
+// NewSingleFlight returns a new SingleFlight.
+func NewSingleFlight[K comparable, V any]() *SingleFlight[K, V] {
+	return &SingleFlight[K, V]{
+		mu:    New(false),
+		calls: make(map[K]*flightCall[V]),
+	}
+}
+
+// Do calls fn and returns its results, sharing a single call among concurrent callers
+// with the same key. The call runs in its own goroutine.
+//
+// The context passed to fn carries the values of the context of the caller which started it.
+// It is canceled when every caller waiting for the call has returned due to an expired context.
+//
+// If ctx expires before the call completes, Do returns the context's error.
+func (g *SingleFlight[K, V]) Do(ctx context.Context, key K, fn func(context.Context) (V, error)) (V, error) {
+	g.mu.Lock()
+	c := g.calls[key]
+	if c == nil {
+		callCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		c = &flightCall[V]{
+			done:   NewEvent(),
+			cancel: cancel,
+		}
+		g.calls[key] = c
+		go g.run(callCtx, key, c, fn)
+	}
+	c.waiters++
+	g.mu.Unlock(false)
+
+	if err := c.done.Wait(ctx); err == nil {
+		return c.v, c.err
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock(false)
+	if c.done.IsSet() {
+		// The call completed while we were giving up.
+		return c.v, c.err
+	}
+	c.waiters--
+	if c.waiters == 0 {
+		// Nobody is interested in the result any more.
+		c.cancel()
+		delete(g.calls, key)
+	}
+	var zero V
+	return zero, ctx.Err()
+}
+
+func (g *SingleFlight[K, V]) run(ctx context.Context, key K, c *flightCall[V], fn func(context.Context) (V, error)) {
+	defer c.cancel()
+	c.v, c.err = fn(ctx)
+	g.mu.Lock()
+	if g.calls[key] == c {
+		delete(g.calls, key)
+	}
+	c.done.Set()
+	g.mu.Unlock(false)
+}
@@ -0,0 +1,87 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate_test
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/neild/gate"
+)
+
+func TestSingleFlightSharesCall(t *testing.T) {
+	g := gate.NewSingleFlight[string, int]()
+	var calls atomic.Int32
+	releasec := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, err := g.Do(context.Background(), "k", func(context.Context) (int, error) {
+				calls.Add(1)
+				<-releasec
+				return 42, nil
+			})
+			if v != 42 || err != nil {
+				t.Errorf("g.Do = %v, %v; want 42, nil", v, err)
+			}
+		}()
+	}
+	time.Sleep(1 * time.Millisecond)
+	close(releasec)
+	wg.Wait()
+	if got := calls.Load(); got != 1 {
+		t.Fatalf("fn called %v times, want 1", got)
+	}
+}
+
+func TestSingleFlightCancellation(t *testing.T) {
+	g := gate.NewSingleFlight[string, int]()
+	startedc := make(chan struct{})
+	canceledc := make(chan struct{})
+	fn := func(ctx context.Context) (int, error) {
+		close(startedc)
+		<-ctx.Done()
+		close(canceledc)
+		return 0, ctx.Err()
+	}
+
+	ctx1, cancel1 := context.WithCancel(context.Background())
+	err1c := make(chan error)
+	go func() {
+		_, err := g.Do(ctx1, "k", fn)
+		err1c <- err
+	}()
+	<-startedc
+	ctx2, cancel2 := context.WithCancel(context.Background())
+	err2c := make(chan error)
+	go func() {
+		_, err := g.Do(ctx2, "k", fn)
+		err2c <- err
+	}()
+	time.Sleep(1 * time.Millisecond)
+
+	// One caller giving up does not cancel the call.
+	cancel1()
+	if err := <-err1c; err != context.Canceled {
+		t.Fatalf("g.Do = %v, want context.Canceled", err)
+	}
+	select {
+	case <-canceledc:
+		t.Fatalf("call canceled while a caller is still waiting")
+	case <-time.After(1 * time.Millisecond):
+	}
+
+	// The last caller giving up does.
+	cancel2()
+	if err := <-err2c; err != context.Canceled {
+		t.Fatalf("g.Do = %v, want context.Canceled", err)
+	}
+	<-canceledc
+}
@@ -0,0 +1,96 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate
+
+import (
+	"context"
+	"slices"
+	"time"
+)
+
+// A SlidingWindowLimiter permits at most a fixed number of events
+// in any trailing window of time.
+//
+// Unlike a token bucket, it never permits a burst which would exceed
+// the limit within the window, matching quotas expressed as "N requests per minute".
+type SlidingWindowLimiter struct {
+	mu     Gate // guards the fields below
+	clock  Clock
+	limit  int
+	window time.Duration
+	times  []time.Time // times of events in the current window, oldest first
+}
+
+// NewSlidingWindowLimiter returns a limiter permitting limit events per window.
+// The limit and window must be positive.
+func NewSlidingWindowLimiter(limit int, window time.Duration, opts ...Option) *SlidingWindowLimiter {
+	if limit <= 0 {
+		panic("gate: sliding window limit must be positive")
+	}
+	if window <= 0 {
+		panic("gate: sliding window must be positive")
+	}
+	o := newOptions(opts)
+	return &SlidingWindowLimiter{
+		mu:     o.newGate(false, ""),
+		clock:  o.clock,
+		limit:  limit,
+		window: window,
+	}
+}
+
+// Allow reports whether an event may happen now, recording it if so.
+func (l *SlidingWindowLimiter) Allow() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock(false)
+	_, ok := l.reserve(l.clock.Now())
+	return ok
+}
+
+// Wait blocks until an event may happen or the context expires.
+// If no event can happen before the context's deadline,
+// Wait returns ErrWouldExceedDeadline immediately.
+func (l *SlidingWindowLimiter) Wait(ctx context.Context) error {
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		l.mu.Lock()
+		now := l.clock.Now()
+		at, ok := l.reserve(now)
+		l.mu.Unlock(false)
+		if ok {
+			return nil
+		}
+		if deadline, ok := ctx.Deadline(); ok && at.After(deadline) {
+			return ErrWouldExceedDeadline
+		}
+		// Another waiter may take the slot when it frees up,
+		// in which case we wait again.
+		t := l.clock.NewTimer(at.Sub(now))
+		select {
+		case <-t.C():
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		}
+	}
+}
+
+// reserve records an event at now if the limit permits it.
+// If not, it returns the time at which the next event will be permitted.
+func (l *SlidingWindowLimiter) reserve(now time.Time) (at time.Time, ok bool) {
+	start := now.Add(-l.window)
+	i := 0
+	for i < len(l.times) && !l.times[i].After(start) {
+		i++
+	}
+	l.times = slices.Delete(l.times, 0, i)
+	if len(l.times) < l.limit {
+		l.times = append(l.times, now)
+		return now, true
+	}
+	return l.times[len(l.times)-l.limit].Add(l.window), false
+}
@@ -0,0 +1,65 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/neild/gate"
+)
+
+func TestSlidingWindowLimiter(t *testing.T) {
+	l := gate.NewSlidingWindowLimiter(2, 10*time.Millisecond)
+	for i := 0; i < 2; i++ {
+		if ok := l.Allow(); !ok {
+			t.Fatalf("l.Allow #%v = %v, want true", i, ok)
+		}
+	}
+	if ok := l.Allow(); ok {
+		t.Fatalf("l.Allow over limit = %v, want false", ok)
+	}
+
+	start := time.Now()
+	if err := l.Wait(context.Background()); err != nil {
+		t.Fatalf("l.Wait = %v, want nil", err)
+	}
+	if elapsed := time.Since(start); elapsed < 5*time.Millisecond {
+		t.Fatalf("l.Wait returned after %v, want it to wait for the window", elapsed)
+	}
+}
+
+func TestSlidingWindowLimiterDeadline(t *testing.T) {
+	l := gate.NewSlidingWindowLimiter(1, 1*time.Hour)
+	l.Allow()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	if err := l.Wait(ctx); err != gate.ErrWouldExceedDeadline {
+		t.Fatalf("l.Wait with short deadline = %v, want %v", err, gate.ErrWouldExceedDeadline)
+	}
+}
+
+func TestSlidingWindowLimiterContextExpired(t *testing.T) {
+	l := gate.NewSlidingWindowLimiter(1, 1*time.Hour)
+	l.Allow()
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(1 * time.Millisecond)
+		cancel()
+	}()
+	if err := l.Wait(ctx); err != context.Canceled {
+		t.Fatalf("l.Wait with canceled context = %v, want context.Canceled", err)
+	}
+}
+
+func TestSlidingWindowLimiterZeroLimit(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("gate.NewSlidingWindowLimiter(0, 1s) did not panic")
+		}
+	}()
+	gate.NewSlidingWindowLimiter(0, 1*time.Second)
+}
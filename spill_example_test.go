@@ -0,0 +1,213 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate_test
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+
+	"github.com/neild/gate"
+)
+
+// A SpillQueue is an unbounded queue which holds a bounded number of items in memory.
+// Items beyond the in-memory bound are encoded and written to a temporary file,
+// and read back as the queue drains.
+type SpillQueue[T any] struct {
+	gate   gate.Gate // set if queue is non-empty or closed
+	max    int
+	encode func(T) ([]byte, error)
+	decode func([]byte) (T, error)
+	err    error
+	mem    []T
+
+	// Items spilled to disk.
+	// Once any items are spilled, new items are spilled until the file is drained
+	// to preserve FIFO order.
+	f      *os.File
+	nspill int
+	roff   int64 // offset of next item to read
+	woff   int64 // offset of next item to write
+}
+
+// NewSpillQueue returns a new queue holding at most max items in memory.
+// The encode and decode functions convert items to and from their on-disk form.
+// The max must be positive.
+func NewSpillQueue[T any](max int, encode func(T) ([]byte, error), decode func([]byte) (T, error)) *SpillQueue[T] {
+	if max <= 0 {
+		panic("gate: SpillQueue max must be positive")
+	}
+	return &SpillQueue[T]{
+		gate:   gate.New(false),
+		max:    max,
+		encode: encode,
+		decode: decode,
+	}
+}
+
+// Close closes the queue, causing pending and future pop operations
+// to return immediately with err, or io.EOF if err is nil.
+// It discards any items in the queue and removes the spill file.
+func (q *SpillQueue[T]) Close(err error) {
+	q.gate.Lock()
+	defer q.unlock()
+	if err == nil {
+		err = io.EOF
+	}
+	q.close(err)
+}
+
+// close closes the queue with err, if it is not already closed,
+// and discards its items.
+// The queue's gate must be held.
+func (q *SpillQueue[T]) close(err error) {
+	if q.err == nil {
+		q.err = err
+	}
+	q.mem = nil
+	if q.f != nil {
+		q.f.Close()
+		os.Remove(q.f.Name())
+		q.f = nil
+		q.nspill = 0
+	}
+}
+
+// Put appends an item to the queue.
+// It returns an error if the queue is closed or the item could not be spilled to disk.
+func (q *SpillQueue[T]) Put(v T) error {
+	q.gate.Lock()
+	defer q.unlock()
+	if q.err != nil {
+		return q.err
+	}
+	if q.nspill == 0 && len(q.mem) < q.max {
+		q.mem = append(q.mem, v)
+		return nil
+	}
+	return q.spill(v)
+}
+
+// Get removes the first item from the queue, blocking until ctx is done, an item is available,
+// or the queue is closed.
+func (q *SpillQueue[T]) Get(ctx context.Context) (T, error) {
+	var zero T
+	if err := q.gate.WaitAndLock(ctx); err != nil {
+		return zero, err
+	}
+	defer q.unlock()
+	if q.err != nil {
+		return zero, q.err
+	}
+	if len(q.mem) == 0 {
+		if err := q.unspill(); err != nil {
+			// The spill file can't be trusted past a bad record,
+			// so the remaining items are lost.
+			q.close(err)
+			return zero, q.err
+		}
+	}
+	v := q.mem[0]
+	q.mem = slices.Delete(q.mem, 0, 1)
+	return v, nil
+}
+
+// spill writes an item to the spill file.
+// The queue's gate must be held.
+func (q *SpillQueue[T]) spill(v T) error {
+	b, err := q.encode(v)
+	if err != nil {
+		return err
+	}
+	if q.f == nil {
+		f, err := os.CreateTemp("", "spillqueue")
+		if err != nil {
+			return err
+		}
+		q.f = f
+	}
+	buf := binary.AppendUvarint(nil, uint64(len(b)))
+	buf = append(buf, b...)
+	if _, err := q.f.WriteAt(buf, q.woff); err != nil {
+		return err
+	}
+	q.woff += int64(len(buf))
+	q.nspill++
+	return nil
+}
+
+// unspill reads items from the spill file into memory.
+// It returns an error if the file is unreadable or corrupt, or an item can't be decoded.
+// The queue's gate must be held.
+func (q *SpillQueue[T]) unspill() error {
+	for len(q.mem) < q.max && q.nspill > 0 {
+		var hdr [binary.MaxVarintLen64]byte
+		// The header may be shorter than hdr, so a short read is expected at the end of the file.
+		n, err := q.f.ReadAt(hdr[:], q.roff)
+		if n == 0 {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return fmt.Errorf("spillqueue: reading spill file: %w", err)
+		}
+		size, hlen := binary.Uvarint(hdr[:n])
+		if hlen <= 0 || size > uint64(q.woff-q.roff) {
+			return errors.New("spillqueue: corrupt spill file")
+		}
+		b := make([]byte, size)
+		if _, err := q.f.ReadAt(b, q.roff+int64(hlen)); err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return fmt.Errorf("spillqueue: reading spill file: %w", err)
+		}
+		v, err := q.decode(b)
+		if err != nil {
+			return fmt.Errorf("spillqueue: decoding item: %w", err)
+		}
+		q.mem = append(q.mem, v)
+		q.roff += int64(hlen) + int64(size)
+		q.nspill--
+	}
+	if q.nspill == 0 {
+		// Reuse the file from the start.
+		q.roff, q.woff = 0, 0
+		q.f.Truncate(0)
+	}
+	return nil
+}
+
+// unlock unlocks the queue's gate,
+// setting the condition to true if the queue is non-empty or closed.
+func (q *SpillQueue[T]) unlock() {
+	q.gate.Unlock(q.err != nil || len(q.mem) > 0 || q.nspill > 0)
+}
+
+func Example_spillQueue() {
+	// A queue holding at most two items in memory.
+	q := NewSpillQueue(2,
+		func(v int) ([]byte, error) { return strconv.AppendInt(nil, int64(v), 10), nil },
+		func(b []byte) (int, error) { return strconv.Atoi(string(b)) },
+	)
+	defer q.Close(io.EOF)
+
+	for i := range 5 {
+		q.Put(i)
+	}
+	for range 5 {
+		fmt.Println(q.Get(context.Background()))
+	}
+	// Output:
+	// 0 <nil>
+	// 1 <nil>
+	// 2 <nil>
+	// 3 <nil>
+	// 4 <nil>
+}
@@ -0,0 +1,77 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"strconv"
+	"testing"
+)
+
+func newIntSpillQueue(max int) *SpillQueue[int] {
+	return NewSpillQueue(max,
+		func(v int) ([]byte, error) { return strconv.AppendInt(nil, int64(v), 10), nil },
+		func(b []byte) (int, error) { return strconv.Atoi(string(b)) },
+	)
+}
+
+func TestSpillQueueCloseNil(t *testing.T) {
+	q := newIntSpillQueue(1)
+	for i := range 3 {
+		q.Put(i)
+	}
+	name := q.f.Name()
+	q.Close(nil)
+	if _, err := os.Stat(name); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("spill file after Close(nil): Stat = %v, want not exist", err)
+	}
+	if _, err := q.Get(context.Background()); err != io.EOF {
+		t.Errorf("Get after Close(nil) = %v, want io.EOF", err)
+	}
+	if err := q.Put(3); err != io.EOF {
+		t.Errorf("Put after Close(nil) = %v, want io.EOF", err)
+	}
+}
+
+func TestSpillQueueTruncatedFile(t *testing.T) {
+	q := newIntSpillQueue(1)
+	defer q.Close(nil)
+	for i := range 3 {
+		q.Put(i)
+	}
+	q.f.Truncate(0)
+	if v, err := q.Get(context.Background()); v != 0 || err != nil {
+		t.Fatalf("Get = %v, %v; want 0, nil", v, err)
+	}
+	_, err := q.Get(context.Background())
+	if !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Fatalf("Get from truncated spill file = %v, want io.ErrUnexpectedEOF", err)
+	}
+	if _, err2 := q.Get(context.Background()); err2 != err {
+		t.Errorf("Get after spill file error = %v, want %v", err2, err)
+	}
+}
+
+func TestSpillQueueDecodeError(t *testing.T) {
+	errDecode := errors.New("decode error")
+	q := NewSpillQueue(1,
+		func(v int) ([]byte, error) { return strconv.AppendInt(nil, int64(v), 10), nil },
+		func(b []byte) (int, error) { return 0, errDecode },
+	)
+	defer q.Close(nil)
+	for i := range 3 {
+		q.Put(i)
+	}
+	q.Get(context.Background())
+	if _, err := q.Get(context.Background()); !errors.Is(err, errDecode) {
+		t.Fatalf("Get of undecodable item = %v, want %v", err, errDecode)
+	}
+	if err := q.Put(3); !errors.Is(err, errDecode) {
+		t.Errorf("Put after decode error = %v, want %v", err, errDecode)
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate
+
+import (
+	"fmt"
+	"log"
+	"time"
+)
+
+// A Stall describes a gate held, or a goroutine blocked on a gate, for longer than a threshold.
+type Stall struct {
+	Goroutine int64         // goroutine ID
+	Gate      string        // gate name, or ""
+	Op        string        // "held", or the blocked operation: "Lock" or "WaitAndLock"
+	Since     time.Time     // time at which the gate was acquired or the goroutine blocked
+	Duration  time.Duration // time held or blocked when the stall was detected
+	Stack     []byte        // stack at which the gate was acquired or the goroutine blocked
+}
+
+func (s *Stall) String() string {
+	if s.Op == "held" {
+		return fmt.Sprintf("gate: %v held by goroutine %v for %v, acquired at:\n%s", gateString(s.Gate), s.Goroutine, s.Duration, s.Stack)
+	}
+	if s.Gate != "" {
+		return fmt.Sprintf("gate: goroutine %v blocked in %v on %v for %v:\n%s", s.Goroutine, s.Op, gateString(s.Gate), s.Duration, s.Stack)
+	}
+	return fmt.Sprintf("gate: goroutine %v blocked in %v for %v:\n%s", s.Goroutine, s.Op, s.Duration, s.Stack)
+}
+
+var stallStop chan struct{} // closed to stop the current stall detector; guarded by debugMu
+
+// SetStallDetector reports tracked gates held for longer than hold,
+// and goroutines blocked on tracked gates for longer than wait.
+// A zero threshold disables the corresponding report.
+// Each hold or wait is reported at most once.
+//
+// Stalls are detected by a background goroutine which checks tracked gates
+// at a quarter of the smaller threshold, so a stall is reported somewhat after
+// its threshold passes. The goroutine calls f for each stall.
+// If f is nil, stalls are written to the standard logger.
+//
+// Stall detection applies only to gates which are tracked; see SetDebug.
+// Calling SetStallDetector replaces any previous detector.
+func SetStallDetector(hold, wait time.Duration, f func(*Stall)) {
+	debugMu.Lock()
+	defer debugMu.Unlock()
+	if stallStop != nil {
+		close(stallStop)
+		stallStop = nil
+	}
+	if hold <= 0 && wait <= 0 {
+		return
+	}
+	if f == nil {
+		f = func(s *Stall) {
+			log.Print(s.String())
+		}
+	}
+	interval := wait
+	if hold > 0 && (wait <= 0 || hold < wait) {
+		interval = hold
+	}
+	stallStop = make(chan struct{})
+	go detectStalls(stallStop, max(interval/4, 1*time.Millisecond), hold, wait, f)
+}
+
+func detectStalls(stop chan struct{}, interval, hold, wait time.Duration, f func(*Stall)) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+		case <-stop:
+			return
+		}
+		for _, s := range findStalls(hold, wait) {
+			f(s)
+		}
+	}
+}
+
+// findStalls returns holds and waits which have exceeded their thresholds
+// and have not yet been reported.
+func findStalls(hold, wait time.Duration) []*Stall {
+	debugMu.Lock()
+	defer debugMu.Unlock()
+	now := time.Now()
+	var stalls []*Stall
+	if hold > 0 {
+		for d := range debugHeld {
+			if d.stalled || now.Sub(d.holderSince) < hold {
+				continue
+			}
+			d.stalled = true
+			stalls = append(stalls, &Stall{
+				Goroutine: d.holder,
+				Gate:      d.name,
+				Op:        "held",
+				Since:     d.holderSince,
+				Duration:  now.Sub(d.holderSince),
+				Stack:     d.holderStack,
+			})
+		}
+	}
+	if wait > 0 {
+		for _, w := range debugWaits {
+			if w.stalled || now.Sub(w.since) < wait {
+				continue
+			}
+			w.stalled = true
+			stalls = append(stalls, &Stall{
+				Goroutine: w.id,
+				Gate:      w.d.name,
+				Op:        w.op,
+				Since:     w.since,
+				Duration:  now.Sub(w.since),
+				Stack:     w.stack,
+			})
+		}
+	}
+	return stalls
+}
@@ -0,0 +1,51 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/neild/gate"
+)
+
+func TestStallDetector(t *testing.T) {
+	enableDebug(t)
+	stalls := make(chan *gate.Stall, 10)
+	gate.SetStallDetector(10*time.Millisecond, 20*time.Millisecond, func(s *gate.Stall) {
+		stalls <- s
+	})
+	defer gate.SetStallDetector(0, 0, nil)
+
+	g := gate.New(false)
+	g.Lock()
+	s := <-stalls
+	if s.Op != "held" || s.Duration < 10*time.Millisecond {
+		t.Fatalf("stall = %v %v, want held for at least 10ms", s.Op, s.Duration)
+	}
+	if !strings.Contains(string(s.Stack), "TestStallDetector") {
+		t.Errorf("stall stack does not contain test function:\n%s", s.Stack)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		g.Lock()
+		g.Unlock(false)
+	}()
+	s = <-stalls
+	if s.Op != "Lock" || s.Duration < 20*time.Millisecond {
+		t.Fatalf("stall = %v %v, want blocked in Lock for at least 20ms", s.Op, s.Duration)
+	}
+	g.Unlock(false)
+	<-done
+
+	select {
+	case s := <-stalls:
+		t.Fatalf("unexpected stall after gate released: %v", s)
+	case <-time.After(30 * time.Millisecond):
+	}
+}
@@ -0,0 +1,96 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// ErrInvalidTransition is returned by StateMachine.Transition
+// when the transition is not permitted.
+var ErrInvalidTransition = errors.New("gate: invalid state transition")
+
+// A StateMachine holds a state which changes according to a table of permitted transitions.
+// Callers may wait for the machine to enter a state.
+type StateMachine[S comparable] struct {
+	mu          Gate // guards the fields below
+	state       S
+	transitions map[S][]S
+	hooks       []func(from, to S)
+	changed     chan struct{} // closed on the next transition
+}
+
+// NewStateMachine returns a new state machine in the initial state.
+// The transitions table maps each state to the states which may follow it.
+func NewStateMachine[S comparable](initial S, transitions map[S][]S) *StateMachine[S] {
+	return &StateMachine[S]{
+		mu:          New(false),
+		state:       initial,
+		transitions: transitions,
+	}
+}
+
+// State returns the current state.
+func (m *StateMachine[S]) State() S {
+	m.mu.Lock()
+	defer m.mu.Unlock(false)
+	return m.state
+}
+
+// Transition changes the state to the given state.
+// It returns an error wrapping ErrInvalidTransition if the transition
+// from the current state is not permitted.
+func (m *StateMachine[S]) Transition(to S) error {
+	m.mu.Lock()
+	from := m.state
+	if !slices.Contains(m.transitions[from], to) {
+		m.mu.Unlock(false)
+		return fmt.Errorf("%w: %v to %v", ErrInvalidTransition, from, to)
+	}
+	m.state = to
+	if m.changed != nil {
+		close(m.changed)
+		m.changed = nil
+	}
+	hooks := m.hooks
+	m.mu.Unlock(false)
+	for _, f := range hooks {
+		f(from, to)
+	}
+	return nil
+}
+
+// OnTransition registers a function to be called after every transition.
+// The function is called without the state machine's gate held,
+// and may be called concurrently for concurrent transitions.
+func (m *StateMachine[S]) OnTransition(f func(from, to S)) {
+	m.mu.Lock()
+	defer m.mu.Unlock(false)
+	m.hooks = append(slices.Clip(m.hooks), f)
+}
+
+// WaitFor blocks until the machine is in one of the given states or the context expires.
+// It returns the current state.
+func (m *StateMachine[S]) WaitFor(ctx context.Context, states ...S) (S, error) {
+	for {
+		m.mu.Lock()
+		state := m.state
+		if slices.Contains(states, state) {
+			m.mu.Unlock(false)
+			return state, nil
+		}
+		if m.changed == nil {
+			m.changed = make(chan struct{})
+		}
+		changed := m.changed
+		m.mu.Unlock(false)
+		if err := m.mu.WaitFor(ctx, changed); err != nil {
+			return state, err
+		}
+	}
+}
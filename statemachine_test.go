@@ -0,0 +1,73 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/neild/gate"
+)
+
+type connState string
+
+const (
+	connIdle     connState = "idle"
+	connActive   connState = "active"
+	connDraining connState = "draining"
+	connClosed   connState = "closed"
+)
+
+func newConnStateMachine() *gate.StateMachine[connState] {
+	return gate.NewStateMachine(connIdle, map[connState][]connState{
+		connIdle:     {connActive, connClosed},
+		connActive:   {connIdle, connDraining},
+		connDraining: {connClosed},
+	})
+}
+
+func TestStateMachineTransition(t *testing.T) {
+	m := newConnStateMachine()
+	var got []connState
+	m.OnTransition(func(from, to connState) {
+		got = append(got, from, to)
+	})
+	if err := m.Transition(connActive); err != nil {
+		t.Fatalf("m.Transition(active) = %v, want nil", err)
+	}
+	if err := m.Transition(connClosed); !errors.Is(err, gate.ErrInvalidTransition) {
+		t.Fatalf("m.Transition(closed) from active = %v, want ErrInvalidTransition", err)
+	}
+	if state := m.State(); state != connActive {
+		t.Fatalf("m.State() = %v, want %v", state, connActive)
+	}
+	if want := []connState{connIdle, connActive}; !slices.Equal(got, want) {
+		t.Fatalf("transition hook calls: %v, want %v", got, want)
+	}
+}
+
+func TestStateMachineWaitFor(t *testing.T) {
+	m := newConnStateMachine()
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Millisecond)
+	defer cancel()
+	if state, err := m.WaitFor(ctx, connClosed); state != connIdle || err != context.DeadlineExceeded {
+		t.Fatalf("m.WaitFor(closed) = %v, %v; want idle, context.DeadlineExceeded", state, err)
+	}
+	go func() {
+		time.Sleep(1 * time.Millisecond)
+		m.Transition(connActive)
+		m.Transition(connDraining)
+		m.Transition(connClosed)
+	}()
+	if state, err := m.WaitFor(context.Background(), connDraining, connClosed); err != nil {
+		t.Fatalf("m.WaitFor(draining, closed) = %v, %v; want nil error", state, err)
+	}
+	if state, err := m.WaitFor(context.Background(), connClosed); state != connClosed || err != nil {
+		t.Fatalf("m.WaitFor(closed) = %v, %v; want closed, nil", state, err)
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate
+
+import "errors"
+
+// ErrStopped is the reason reported by a Stopper stopped with a nil reason,
+// and is returned by operations on a stopped Periodic.
+var ErrStopped = errors.New("gate: stopped")
+
+// A Stopper coordinates the shutdown of a component in two phases.
+//
+// Stop begins shutdown: the Stopping signal is set,
+// and the component should stop accepting work and drain what it has.
+// Done completes shutdown: the Stopped signal is set,
+// and resources shared with the component may be torn down.
+type Stopper struct {
+	mu       Gate // guards reason
+	reason   error
+	stopping *Event
+	stopped  *Event
+}
+
+// NewStopper returns a new, running Stopper.
+func NewStopper() *Stopper {
+	return &Stopper{
+		mu:       New(false),
+		stopping: NewEvent(),
+		stopped:  NewEvent(),
+	}
+}
+
+// Stop begins shutdown with the given reason.
+// If reason is nil, the reason is ErrStopped.
+// It reports whether this call began shutdown.
+// If shutdown has already begun, the reason is not changed.
+func (s *Stopper) Stop(reason error) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock(false)
+	if s.reason != nil {
+		return false
+	}
+	if reason == nil {
+		reason = ErrStopped
+	}
+	s.reason = reason
+	s.stopping.Set()
+	return true
+}
+
+// Done completes shutdown, setting the Stopped signal.
+// If Stop has not been called, Done calls Stop(nil) first.
+func (s *Stopper) Done() {
+	s.Stop(nil)
+	s.stopped.Set()
+}
+
+// Reason returns the reason shutdown began,
+// or nil if Stop has not been called.
+func (s *Stopper) Reason() error {
+	s.mu.Lock()
+	defer s.mu.Unlock(false)
+	return s.reason
+}
+
+// Stopping returns a signal which is set when shutdown begins.
+func (s *Stopper) Stopping() Signal {
+	return s.stopping.Signal()
+}
+
+// Stopped returns a signal which is set when shutdown is complete.
+func (s *Stopper) Stopped() Signal {
+	return s.stopped.Signal()
+}
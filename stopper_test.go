@@ -0,0 +1,63 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/neild/gate"
+)
+
+func TestStopper(t *testing.T) {
+	s := gate.NewStopper()
+	if err := s.Reason(); err != nil {
+		t.Fatalf("s.Reason of running stopper = %v, want nil", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Millisecond)
+	defer cancel()
+	if err := s.Stopping().Wait(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("s.Stopping().Wait of running stopper = %v, want context.DeadlineExceeded", err)
+	}
+
+	wantErr := errors.New("shutting down")
+	donec := make(chan struct{})
+	go func() {
+		defer close(donec)
+		// Drain, then report completion.
+		s.Stopping().Wait(context.Background())
+		time.Sleep(1 * time.Millisecond)
+		s.Done()
+	}()
+	if ok := s.Stop(wantErr); !ok {
+		t.Fatalf("first s.Stop = %v, want true", ok)
+	}
+	if ok := s.Stop(errors.New("ignored")); ok {
+		t.Fatalf("second s.Stop = %v, want false", ok)
+	}
+	if err := s.Reason(); err != wantErr {
+		t.Fatalf("s.Reason = %v, want %v", err, wantErr)
+	}
+	if set := s.Stopped().IsSet(); set {
+		t.Fatalf("s.Stopped().IsSet before Done = %v, want false", set)
+	}
+	if err := s.Stopped().Wait(context.Background()); err != nil {
+		t.Fatalf("s.Stopped().Wait = %v, want nil", err)
+	}
+	<-donec
+}
+
+func TestStopperDoneWithoutStop(t *testing.T) {
+	s := gate.NewStopper()
+	s.Done()
+	if set := s.Stopping().IsSet(); !set {
+		t.Fatalf("s.Stopping().IsSet after Done = %v, want true", set)
+	}
+	if err := s.Reason(); err != gate.ErrStopped {
+		t.Fatalf("s.Reason after Done = %v, want %v", err, gate.ErrStopped)
+	}
+}
@@ -0,0 +1,56 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate
+
+import "context"
+
+// A Switch pauses and resumes work.
+// Workers call Wait in their loops, which returns immediately while the switch is running
+// and blocks while it is suspended.
+type Switch struct {
+	gate      Gate // set if running
+	suspended bool
+}
+
+// NewSwitch returns a new, running switch.
+func NewSwitch() *Switch {
+	return &Switch{
+		gate: New(true),
+	}
+}
+
+// Suspend suspends the switch, causing future calls to Wait to block.
+func (s *Switch) Suspend() {
+	s.gate.Lock()
+	s.suspended = true
+	s.unlock()
+}
+
+// Resume resumes the switch, unblocking callers of Wait.
+func (s *Switch) Resume() {
+	s.gate.Lock()
+	s.suspended = false
+	s.unlock()
+}
+
+// Suspended reports whether the switch is suspended.
+func (s *Switch) Suspended() bool {
+	s.gate.Lock()
+	defer s.unlock()
+	return s.suspended
+}
+
+// Wait blocks until the switch is running or the context expires.
+func (s *Switch) Wait(ctx context.Context) error {
+	if err := s.gate.WaitAndLock(ctx); err != nil {
+		return err
+	}
+	s.unlock()
+	return nil
+}
+
+func (s *Switch) unlock() {
+	s.gate.Unlock(!s.suspended)
+}
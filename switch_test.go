@@ -0,0 +1,44 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/neild/gate"
+)
+
+func TestSwitch(t *testing.T) {
+	s := gate.NewSwitch()
+	if err := s.Wait(context.Background()); err != nil {
+		t.Fatalf("s.Wait of running switch = %v, want nil", err)
+	}
+
+	s.Suspend()
+	if suspended := s.Suspended(); !suspended {
+		t.Fatalf("s.Suspended after Suspend = %v, want true", suspended)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Millisecond)
+	defer cancel()
+	if err := s.Wait(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("s.Wait of suspended switch = %v, want context.DeadlineExceeded", err)
+	}
+
+	donec := make(chan error)
+	for i := 0; i < 2; i++ {
+		go func() {
+			donec <- s.Wait(context.Background())
+		}()
+	}
+	time.Sleep(1 * time.Millisecond)
+	s.Resume()
+	for i := 0; i < 2; i++ {
+		if err := <-donec; err != nil {
+			t.Fatalf("s.Wait after Resume = %v, want nil", err)
+		}
+	}
+}
@@ -0,0 +1,259 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"testing/synctest"
+	"time"
+
+	"github.com/neild/gate"
+)
+
+func TestSynctestGateDurablyBlocked(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		g := gate.New(false)
+		done := false
+		go func() {
+			g.WaitAndLock(context.Background())
+			done = true
+			g.Unlock(true)
+		}()
+		synctest.Wait()
+		if done {
+			t.Fatalf("WaitAndLock returned before gate was set")
+		}
+		g.Lock()
+		g.Unlock(true)
+		synctest.Wait()
+		if !done {
+			t.Fatalf("WaitAndLock did not return after gate was set")
+		}
+	})
+}
+
+func TestSynctestGateContextTimeout(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		g := gate.New(false)
+		start := time.Now()
+		ctx, cancel := context.WithTimeout(context.Background(), 1*time.Hour)
+		defer cancel()
+		if err := g.WaitAndLock(ctx); err != context.DeadlineExceeded {
+			t.Fatalf("g.WaitAndLock = %v, want context.DeadlineExceeded", err)
+		}
+		if got, want := time.Since(start), 1*time.Hour; got != want {
+			t.Fatalf("WaitAndLock returned after %v, want %v", got, want)
+		}
+	})
+}
+
+func TestSynctestDebouncer(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		d := gate.NewDebouncer(1*time.Minute, 5*time.Minute)
+		start := time.Now()
+		for i := 0; i < 10; i++ {
+			d.Trigger()
+			time.Sleep(30 * time.Second)
+		}
+		if err := d.Wait(context.Background()); err != nil {
+			t.Fatalf("d.Wait = %v, want nil", err)
+		}
+		if got, want := time.Since(start), 5*time.Minute; got != want {
+			t.Fatalf("debouncer fired after %v, want %v (max delay)", got, want)
+		}
+	})
+}
+
+func TestSynctestIdleTracker(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		it := gate.NewIdleTracker(1 * time.Minute)
+		defer it.Stop()
+		time.Sleep(30 * time.Second)
+		it.Activity()
+		start := time.Now()
+		if err := it.Wait(context.Background()); err != nil {
+			t.Fatalf("it.Wait = %v, want nil", err)
+		}
+		if got, want := time.Since(start), 1*time.Minute; got != want {
+			t.Fatalf("tracker became idle %v after activity, want %v", got, want)
+		}
+	})
+}
+
+func TestSynctestWatchdog(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		w := gate.NewWatchdog(1 * time.Minute)
+		defer w.Stop()
+		for i := 0; i < 5; i++ {
+			time.Sleep(50 * time.Second)
+			w.Kick()
+		}
+		if w.Expired() {
+			t.Fatalf("w.Expired() = true after regular kicks, want false")
+		}
+		start := time.Now()
+		if err := w.WaitExpired(context.Background()); err != nil {
+			t.Fatalf("w.WaitExpired = %v, want nil", err)
+		}
+		if got, want := time.Since(start), 1*time.Minute; got != want {
+			t.Fatalf("watchdog expired %v after last kick, want %v", got, want)
+		}
+	})
+}
+
+func TestSynctestPeriodic(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		var runs []time.Duration
+		start := time.Now()
+		p := gate.NewPeriodic(1*time.Hour, func(ctx context.Context) error {
+			runs = append(runs, time.Since(start))
+			return nil
+		})
+		defer p.Stop()
+		for i := 0; i < 3; i++ {
+			if err := p.Wait(context.Background()); err != nil {
+				t.Fatalf("p.Wait = %v, want nil", err)
+			}
+		}
+		time.Sleep(30 * time.Minute)
+		p.Trigger()
+		if err := p.Wait(context.Background()); err != nil {
+			t.Fatalf("p.Wait = %v, want nil", err)
+		}
+		want := []time.Duration{0, 1 * time.Hour, 2 * time.Hour, 2*time.Hour + 30*time.Minute}
+		if len(runs) != len(want) {
+			t.Fatalf("runs at %v, want %v", runs, want)
+		}
+		for i := range want {
+			if runs[i] != want[i] {
+				t.Fatalf("runs at %v, want %v", runs, want)
+			}
+		}
+	})
+}
+
+func TestSynctestRetrier(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		r := gate.NewRetrier(1*time.Second, 1*time.Minute, 0)
+		start := time.Now()
+		var total time.Duration
+		for i := 0; i < 8; i++ {
+			total += r.Failure()
+			if err := r.Wait(context.Background()); err != nil {
+				t.Fatalf("r.Wait = %v, want nil", err)
+			}
+		}
+		if got := time.Since(start); got != total {
+			t.Fatalf("retries took %v, want %v", got, total)
+		}
+		r.Success()
+		if !r.Allowed() {
+			t.Fatalf("r.Allowed() = false after Success, want true")
+		}
+	})
+}
+
+func TestSynctestRateLimiter(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		l := gate.NewRateLimiter(1, 1)
+		start := time.Now()
+		for i := 0; i < 5; i++ {
+			if err := l.Wait(context.Background()); err != nil {
+				t.Fatalf("l.Wait = %v, want nil", err)
+			}
+		}
+		if got, want := time.Since(start), 4*time.Second; got != want {
+			t.Fatalf("5 waits took %v, want %v", got, want)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+		defer cancel()
+		if err := l.Wait(ctx); err != gate.ErrWouldExceedDeadline {
+			t.Fatalf("l.Wait = %v, want ErrWouldExceedDeadline", err)
+		}
+	})
+}
+
+func TestSynctestSlidingWindowLimiter(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		l := gate.NewSlidingWindowLimiter(2, 1*time.Minute)
+		start := time.Now()
+		for i := 0; i < 6; i++ {
+			if err := l.Wait(context.Background()); err != nil {
+				t.Fatalf("l.Wait = %v, want nil", err)
+			}
+		}
+		if got, want := time.Since(start), 2*time.Minute; got != want {
+			t.Fatalf("6 waits took %v, want %v", got, want)
+		}
+	})
+}
+
+func TestSynctestLease(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		l := gate.NewLeaser()
+		lease, err := l.Acquire(context.Background(), 1*time.Minute)
+		if err != nil {
+			t.Fatalf("l.Acquire = %v", err)
+		}
+		start := time.Now()
+		next, err := l.Acquire(context.Background(), 1*time.Minute)
+		if err != nil {
+			t.Fatalf("l.Acquire = %v", err)
+		}
+		defer next.Release()
+		if got, want := time.Since(start), 1*time.Minute; got != want {
+			t.Fatalf("second lease acquired after %v, want %v", got, want)
+		}
+		if err := lease.Renew(1 * time.Minute); !errors.Is(err, gate.ErrLeaseLost) {
+			t.Fatalf("lease.Renew = %v, want ErrLeaseLost", err)
+		}
+	})
+}
+
+func TestSynctestScheduler(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		s := gate.NewScheduler[int]()
+		start := time.Now()
+		s.Schedule(start.Add(2*time.Hour), 2)
+		s.Schedule(start.Add(3*time.Hour), 3)
+		go func() {
+			time.Sleep(30 * time.Minute)
+			s.Schedule(start.Add(1*time.Hour), 1)
+		}()
+		for want := 1; want <= 3; want++ {
+			got, err := s.Get(context.Background())
+			if err != nil {
+				t.Fatalf("s.Get = %v", err)
+			}
+			if got != want {
+				t.Fatalf("s.Get = %v, want %v", got, want)
+			}
+			if got, want := time.Since(start), time.Duration(want)*time.Hour; got != want {
+				t.Fatalf("s.Get returned after %v, want %v", got, want)
+			}
+		}
+	})
+}
+
+func TestSynctestCommitter(t *testing.T) {
+	synctest.Test(t, func(t *testing.T) {
+		var flushed []time.Duration
+		start := time.Now()
+		c := gate.NewCommitter(10, 1*time.Minute, func(items []int) error {
+			flushed = append(flushed, time.Since(start))
+			return nil
+		})
+		defer c.Close()
+		if err := c.Add(context.Background(), 1); err != nil {
+			t.Fatalf("c.Add = %v, want nil", err)
+		}
+		if len(flushed) != 1 || flushed[0] != 1*time.Minute {
+			t.Fatalf("batches flushed at %v, want [%v]", flushed, 1*time.Minute)
+		}
+	})
+}
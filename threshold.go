@@ -0,0 +1,55 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// A ThresholdCounter is a counter which may be waited on to reach a threshold.
+//
+// Adding to the counter is a single atomic operation,
+// except when the addition crosses the threshold.
+type ThresholdCounter struct {
+	gate      Gate // set if value >= threshold
+	threshold int64
+	v         atomic.Int64
+}
+
+// NewThresholdCounter returns a new counter with a value of zero.
+func NewThresholdCounter(threshold int64) *ThresholdCounter {
+	return &ThresholdCounter{
+		gate:      New(threshold <= 0),
+		threshold: threshold,
+	}
+}
+
+// Add adds delta, which may be negative, to the counter and returns the new value.
+func (c *ThresholdCounter) Add(delta int64) int64 {
+	v := c.v.Add(delta)
+	old := v - delta
+	if (old >= c.threshold) != (v >= c.threshold) {
+		// Recompute the condition from the current value,
+		// since other additions may have crossed the threshold concurrently.
+		c.gate.Lock()
+		c.gate.Unlock(c.v.Load() >= c.threshold)
+	}
+	return v
+}
+
+// Value returns the counter's value.
+func (c *ThresholdCounter) Value() int64 {
+	return c.v.Load()
+}
+
+// Wait blocks until the counter's value is at least the threshold or the context expires.
+func (c *ThresholdCounter) Wait(ctx context.Context) error {
+	if err := c.gate.WaitAndLock(ctx); err != nil {
+		return err
+	}
+	c.gate.Unlock(c.v.Load() >= c.threshold)
+	return nil
+}
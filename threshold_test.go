@@ -0,0 +1,68 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/neild/gate"
+)
+
+func TestThresholdCounter(t *testing.T) {
+	c := gate.NewThresholdCounter(10)
+	c.Add(5)
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Millisecond)
+	defer cancel()
+	if err := c.Wait(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("c.Wait below threshold = %v, want context.DeadlineExceeded", err)
+	}
+
+	donec := make(chan error)
+	go func() {
+		donec <- c.Wait(context.Background())
+	}()
+	time.Sleep(1 * time.Millisecond)
+	if got, want := c.Add(5), int64(10); got != want {
+		t.Fatalf("c.Add(5) = %v, want %v", got, want)
+	}
+	if err := <-donec; err != nil {
+		t.Fatalf("c.Wait = %v, want nil", err)
+	}
+
+	// Dropping back below the threshold unsets the condition.
+	c.Add(-10)
+	ctx, cancel = context.WithTimeout(context.Background(), 1*time.Millisecond)
+	defer cancel()
+	if err := c.Wait(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("c.Wait after dropping below threshold = %v, want context.DeadlineExceeded", err)
+	}
+}
+
+func TestThresholdCounterConcurrent(t *testing.T) {
+	const n = 100
+	c := gate.NewThresholdCounter(n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// Cross the threshold back and forth before settling.
+			c.Add(2)
+			c.Add(-1)
+		}()
+	}
+	wg.Wait()
+	if got, want := c.Value(), int64(n); got != want {
+		t.Fatalf("c.Value() = %v, want %v", got, want)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	if err := c.Wait(ctx); err != nil {
+		t.Fatalf("c.Wait at threshold = %v, want nil", err)
+	}
+}
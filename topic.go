@@ -0,0 +1,196 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate
+
+import (
+	"context"
+	"errors"
+	"slices"
+)
+
+var (
+	// ErrTopicClosed is returned by Subscription.Get after the topic is closed
+	// and all buffered items have been received.
+	ErrTopicClosed = errors.New("gate: topic closed")
+
+	// ErrSubscriptionDropped is returned by Subscription.Get after a subscription
+	// with the DropSubscription policy overflows its buffer
+	// and all buffered items have been received.
+	ErrSubscriptionDropped = errors.New("gate: subscription dropped")
+)
+
+// A DropPolicy determines what a Topic does when a subscriber's buffer is full.
+type DropPolicy int
+
+const (
+	// DropOldest discards the oldest buffered item to make room for the new one.
+	DropOldest DropPolicy = iota
+	// DropNewest discards the new item.
+	DropNewest
+	// DropSubscription ends the subscription with ErrSubscriptionDropped.
+	DropSubscription
+)
+
+// A Topic delivers published items to each of its subscribers.
+// Publishing never blocks: each subscriber has its own buffer,
+// and a DropPolicy which determines what happens when the buffer is full.
+type Topic[T any] struct {
+	mu     Gate // guards the fields below
+	subs   []*Subscription[T]
+	closed bool
+}
+
+// A Subscription receives items published to a Topic.
+type Subscription[T any] struct {
+	gate    Gate // set if buffer is non-empty or the subscription has ended
+	max     int
+	policy  DropPolicy
+	err     error
+	q       []T
+	dropped int
+	stop    func() bool // stops the subscription's context.AfterFunc
+}
+
+// NewTopic returns a new topic with no subscribers.
+func NewTopic[T any]() *Topic[T] {
+	return &Topic[T]{
+		mu: New(false),
+	}
+}
+
+// Subscribe adds a subscriber which receives all items published after this call.
+// The subscriber buffers at most size unread items, or an unlimited number if size is zero.
+// The policy determines what happens when the buffer is full.
+//
+// The subscription ends when ctx is done,
+// after which Get returns ctx's error once the buffer is empty.
+func (t *Topic[T]) Subscribe(ctx context.Context, size int, policy DropPolicy) *Subscription[T] {
+	s := &Subscription[T]{
+		gate:   New(false),
+		max:    size,
+		policy: policy,
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock(false)
+	if t.closed {
+		s.end(ErrTopicClosed)
+		return s
+	}
+	t.subs = append(t.subs, s)
+	s.stop = context.AfterFunc(ctx, func() {
+		t.remove(s)
+		s.end(ctx.Err())
+	})
+	return s
+}
+
+// Publish delivers an item to every subscriber.
+// It does not block waiting for subscribers to receive the item.
+// It reports whether the item was published, which it is not if the topic is closed.
+func (t *Topic[T]) Publish(v T) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock(false)
+	if t.closed {
+		return false
+	}
+	for _, s := range t.subs {
+		if !s.put(v) {
+			s.stop()
+		}
+	}
+	// Forget about any subscriptions which were dropped.
+	t.subs = slices.DeleteFunc(t.subs, (*Subscription[T]).ended)
+	return true
+}
+
+// Close closes the topic, ending all subscriptions with ErrTopicClosed.
+func (t *Topic[T]) Close() {
+	t.mu.Lock()
+	defer t.mu.Unlock(false)
+	t.closed = true
+	t.mu.recordClose()
+	for _, s := range t.subs {
+		s.stop()
+		s.end(ErrTopicClosed)
+	}
+	t.subs = nil
+}
+
+func (t *Topic[T]) remove(s *Subscription[T]) {
+	t.mu.Lock()
+	defer t.mu.Unlock(false)
+	t.subs = slices.DeleteFunc(t.subs, func(sub *Subscription[T]) bool {
+		return sub == s
+	})
+}
+
+// Get removes the next item from the subscription's buffer,
+// blocking until ctx is done or an item is available.
+// Once the subscription has ended and its buffer is empty,
+// Get returns the reason the subscription ended.
+func (s *Subscription[T]) Get(ctx context.Context) (T, error) {
+	var zero T
+	if err := s.gate.WaitAndLock(ctx); err != nil {
+		return zero, err
+	}
+	defer s.unlock()
+	if len(s.q) == 0 {
+		return zero, s.err
+	}
+	v := s.q[0]
+	s.q = slices.Delete(s.q, 0, 1)
+	return v, nil
+}
+
+// Dropped returns the number of items discarded because the subscription's buffer was full.
+func (s *Subscription[T]) Dropped() int {
+	s.gate.Lock()
+	defer s.unlock()
+	return s.dropped
+}
+
+// put adds an item to the subscription's buffer, applying its policy if the buffer is full.
+// It reports false if the subscription was dropped.
+func (s *Subscription[T]) put(v T) bool {
+	s.gate.Lock()
+	defer s.unlock()
+	if s.err != nil {
+		return true
+	}
+	if s.max > 0 && len(s.q) >= s.max {
+		s.dropped++
+		switch s.policy {
+		case DropOldest:
+			s.q = slices.Delete(s.q, 0, 1)
+		case DropNewest:
+			return true
+		case DropSubscription:
+			s.err = ErrSubscriptionDropped
+			return false
+		}
+	}
+	s.q = append(s.q, v)
+	return true
+}
+
+// end ends the subscription with err, if it has not already ended.
+func (s *Subscription[T]) end(err error) {
+	s.gate.Lock()
+	defer s.unlock()
+	if s.err == nil {
+		s.err = err
+	}
+}
+
+// ended reports whether the subscription has ended.
+func (s *Subscription[T]) ended() bool {
+	s.gate.Lock()
+	defer s.unlock()
+	return s.err != nil
+}
+
+func (s *Subscription[T]) unlock() {
+	s.gate.Unlock(s.err != nil || len(s.q) > 0)
+}
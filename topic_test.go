@@ -0,0 +1,106 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/neild/gate"
+)
+
+func TestTopic(t *testing.T) {
+	topic := gate.NewTopic[int]()
+	a := topic.Subscribe(context.Background(), 0, gate.DropOldest)
+	b := topic.Subscribe(context.Background(), 0, gate.DropOldest)
+	topic.Publish(1)
+	topic.Publish(2)
+	for _, s := range []*gate.Subscription[int]{a, b} {
+		for want := 1; want <= 2; want++ {
+			if v, err := s.Get(context.Background()); err != nil || v != want {
+				t.Fatalf("s.Get = %v, %v; want %v, nil", v, err, want)
+			}
+		}
+	}
+
+	donec := make(chan int)
+	go func() {
+		v, _ := a.Get(context.Background())
+		donec <- v
+	}()
+	time.Sleep(1 * time.Millisecond)
+	topic.Publish(3)
+	if v := <-donec; v != 3 {
+		t.Fatalf("blocked a.Get = %v, want 3", v)
+	}
+}
+
+func TestTopicDropPolicy(t *testing.T) {
+	topic := gate.NewTopic[int]()
+	oldest := topic.Subscribe(context.Background(), 2, gate.DropOldest)
+	newest := topic.Subscribe(context.Background(), 2, gate.DropNewest)
+	drop := topic.Subscribe(context.Background(), 2, gate.DropSubscription)
+	for i := 0; i < 3; i++ {
+		if ok := topic.Publish(i); !ok {
+			t.Fatalf("topic.Publish(%v) = %v, want true", i, ok)
+		}
+	}
+	for _, test := range []struct {
+		name    string
+		s       *gate.Subscription[int]
+		want    []int
+		wantErr error
+	}{
+		{"DropOldest", oldest, []int{1, 2}, nil},
+		{"DropNewest", newest, []int{0, 1}, nil},
+		{"DropSubscription", drop, []int{0, 1}, gate.ErrSubscriptionDropped},
+	} {
+		if got, want := test.s.Dropped(), 1; got != want {
+			t.Errorf("%v: s.Dropped() = %v, want %v", test.name, got, want)
+		}
+		for _, want := range test.want {
+			if v, err := test.s.Get(context.Background()); err != nil || v != want {
+				t.Errorf("%v: s.Get = %v, %v; want %v, nil", test.name, v, err, want)
+			}
+		}
+		if test.wantErr != nil {
+			if _, err := test.s.Get(context.Background()); err != test.wantErr {
+				t.Errorf("%v: s.Get after buffered items = %v, want %v", test.name, err, test.wantErr)
+			}
+		}
+	}
+}
+
+func TestTopicSubscriptionContext(t *testing.T) {
+	topic := gate.NewTopic[int]()
+	ctx, cancel := context.WithCancel(context.Background())
+	s := topic.Subscribe(ctx, 0, gate.DropOldest)
+	topic.Publish(1)
+	cancel()
+	// Buffered items are still delivered after the subscription ends.
+	if v, err := s.Get(context.Background()); err != nil || v != 1 {
+		t.Fatalf("s.Get = %v, %v; want 1, nil", v, err)
+	}
+	if _, err := s.Get(context.Background()); err != context.Canceled {
+		t.Fatalf("s.Get after cancel = %v, want context.Canceled", err)
+	}
+}
+
+func TestTopicClose(t *testing.T) {
+	topic := gate.NewTopic[int]()
+	s := topic.Subscribe(context.Background(), 0, gate.DropOldest)
+	topic.Close()
+	if _, err := s.Get(context.Background()); err != gate.ErrTopicClosed {
+		t.Fatalf("s.Get after topic.Close = %v, want %v", err, gate.ErrTopicClosed)
+	}
+	if ok := topic.Publish(1); ok {
+		t.Fatalf("topic.Publish after Close = %v, want false", ok)
+	}
+	s = topic.Subscribe(context.Background(), 0, gate.DropOldest)
+	if _, err := s.Get(context.Background()); err != gate.ErrTopicClosed {
+		t.Fatalf("s.Get of subscription to closed topic = %v, want %v", err, gate.ErrTopicClosed)
+	}
+}
@@ -0,0 +1,48 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate
+
+import (
+	"context"
+	"runtime/trace"
+	"sync/atomic"
+)
+
+var tracing atomic.Bool
+
+// SetTracing enables or disables runtime/trace instrumentation of gates.
+//
+// While enabled and an execution trace is being collected, each wait in Lock or WaitAndLock
+// is recorded as a trace region of type "gate.Lock" or "gate.WaitAndLock",
+// and each acquisition and release of a gate is recorded as a log event
+// in the "gate" category, prefixed with the gate's name if it has one.
+// Releases are recorded as log events rather than by ending a region,
+// since a gate may be unlocked by a different goroutine than the one which locked it.
+//
+// WaitAndLock associates its region and events with the trace task of its context, if any.
+func SetTracing(on bool) {
+	setDiagnostic(func() {
+		tracing.Store(on)
+	})
+}
+
+// traceWait starts a region for a wait, if tracing.
+func traceWait(ctx context.Context, op string) *trace.Region {
+	if !tracing.Load() || !trace.IsEnabled() {
+		return nil
+	}
+	return trace.StartRegion(ctx, op)
+}
+
+// traceEvent logs an event for the named gate, if tracing.
+func traceEvent(ctx context.Context, name, msg string) {
+	if !tracing.Load() || !trace.IsEnabled() {
+		return
+	}
+	if name != "" {
+		msg = name + ": " + msg
+	}
+	trace.Log(ctx, "gate", msg)
+}
@@ -0,0 +1,91 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate
+
+import "context"
+
+// A Tracker records the completion of operations identified by sequential IDs,
+// and allows waiting for particular operations to complete.
+//
+// The Tracker does not allocate IDs: the caller numbers its operations sequentially,
+// starting from the Tracker's first ID, and reports each one to Done as it completes.
+// The Tracker remembers completed IDs only until every earlier ID has also completed,
+// so its memory use is bounded by the number of operations in flight,
+// provided that every ID up to the highest one in use is eventually reported.
+type Tracker struct {
+	mu      Gate                // guards the fields below
+	low     uint64              // every ID below low is complete
+	done    map[uint64]struct{} // completed IDs above low
+	changed chan struct{}       // closed when an ID completes
+}
+
+// NewTracker returns a new tracker whose first ID is start.
+// IDs below start are considered complete.
+func NewTracker(start uint64) *Tracker {
+	return &Tracker{
+		mu:   New(false),
+		low:  start,
+		done: make(map[uint64]struct{}),
+	}
+}
+
+// Done marks the operation with the given ID as complete.
+// Marking an ID complete more than once has no effect.
+func (t *Tracker) Done(id uint64) {
+	t.mu.Lock()
+	defer t.mu.Unlock(false)
+	if t.isDone(id) {
+		return
+	}
+	t.done[id] = struct{}{}
+	for {
+		if _, ok := t.done[t.low]; !ok {
+			break
+		}
+		delete(t.done, t.low)
+		t.low++
+	}
+	if t.changed != nil {
+		close(t.changed)
+		t.changed = nil
+	}
+}
+
+// IsDone reports whether the operation with the given ID is complete.
+func (t *Tracker) IsDone(id uint64) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock(false)
+	return t.isDone(id)
+}
+
+// WaitFor blocks until every listed operation is complete or the context expires.
+func (t *Tracker) WaitFor(ctx context.Context, ids ...uint64) error {
+	for {
+		t.mu.Lock()
+		for len(ids) > 0 && t.isDone(ids[0]) {
+			ids = ids[1:]
+		}
+		if len(ids) == 0 {
+			t.mu.Unlock(false)
+			return nil
+		}
+		if t.changed == nil {
+			t.changed = make(chan struct{})
+		}
+		changed := t.changed
+		t.mu.Unlock(false)
+		if err := t.mu.WaitFor(ctx, changed); err != nil {
+			return err
+		}
+	}
+}
+
+func (t *Tracker) isDone(id uint64) bool {
+	if id < t.low {
+		return true
+	}
+	_, ok := t.done[id]
+	return ok
+}
@@ -0,0 +1,53 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/neild/gate"
+)
+
+func TestTracker(t *testing.T) {
+	tr := gate.NewTracker(1)
+	if done := tr.IsDone(0); !done {
+		t.Fatalf("tr.IsDone(0) below start = %v, want true", done)
+	}
+
+	donec := make(chan error)
+	go func() {
+		donec <- tr.WaitFor(context.Background(), 2, 4)
+	}()
+	time.Sleep(1 * time.Millisecond)
+	tr.Done(4)
+	select {
+	case err := <-donec:
+		t.Fatalf("tr.WaitFor(2, 4) returned %v with 2 incomplete", err)
+	case <-time.After(1 * time.Millisecond):
+	}
+	tr.Done(2)
+	if err := <-donec; err != nil {
+		t.Fatalf("tr.WaitFor(2, 4) = %v, want nil", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Millisecond)
+	defer cancel()
+	if err := tr.WaitFor(ctx, 3); err != context.DeadlineExceeded {
+		t.Fatalf("tr.WaitFor(3) = %v, want context.DeadlineExceeded", err)
+	}
+	for _, id := range []uint64{1, 3} {
+		tr.Done(id)
+	}
+	for id := uint64(0); id <= 4; id++ {
+		if done := tr.IsDone(id); !done {
+			t.Errorf("tr.IsDone(%v) = %v, want true", id, done)
+		}
+	}
+	if done := tr.IsDone(5); done {
+		t.Errorf("tr.IsDone(5) = %v, want false", done)
+	}
+}
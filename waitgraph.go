@@ -0,0 +1,82 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate
+
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strconv"
+)
+
+// WriteWaitGraph writes the current wait-for graph of tracked gates
+// in the Graphviz DOT language.
+//
+// The graph has a node for each tracked gate which is locked or has blocked waiters,
+// and for each goroutine which holds or waits for one of those gates.
+// An edge from a goroutine to a gate is labeled with the operation blocked on the gate,
+// and an edge from a gate to a goroutine means the goroutine holds the gate.
+// A cycle in the graph is a deadlock, unless a wait in it has a context which may expire
+// or is a WaitFor wait for a notification; such waits are drawn with dashed edges.
+//
+// Only gates which are tracked are included; see SetDebug.
+func WriteWaitGraph(w io.Writer) error {
+	var b bytes.Buffer
+	b.WriteString("digraph gates {\n")
+
+	debugMu.Lock()
+	gates := maps.Clone(debugHeld)
+	for _, w := range debugWaits {
+		gates[w.d] = true
+	}
+	sorted := slices.SortedFunc(maps.Keys(gates), func(a, b *gateDebug) int {
+		return cmp.Compare(a.seq, b.seq)
+	})
+	goroutines := map[int64]bool{}
+	for _, d := range sorted {
+		label := d.name
+		if label == "" {
+			label = fmt.Sprintf("gate %v", d.seq)
+		}
+		fmt.Fprintf(&b, "\t%v [shape=box, label=%v];\n", d.node(), strconv.Quote(label))
+		if d.holder != 0 {
+			goroutines[d.holder] = true
+		}
+	}
+	waits := slices.SortedFunc(maps.Values(debugWaits), func(a, b *debugWait) int {
+		return cmp.Compare(a.id, b.id)
+	})
+	for _, w := range waits {
+		goroutines[w.id] = true
+	}
+	for _, id := range slices.Sorted(maps.Keys(goroutines)) {
+		fmt.Fprintf(&b, "\tg%v [label=%v];\n", id, strconv.Quote(fmt.Sprintf("goroutine %v", id)))
+	}
+	for _, d := range sorted {
+		if d.holder != 0 {
+			fmt.Fprintf(&b, "\t%v -> g%v [label=\"held by\"];\n", d.node(), d.holder)
+		}
+	}
+	for _, w := range waits {
+		style := ""
+		if !w.stuck {
+			style = ", style=dashed"
+		}
+		fmt.Fprintf(&b, "\tg%v -> %v [label=%v%v];\n", w.id, w.d.node(), strconv.Quote(w.op), style)
+	}
+	debugMu.Unlock()
+
+	b.WriteString("}\n")
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// node returns the gate's node ID in a wait-for graph.
+func (d *gateDebug) node() string {
+	return fmt.Sprintf("gate%v", d.seq)
+}
@@ -0,0 +1,65 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate_test
+
+import (
+	"bytes"
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/neild/gate"
+)
+
+func TestWriteWaitGraph(t *testing.T) {
+	enableDebug(t)
+	a := gate.NewNamed(true, "a")
+	b := gate.New(true)
+	a.Lock()
+	b.Lock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Hour)
+	done := make(chan struct{}, 2)
+	go func() {
+		a.Lock()
+		a.Unlock(true)
+		done <- struct{}{}
+	}()
+	go func() {
+		b.WaitAndLock(ctx)
+		done <- struct{}{}
+	}()
+	defer cancel()
+	for len(a.Waiters()) == 0 || len(b.Waiters()) == 0 {
+		time.Sleep(1 * time.Millisecond)
+	}
+
+	var buf bytes.Buffer
+	if err := gate.WriteWaitGraph(&buf); err != nil {
+		t.Fatalf("WriteWaitGraph: %v", err)
+	}
+	graph := buf.String()
+	if !strings.HasPrefix(graph, "digraph gates {\n") || !strings.HasSuffix(graph, "}\n") {
+		t.Errorf("graph is not a DOT digraph:\n%v", graph)
+	}
+	for _, re := range []string{
+		`gate\d+ \[shape=box, label="a"\];`,
+		`gate\d+ \[shape=box, label="gate \d+"\];`,
+		`gate\d+ -> g\d+ \[label="held by"\];`,
+		`g\d+ -> gate\d+ \[label="Lock"\];`,
+		`g\d+ -> gate\d+ \[label="WaitAndLock", style=dashed\];`,
+	} {
+		if !regexp.MustCompile(re).MatchString(graph) {
+			t.Errorf("graph does not match %q:\n%v", re, graph)
+		}
+	}
+
+	a.Unlock(true)
+	b.Unlock(true)
+	<-done
+	<-done
+}
@@ -0,0 +1,57 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate
+
+import "context"
+
+// A WaitGroup waits for a collection of tasks to finish.
+// It is like sync.WaitGroup, but Wait may be bounded by a context.
+type WaitGroup struct {
+	gate  Gate // set if count is zero
+	count int
+}
+
+// NewWaitGroup returns a new WaitGroup with a count of zero.
+func NewWaitGroup() *WaitGroup {
+	return &WaitGroup{
+		gate: New(true),
+	}
+}
+
+// Add adds delta, which may be negative, to the WaitGroup's count.
+// It panics if the count becomes negative.
+func (wg *WaitGroup) Add(delta int) {
+	wg.gate.Lock()
+	defer wg.unlock()
+	if wg.count+delta < 0 {
+		panic("gate: negative WaitGroup count")
+	}
+	wg.count += delta
+}
+
+// Done decrements the WaitGroup's count by one.
+func (wg *WaitGroup) Done() {
+	wg.Add(-1)
+}
+
+// Wait blocks until the WaitGroup's count is zero or the context expires.
+func (wg *WaitGroup) Wait(ctx context.Context) error {
+	if err := wg.gate.WaitAndLock(ctx); err != nil {
+		return err
+	}
+	wg.unlock()
+	return nil
+}
+
+// Count returns the WaitGroup's current count.
+func (wg *WaitGroup) Count() int {
+	wg.gate.Lock()
+	defer wg.unlock()
+	return wg.count
+}
+
+func (wg *WaitGroup) unlock() {
+	wg.gate.Unlock(wg.count == 0)
+}
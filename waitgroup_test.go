@@ -0,0 +1,48 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/neild/gate"
+)
+
+func TestWaitGroup(t *testing.T) {
+	wg := gate.NewWaitGroup()
+	if err := wg.Wait(context.Background()); err != nil {
+		t.Fatalf("wg.Wait with zero count = %v, want nil", err)
+	}
+	wg.Add(2)
+	if got, want := wg.Count(), 2; got != want {
+		t.Fatalf("wg.Count() = %v, want %v", got, want)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Millisecond)
+	defer cancel()
+	if err := wg.Wait(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("wg.Wait with nonzero count = %v, want context.DeadlineExceeded", err)
+	}
+	for i := 0; i < 2; i++ {
+		go func() {
+			time.Sleep(1 * time.Millisecond)
+			wg.Done()
+		}()
+	}
+	if err := wg.Wait(context.Background()); err != nil {
+		t.Fatalf("wg.Wait = %v, want nil", err)
+	}
+}
+
+func TestWaitGroupNegativeCount(t *testing.T) {
+	wg := gate.NewWaitGroup()
+	defer func() {
+		if recover() == nil {
+			t.Errorf("wg.Done with zero count did not panic")
+		}
+	}()
+	wg.Done()
+}
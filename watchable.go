@@ -0,0 +1,67 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate
+
+import "context"
+
+// A Watchable holds a value which may be watched for changes.
+//
+// Each Store increments the value's version.
+// Watchers pass the last version they observed to Wait,
+// so a change made between observing the value and calling Wait is never missed.
+type Watchable[T any] struct {
+	mu      Gate // guards the fields below
+	v       T
+	version uint64
+	changed chan struct{} // closed on the next Store
+}
+
+// NewWatchable returns a new Watchable holding v at version 0.
+func NewWatchable[T any](v T) *Watchable[T] {
+	return &Watchable[T]{
+		mu: New(false),
+		v:  v,
+	}
+}
+
+// Store sets the value, waking all watchers.
+// It returns the new version.
+func (w *Watchable[T]) Store(v T) (version uint64) {
+	w.mu.Lock()
+	defer w.mu.Unlock(false)
+	w.v = v
+	w.version++
+	if w.changed != nil {
+		close(w.changed)
+		w.changed = nil
+	}
+	return w.version
+}
+
+// Load returns the current value and its version.
+func (w *Watchable[T]) Load() (v T, version uint64) {
+	w.mu.Lock()
+	defer w.mu.Unlock(false)
+	return w.v, w.version
+}
+
+// Wait blocks until the version is greater than lastSeen or the context expires.
+// It returns the current value and version.
+func (w *Watchable[T]) Wait(ctx context.Context, lastSeen uint64) (v T, version uint64, err error) {
+	w.mu.Lock()
+	if w.version > lastSeen {
+		v, version = w.v, w.version
+		w.mu.Unlock(false)
+		return v, version, nil
+	}
+	if w.changed == nil {
+		w.changed = make(chan struct{})
+	}
+	changed := w.changed
+	w.mu.Unlock(false)
+	err = w.mu.WaitFor(ctx, changed)
+	v, version = w.Load()
+	return v, version, err
+}
@@ -0,0 +1,41 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/neild/gate"
+)
+
+func TestWatchable(t *testing.T) {
+	w := gate.NewWatchable("idle")
+	v, version := w.Load()
+	if v != "idle" || version != 0 {
+		t.Fatalf("w.Load() = %q, %v; want %q, 0", v, version, "idle")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Millisecond)
+	defer cancel()
+	if v, version, err := w.Wait(ctx, 0); v != "idle" || version != 0 || err != context.DeadlineExceeded {
+		t.Fatalf("w.Wait(0) = %q, %v, %v; want %q, 0, context.DeadlineExceeded", v, version, err, "idle")
+	}
+
+	go func() {
+		time.Sleep(1 * time.Millisecond)
+		w.Store("active")
+	}()
+	if v, version, err := w.Wait(context.Background(), 0); v != "active" || version != 1 || err != nil {
+		t.Fatalf("w.Wait(0) = %q, %v, %v; want %q, 1, nil", v, version, err, "active")
+	}
+
+	// A change made before Wait is called is not missed.
+	w.Store("closed")
+	if v, version, err := w.Wait(ctx, 1); v != "closed" || version != 2 || err != nil {
+		t.Fatalf("w.Wait(1) = %q, %v, %v; want %q, 2, nil", v, version, err, "closed")
+	}
+}
@@ -0,0 +1,129 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate
+
+import (
+	"context"
+	"slices"
+	"time"
+)
+
+// A Watchdog detects a stalled process, such as a wedged worker loop.
+//
+// The process calls Kick regularly.
+// If an interval passes without a kick, the watchdog expires
+// and calls its handlers.
+// A kick after expiry recovers the watchdog.
+type Watchdog struct {
+	gate      Gate // set if expired
+	alive     Gate // set if not expired; updated while gate is held
+	clock     Clock
+	interval  time.Duration
+	last      time.Time // time of last kick
+	expired   bool
+	scheduled bool // timer is running
+	stopped   bool
+	timer     Timer
+	handlers  []func()
+}
+
+// NewWatchdog returns a new watchdog which expires if interval passes without a kick.
+// The watchdog starts out as if Kick had just been called.
+func NewWatchdog(interval time.Duration, opts ...Option) *Watchdog {
+	o := newOptions(opts)
+	clock := o.clock
+	w := &Watchdog{
+		gate:      o.newGate(false, ""),
+		alive:     o.newGate(true, "alive"),
+		clock:     clock,
+		interval:  interval,
+		last:      clock.Now(),
+		scheduled: true,
+	}
+	w.timer = clock.AfterFunc(interval, w.check)
+	return w
+}
+
+// Kick resets the watchdog's interval, recovering it if it has expired.
+func (w *Watchdog) Kick() {
+	w.gate.Lock()
+	defer w.unlock()
+	w.last = w.clock.Now()
+	w.expired = false
+	if !w.scheduled {
+		w.scheduled = true
+		w.timer.Reset(w.interval)
+	}
+}
+
+// OnExpire registers a function to be called each time the watchdog expires.
+// The function is called without the watchdog's gate held.
+func (w *Watchdog) OnExpire(f func()) {
+	w.gate.Lock()
+	defer w.unlock()
+	w.handlers = append(slices.Clip(w.handlers), f)
+}
+
+// Expired reports whether the watchdog has expired.
+func (w *Watchdog) Expired() bool {
+	w.gate.Lock()
+	defer w.unlock()
+	return w.expired
+}
+
+// WaitExpired blocks until the watchdog has expired or the context expires.
+func (w *Watchdog) WaitExpired(ctx context.Context) error {
+	if err := w.gate.WaitAndLock(ctx); err != nil {
+		return err
+	}
+	w.unlock()
+	return nil
+}
+
+// WaitAlive blocks until the watchdog has not expired or the context expires.
+func (w *Watchdog) WaitAlive(ctx context.Context) error {
+	if err := w.alive.WaitAndLock(ctx); err != nil {
+		return err
+	}
+	w.alive.Unlock(true)
+	return nil
+}
+
+// Stop stops the watchdog's timer.
+// The watchdog does not expire after Stop is called.
+func (w *Watchdog) Stop() {
+	w.gate.Lock()
+	defer w.unlock()
+	w.timer.Stop()
+	w.stopped = true
+	w.scheduled = true // prevent Kick from restarting the timer
+}
+
+func (w *Watchdog) check() {
+	w.gate.Lock()
+	if w.stopped {
+		// Stop was called after the timer fired.
+		w.unlock()
+		return
+	}
+	if d := w.last.Add(w.interval).Sub(w.clock.Now()); d > 0 {
+		w.timer.Reset(d)
+		w.unlock()
+		return
+	}
+	w.scheduled = false
+	w.expired = true
+	handlers := w.handlers
+	w.unlock()
+	for _, f := range handlers {
+		f()
+	}
+}
+
+func (w *Watchdog) unlock() {
+	w.alive.Lock()
+	w.alive.Unlock(!w.expired)
+	w.gate.Unlock(w.expired)
+}
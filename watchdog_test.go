@@ -0,0 +1,70 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/neild/gate"
+)
+
+func TestWatchdog(t *testing.T) {
+	w := gate.NewWatchdog(20 * time.Millisecond)
+	defer w.Stop()
+	expiredc := make(chan struct{}, 1)
+	w.OnExpire(func() {
+		expiredc <- struct{}{}
+	})
+
+	// Kicking keeps the watchdog alive.
+	for i := 0; i < 5; i++ {
+		time.Sleep(1 * time.Millisecond)
+		w.Kick()
+	}
+	if expired := w.Expired(); expired {
+		t.Fatalf("w.Expired while kicked = %v, want false", expired)
+	}
+
+	if err := w.WaitExpired(context.Background()); err != nil {
+		t.Fatalf("w.WaitExpired = %v, want nil", err)
+	}
+	<-expiredc
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Millisecond)
+	defer cancel()
+	if err := w.WaitAlive(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("w.WaitAlive of expired watchdog = %v, want context.DeadlineExceeded", err)
+	}
+
+	// A kick recovers the watchdog.
+	donec := make(chan error)
+	go func() {
+		donec <- w.WaitAlive(context.Background())
+	}()
+	time.Sleep(1 * time.Millisecond)
+	w.Kick()
+	if err := <-donec; err != nil {
+		t.Fatalf("w.WaitAlive = %v, want nil", err)
+	}
+
+	// It expires again if kicks stop.
+	if err := w.WaitExpired(context.Background()); err != nil {
+		t.Fatalf("second w.WaitExpired = %v, want nil", err)
+	}
+	<-expiredc
+}
+
+func TestWatchdogStop(t *testing.T) {
+	clock := gate.NewFakeClock(time.Now())
+	w := gate.NewWatchdog(1*time.Minute, gate.WithClock(clock))
+	w.OnExpire(func() { t.Errorf("handler called after Stop") })
+	w.Stop()
+	w.Kick()
+	clock.Advance(2 * time.Minute)
+	if w.Expired() {
+		t.Fatalf("watchdog expired after Stop")
+	}
+}
@@ -0,0 +1,155 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate_test
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/neild/gate"
+)
+
+// A WeightedQueue is a queue bounded by the total weight of its items.
+type WeightedQueue[T any] struct {
+	gate   gate.Gate // set if queue is non-empty or closed
+	size   func(T) int64
+	max    int64
+	err    error
+	q      []T
+	weight int64         // total weight of items in q
+	drainc chan struct{} // closed when an item is removed or the queue is closed
+	reject func(v T, reason error)
+}
+
+// NewWeightedQueue returns a new queue holding items with a total weight of at most max.
+// The size function returns the weight of an item.
+func NewWeightedQueue[T any](max int64, size func(T) int64) *WeightedQueue[T] {
+	return &WeightedQueue[T]{
+		gate: gate.New(false),
+		size: size,
+		max:  max,
+	}
+}
+
+// Close closes the queue, causing pending and future operations
+// to return immediately with err.
+func (q *WeightedQueue[T]) Close(err error) {
+	q.gate.Lock()
+	defer q.unlock()
+	if q.err == nil {
+		q.err = err
+	}
+	q.drained()
+}
+
+// OnReject registers a function to be called with items which Put fails to add,
+// either because the queue is closed or because ctx expired while waiting for room.
+// The reason is the error returned by Put.
+// The function is called without the queue's gate held.
+func (q *WeightedQueue[T]) OnReject(f func(v T, reason error)) {
+	q.gate.Lock()
+	defer q.unlock()
+	q.reject = f
+}
+
+// Put appends an item to the queue, blocking until ctx is done,
+// there is room for the item, or the queue is closed.
+// An item weighing more than the queue's bound is only added when the queue is empty.
+func (q *WeightedQueue[T]) Put(ctx context.Context, v T) error {
+	w := q.size(v)
+	for {
+		q.gate.Lock()
+		if err := q.err; err != nil {
+			return q.unlockAndReject(v, err)
+		}
+		if q.weight+w <= q.max || len(q.q) == 0 {
+			q.q = append(q.q, v)
+			q.weight += w
+			q.unlock()
+			return nil
+		}
+		if q.drainc == nil {
+			q.drainc = make(chan struct{})
+		}
+		drainc := q.drainc
+		q.unlock()
+		select {
+		case <-drainc:
+		case <-ctx.Done():
+			q.gate.Lock()
+			return q.unlockAndReject(v, ctx.Err())
+		}
+	}
+}
+
+// Get removes the first item from the queue, blocking until ctx is done, an item is available,
+// or the queue is closed.
+func (q *WeightedQueue[T]) Get(ctx context.Context) (T, error) {
+	var zero T
+	if err := q.gate.WaitAndLock(ctx); err != nil {
+		return zero, err
+	}
+	defer q.unlock()
+	if q.err != nil {
+		return zero, q.err
+	}
+	v := q.q[0]
+	q.q = slices.Delete(q.q, 0, 1)
+	q.weight -= q.size(v)
+	q.drained()
+	return v, nil
+}
+
+// drained wakes any Put calls waiting for room in the queue.
+// The queue's gate must be held.
+func (q *WeightedQueue[T]) drained() {
+	if q.drainc != nil {
+		close(q.drainc)
+		q.drainc = nil
+	}
+}
+
+// unlockAndReject unlocks the queue's gate, passes v to the reject handler,
+// and returns err.
+func (q *WeightedQueue[T]) unlockAndReject(v T, err error) error {
+	reject := q.reject
+	q.unlock()
+	if reject != nil {
+		reject(v, err)
+	}
+	return err
+}
+
+// unlock unlocks the queue's gate,
+// setting the condition to true if the queue is non-empty or closed.
+func (q *WeightedQueue[T]) unlock() {
+	q.gate.Unlock(q.err != nil || len(q.q) > 0)
+}
+
+func Example_weightedQueue() {
+	// A queue of at most 8 bytes of data.
+	q := NewWeightedQueue(8, func(b []byte) int64 { return int64(len(b)) })
+
+	q.Put(context.Background(), []byte("hello"))
+
+	// There is no room for this item until the first one is removed.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	fmt.Println(q.Put(ctx, []byte("world")))
+
+	// This Put blocks until the Get below makes room.
+	putc := make(chan error)
+	go func() {
+		putc <- q.Put(context.Background(), []byte("world"))
+	}()
+	b, _ := q.Get(context.Background())
+	fmt.Printf("%q\n", b)
+	fmt.Println(<-putc)
+	// Output:
+	// context canceled
+	// "hello"
+	// <nil>
+}
@@ -0,0 +1,140 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate
+
+import (
+	"context"
+	"slices"
+	"time"
+)
+
+// A WeightedSemaphore is a semaphore which permits acquiring multiple units at once.
+//
+// Waiters are admitted in FIFO order: a large Acquire blocks later, smaller ones,
+// so it is not starved by a stream of them.
+type WeightedSemaphore struct {
+	gate    Gate // set if units are available and there are no waiters
+	size    int64
+	avail   int64
+	waiters []*weightedWaiter
+	obs     instanceObserver
+	clock   Clock // times waits for the observer
+}
+
+type weightedWaiter struct {
+	n     int64
+	start time.Time     // when the waiter began waiting
+	ready chan struct{} // closed when the units are granted
+}
+
+// NewWeightedSemaphore returns a new semaphore with size units, all available.
+// The options a weighted semaphore accepts are WithName, WithObserver, and WithClock.
+func NewWeightedSemaphore(size int64, opts ...Option) *WeightedSemaphore {
+	o := newOptions(opts)
+	return &WeightedSemaphore{
+		gate:  o.newGate(size > 0, ""),
+		size:  size,
+		avail: size,
+		obs:   instanceObserver{o.observer},
+		clock: o.clock,
+	}
+}
+
+// Acquire acquires n units, blocking until they are available.
+// If the context expires, Acquire returns an error and does not acquire any units.
+// Acquiring more units than the size of the semaphore fails when the context expires.
+func (s *WeightedSemaphore) Acquire(ctx context.Context, n int64) error {
+	s.gate.Lock()
+	if len(s.waiters) == 0 && s.avail >= n {
+		s.avail -= n
+		s.obs.count("weightedsemaphore.acquired", n)
+		s.unlock()
+		return nil
+	}
+	w := &weightedWaiter{
+		n:     n,
+		start: s.clock.Now(),
+		ready: make(chan struct{}),
+	}
+	s.waiters = append(s.waiters, w)
+	s.unlock()
+
+	if s.gate.WaitFor(ctx, w.ready) == nil {
+		return nil
+	}
+	s.gate.Lock()
+	defer s.unlock()
+	select {
+	case <-w.ready:
+		// The units were granted before we reacquired the gate.
+		return nil
+	default:
+	}
+	i := slices.Index(s.waiters, w)
+	s.waiters = slices.Delete(s.waiters, i, i+1)
+	if i == 0 {
+		// We were blocking the waiters behind us.
+		s.grant()
+	}
+	return ctx.Err()
+}
+
+// TryAcquire acquires n units if they are available without blocking.
+// It does not acquire units while other callers are waiting in Acquire.
+// It reports whether the units were acquired.
+func (s *WeightedSemaphore) TryAcquire(n int64) (acquired bool) {
+	if !s.gate.LockIfSet() {
+		return false
+	}
+	defer s.unlock()
+	if s.avail < n {
+		return false
+	}
+	s.avail -= n
+	s.obs.count("weightedsemaphore.acquired", n)
+	return true
+}
+
+// Release releases n units.
+// It panics if more units are released than are held.
+func (s *WeightedSemaphore) Release(n int64) {
+	s.gate.Lock()
+	defer s.unlock()
+	if s.avail+n > s.size {
+		panic("gate: WeightedSemaphore released more units than held")
+	}
+	s.avail += n
+	s.obs.count("weightedsemaphore.released", n)
+	s.grant()
+}
+
+// grant admits waiters from the front of the queue for as long as units are available.
+func (s *WeightedSemaphore) grant() {
+	for len(s.waiters) > 0 && s.avail >= s.waiters[0].n {
+		w := s.waiters[0]
+		s.waiters = slices.Delete(s.waiters, 0, 1)
+		s.avail -= w.n
+		s.obs.count("weightedsemaphore.acquired", w.n)
+		s.obs.timing("weightedsemaphore.wait", s.clock.Now().Sub(w.start))
+		close(w.ready)
+	}
+}
+
+// SetObserver sets the semaphore's observer.
+// The semaphore reports the "weightedsemaphore.acquired" and "weightedsemaphore.released"
+// counters of units, the "weightedsemaphore.wait" time of each Acquire which had to wait,
+// and the "weightedsemaphore.available" and "weightedsemaphore.waiters" gauges.
+// If no observer is set, counters and timings are reported to the package-wide observer.
+func (s *WeightedSemaphore) SetObserver(o Observer) {
+	s.gate.Lock()
+	defer s.unlock()
+	s.obs.o = o
+}
+
+func (s *WeightedSemaphore) unlock() {
+	s.obs.gauge("weightedsemaphore.available", s.avail)
+	s.obs.gauge("weightedsemaphore.waiters", int64(len(s.waiters)))
+	s.gate.Unlock(s.avail > 0 && len(s.waiters) == 0)
+}
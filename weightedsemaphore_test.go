@@ -0,0 +1,84 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/neild/gate"
+)
+
+func TestWeightedSemaphoreAcquireAndRelease(t *testing.T) {
+	s := gate.NewWeightedSemaphore(10)
+	if err := s.Acquire(context.Background(), 7); err != nil {
+		t.Fatalf("s.Acquire(7) = %v, want nil", err)
+	}
+	if acquired := s.TryAcquire(4); acquired {
+		t.Fatalf("s.TryAcquire(4) with 3 units available = %v, want false", acquired)
+	}
+	if acquired := s.TryAcquire(3); !acquired {
+		t.Fatalf("s.TryAcquire(3) with 3 units available = %v, want true", acquired)
+	}
+	s.Release(10)
+	if acquired := s.TryAcquire(10); !acquired {
+		t.Fatalf("s.TryAcquire(10) after Release = %v, want true", acquired)
+	}
+}
+
+func TestWeightedSemaphoreNoStarvation(t *testing.T) {
+	s := gate.NewWeightedSemaphore(10)
+	s.Acquire(context.Background(), 5)
+
+	// A large waiter blocks until all units are available.
+	bigc := make(chan error)
+	go func() {
+		bigc <- s.Acquire(context.Background(), 10)
+	}()
+	time.Sleep(1 * time.Millisecond)
+
+	// Small acquisitions don't jump ahead of it.
+	if acquired := s.TryAcquire(1); acquired {
+		t.Fatalf("s.TryAcquire(1) with a blocked waiter = %v, want false", acquired)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Millisecond)
+	defer cancel()
+	if err := s.Acquire(ctx, 1); err != context.DeadlineExceeded {
+		t.Fatalf("s.Acquire(1) with a blocked waiter = %v, want context.DeadlineExceeded", err)
+	}
+
+	s.Release(5)
+	if err := <-bigc; err != nil {
+		t.Fatalf("s.Acquire(10) = %v, want nil", err)
+	}
+}
+
+func TestWeightedSemaphoreCanceledWaiterUnblocksOthers(t *testing.T) {
+	s := gate.NewWeightedSemaphore(10)
+	s.Acquire(context.Background(), 5)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	bigc := make(chan error)
+	go func() {
+		bigc <- s.Acquire(ctx, 10)
+	}()
+	time.Sleep(1 * time.Millisecond)
+	smallc := make(chan error)
+	go func() {
+		smallc <- s.Acquire(context.Background(), 5)
+	}()
+	time.Sleep(1 * time.Millisecond)
+
+	// When the waiter at the head of the queue gives up,
+	// the one behind it is admitted.
+	cancel()
+	if err := <-bigc; err != context.Canceled {
+		t.Fatalf("s.Acquire(10) = %v, want context.Canceled", err)
+	}
+	if err := <-smallc; err != nil {
+		t.Fatalf("s.Acquire(5) = %v, want nil", err)
+	}
+}
@@ -0,0 +1,126 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate
+
+import (
+	"context"
+	"errors"
+	"slices"
+)
+
+// ErrShutdown is returned when submitting work to a WorkerPool which has been shut down.
+var ErrShutdown = errors.New("gate: worker pool shut down")
+
+// A WorkerPool runs tasks on a set of worker goroutines.
+// The number of workers may be changed at any time.
+type WorkerPool struct {
+	gate     Gate // set if tasks are queued, there are too many workers, or the pool is shut down
+	max      int  // maximum queued tasks
+	tasks    []func()
+	workers  int // running workers
+	target   int // desired number of workers
+	shutdown bool
+	roomc    chan struct{} // closed when a task is dequeued or the pool is shut down
+	stopped  *Event        // set when the pool is shut down and all workers have exited
+}
+
+// NewWorkerPool returns a new pool with the given number of workers,
+// which queues at most queueSize tasks waiting for a worker.
+// Both workers and queueSize must be positive.
+// The options a worker pool accepts are WithName, WithObserver, and WithClock.
+func NewWorkerPool(workers, queueSize int, opts ...Option) *WorkerPool {
+	if workers <= 0 {
+		panic("gate: worker pool must have at least one worker")
+	}
+	if queueSize <= 0 {
+		panic("gate: worker pool queue size must be positive")
+	}
+	p := &WorkerPool{
+		gate:    newOptions(opts).newGate(false, ""),
+		max:     queueSize,
+		stopped: NewEvent(),
+	}
+	p.SetWorkers(workers)
+	return p
+}
+
+// Submit queues a task to be run by a worker.
+// It blocks until there is room in the queue or the context expires.
+// It returns ErrShutdown if the pool has been shut down.
+func (p *WorkerPool) Submit(ctx context.Context, task func()) error {
+	for {
+		p.gate.Lock()
+		if p.shutdown {
+			p.unlock()
+			return ErrShutdown
+		}
+		if len(p.tasks) < p.max {
+			p.tasks = append(p.tasks, task)
+			p.unlock()
+			return nil
+		}
+		if p.roomc == nil {
+			p.roomc = make(chan struct{})
+		}
+		roomc := p.roomc
+		p.unlock()
+		if err := p.gate.WaitFor(ctx, roomc); err != nil {
+			return err
+		}
+	}
+}
+
+// SetWorkers changes the number of workers.
+// When the number is reduced, excess workers exit after finishing their current task.
+// A pool with no workers runs no tasks, even after it is shut down.
+func (p *WorkerPool) SetWorkers(n int) {
+	p.gate.Lock()
+	defer p.unlock()
+	p.target = n
+	for ; p.workers < p.target; p.workers++ {
+		go p.work()
+	}
+}
+
+// Shutdown stops the pool from accepting new tasks,
+// and waits for queued and running tasks to finish or the context to expire.
+// Tasks continue to run after the context expires.
+func (p *WorkerPool) Shutdown(ctx context.Context) error {
+	p.gate.Lock()
+	p.shutdown = true
+	p.wakeSubmitters()
+	p.unlock()
+	return p.stopped.Wait(ctx)
+}
+
+func (p *WorkerPool) work() {
+	for {
+		p.gate.WaitAndLock(context.Background())
+		if p.workers > p.target || (p.shutdown && len(p.tasks) == 0) {
+			p.workers--
+			p.unlock()
+			return
+		}
+		task := p.tasks[0]
+		p.tasks = slices.Delete(p.tasks, 0, 1)
+		p.wakeSubmitters()
+		p.unlock()
+		task()
+	}
+}
+
+func (p *WorkerPool) wakeSubmitters() {
+	if p.roomc != nil {
+		close(p.roomc)
+		p.roomc = nil
+	}
+}
+
+func (p *WorkerPool) unlock() {
+	if p.shutdown && p.workers == 0 && len(p.tasks) == 0 {
+		p.stopped.Set()
+	}
+	p.gate.Unlock(len(p.tasks) > 0 || p.workers > p.target || p.shutdown)
+}
@@ -0,0 +1,96 @@
+// Copyright 2024 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package gate_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/neild/gate"
+)
+
+func TestWorkerPoolRunsTasks(t *testing.T) {
+	p := gate.NewWorkerPool(2, 10)
+	var ran atomic.Int32
+	for i := 0; i < 10; i++ {
+		if err := p.Submit(context.Background(), func() {
+			ran.Add(1)
+		}); err != nil {
+			t.Fatalf("p.Submit = %v, want nil", err)
+		}
+	}
+	if err := p.Shutdown(context.Background()); err != nil {
+		t.Fatalf("p.Shutdown = %v, want nil", err)
+	}
+	if got := ran.Load(); got != 10 {
+		t.Fatalf("%v tasks ran before Shutdown returned, want 10", got)
+	}
+	if err := p.Submit(context.Background(), func() {}); err != gate.ErrShutdown {
+		t.Fatalf("p.Submit after Shutdown = %v, want ErrShutdown", err)
+	}
+}
+
+func TestWorkerPoolSubmitBlocks(t *testing.T) {
+	p := gate.NewWorkerPool(1, 1)
+	releasec := make(chan struct{})
+	startedc := make(chan struct{})
+	p.Submit(context.Background(), func() {
+		close(startedc)
+		<-releasec
+	})
+	<-startedc
+	p.Submit(context.Background(), func() {})
+
+	// The worker is busy and the queue is full.
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Millisecond)
+	defer cancel()
+	if err := p.Submit(ctx, func() {}); err != context.DeadlineExceeded {
+		t.Fatalf("p.Submit to full pool = %v, want context.DeadlineExceeded", err)
+	}
+	if err := p.Shutdown(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("p.Shutdown with running task = %v, want context.DeadlineExceeded", err)
+	}
+	close(releasec)
+	if err := p.Shutdown(context.Background()); err != nil {
+		t.Fatalf("p.Shutdown = %v, want nil", err)
+	}
+}
+
+func TestWorkerPoolSetWorkers(t *testing.T) {
+	p := gate.NewWorkerPool(1, 1)
+	p.SetWorkers(0)
+	ranc := make(chan struct{})
+	p.Submit(context.Background(), func() {
+		close(ranc)
+	})
+	select {
+	case <-ranc:
+		t.Fatalf("task ran with no workers")
+	case <-time.After(1 * time.Millisecond):
+	}
+	p.SetWorkers(1)
+	<-ranc
+	p.Shutdown(context.Background())
+}
+
+func TestWorkerPoolInvalid(t *testing.T) {
+	for _, test := range []struct {
+		workers, queueSize int
+	}{
+		{0, 1},
+		{1, 0},
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("gate.NewWorkerPool(%v, %v) did not panic", test.workers, test.queueSize)
+				}
+			}()
+			gate.NewWorkerPool(test.workers, test.queueSize)
+		}()
+	}
+}